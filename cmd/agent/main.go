@@ -44,12 +44,16 @@ func main_impl() error {
 		IsUnencumbered:               false,
 		UnencumberData:               unencumberData,
 		OpenAIKey:                    output.OpenAIKey,
+		ModelRoutes:                  output.ModelRoutes,
+		FallbackModel:                output.FallbackModel,
 		StarknetRpcUrls:              output.StarknetRpcUrls,
 		DstackTappdEndpoint:          output.DstackTappdEndpoint,
 		StarknetPrivateKeySeed:       output.StarknetPrivateKeySeed,
 		AgentRegistryAddress:         output.AgentRegistryAddress,
 		AgentRegistryDeploymentBlock: output.AgentRegistryDeploymentBlock,
 		TaskConcurrency:              10,
+		MaxPromptsPerUser:            1,
+		MaxPromptsPerAgent:           3,
 		TickRate:                     10 * time.Second,
 		SafeBlockDelta:               0,
 		MaxSystemPromptTokens:        800,
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/agent"
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
+	"github.com/NethermindEth/teeception/pkg/agent/outbox"
+	"github.com/NethermindEth/teeception/pkg/agent/validation"
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/network"
+	"github.com/NethermindEth/teeception/pkg/twitter"
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+// ReplayReport is the output of a replay run.
+type ReplayReport struct {
+	Prompts int             `json:"prompts"`
+	Drains  int             `json:"drains"`
+	Errors  int             `json:"errors"`
+	Results []*ReplayResult `json:"results"`
+}
+
+type replayParams struct {
+	eventsFile      string
+	providerURLs    []string
+	registryAddr    string
+	fromBlock       uint64
+	toBlock         uint64
+	agentsFile      string
+	responsesFile   string
+	openAIKey       string
+	modelRoutes     string
+	model           string
+	twitterUsername string
+	outputFile      string
+	network         string
+}
+
+func main() {
+	params := &replayParams{}
+
+	rootCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay historical PromptPaid events through the agent without side effects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (params.eventsFile == "" && len(params.providerURLs) == 0) || params.twitterUsername == "" {
+				return cmd.Help()
+			}
+
+			return runReplay(context.Background(), params)
+		},
+	}
+
+	rootCmd.Flags().StringVar(&params.eventsFile, "events", "", "JSON export of emitted events to replay")
+	rootCmd.Flags().StringArrayVar(&params.providerURLs, "provider-url", nil, "Starknet provider URL used to fetch events and agents (can be specified multiple times)")
+	rootCmd.Flags().StringVar(&params.registryAddr, "registry-addr", "", "Agent registry contract address, required to fetch agents from the provider")
+	rootCmd.Flags().Uint64Var(&params.fromBlock, "from-block", 0, "First block to replay")
+	rootCmd.Flags().Uint64Var(&params.toBlock, "to-block", 0, "Last block to replay (0 for no limit with --events)")
+	rootCmd.Flags().StringVar(&params.agentsFile, "agents", "", "JSON export of agents, used instead of fetching them from the provider")
+	rootCmd.Flags().StringVar(&params.responsesFile, "responses", "", "JSON file of recorded LLM responses, used instead of live models")
+	rootCmd.Flags().StringVar(&params.openAIKey, "openai-key", os.Getenv("OPENAI_API_KEY"), "OpenAI API key for live models")
+	rootCmd.Flags().StringVar(&params.modelRoutes, "model-routes", os.Getenv("MODEL_ROUTES"), "JSON model routes for live models")
+	rootCmd.Flags().StringVar(&params.model, "model", "", "Override the model of every agent")
+	rootCmd.Flags().StringVar(&params.twitterUsername, "twitter-username", "", "Twitter username of the agent being replayed")
+	rootCmd.Flags().StringVar(&params.outputFile, "output", "", "Report output file (defaults to stdout)")
+	rootCmd.Flags().StringVar(&params.network, "network", network.NameSepolia, "Network profile used for explorer links in replies (mainnet, sepolia or devnet)")
+
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func runReplay(ctx context.Context, params *replayParams) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var client snaccount.ProviderWrapper
+	if len(params.providerURLs) > 0 {
+		providers := make([]rpc.RpcProvider, 0, len(params.providerURLs))
+		for _, url := range params.providerURLs {
+			provider, err := rpc.NewProvider(url)
+			if err != nil {
+				return fmt.Errorf("failed to create RPC client for %s: %v", url, err)
+			}
+			providers = append(providers, provider)
+		}
+
+		var err error
+		client, err = snaccount.NewRateLimitedMultiProvider(snaccount.RateLimitedMultiProviderConfig{
+			Providers: providers,
+			Limiter:   nil,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create rate limited client: %v", err)
+		}
+	}
+
+	var events []rpc.EmittedEvent
+	var err error
+	if params.eventsFile != "" {
+		events, err = loadEventExport(params.eventsFile)
+	} else {
+		if params.toBlock == 0 {
+			return fmt.Errorf("--to-block is required when fetching events from a provider")
+		}
+		events, err = fetchEvents(ctx, client, params.fromBlock, params.toBlock)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load events: %v", err)
+	}
+
+	prompts := filterPromptPaid(events, params.fromBlock, params.toBlock)
+	slog.Info("loaded prompts to replay", "count", len(prompts))
+
+	getAgentInfo, err := newAgentInfoGetter(params, client)
+	if err != nil {
+		return err
+	}
+
+	var modelOverride *felt.Felt
+	if params.model != "" {
+		modelOverride, err = snaccount.ShortStringToFelt(params.model)
+		if err != nil {
+			return fmt.Errorf("invalid model: %v", err)
+		}
+	}
+
+	var recorded *recordedChatCompletion
+	var chatCompletion chat.ChatCompletion
+	if params.responsesFile != "" {
+		var responses []RecordedResponse
+		if err := readJSONFile(params.responsesFile, &responses); err != nil {
+			return fmt.Errorf("failed to load recorded responses: %v", err)
+		}
+		recorded = newRecordedChatCompletion(responses)
+		chatCompletion = recorded
+	} else {
+		var modelRoutes map[string]chat.BackendConfig
+		if params.modelRoutes != "" {
+			if err := json.Unmarshal([]byte(params.modelRoutes), &modelRoutes); err != nil {
+				return fmt.Errorf("failed to parse model routes: %v", err)
+			}
+		}
+
+		modelRouterChatCompletion, err := agent.NewModelRouterChatCompletion(&agent.AgentConfigParams{
+			OpenAIKey:   params.openAIKey,
+			ModelRoutes: modelRoutes,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create model router chat completion: %v", err)
+		}
+
+		chatCompletion, err = chat.NewTokenLimitChatCompletion(modelRouterChatCompletion, 800, -1)
+		if err != nil {
+			return fmt.Errorf("failed to create token limit chat completion: %v", err)
+		}
+	}
+
+	rec := &recorder{}
+	twitterClient := &recordingTwitterClient{recorder: rec}
+
+	replyOutbox := outbox.NewOutbox(&outbox.OutboxConfig{
+		TwitterClient: twitterClient,
+		TickRate:      10 * time.Millisecond,
+	})
+	go replyOutbox.Run(ctx)
+
+	nameCache := validation.NewNameCache(chatCompletion)
+	go nameCache.Run(ctx)
+
+	account, err := snaccount.NewStarknetAccount(snaccount.NewPrivateKey([]byte("replay")))
+	if err != nil {
+		return fmt.Errorf("failed to create account: %v", err)
+	}
+
+	networkProfile, err := network.Get(params.network)
+	if err != nil {
+		return fmt.Errorf("failed to load network profile: %v", err)
+	}
+
+	replayAgent, err := agent.NewAgent(&agent.AgentConfig{
+		TwitterClient: twitterClient,
+		TwitterClientConfig: &twitter.TwitterClientConfig{
+			Username: params.twitterUsername,
+		},
+		ChatCompletion: chatCompletion,
+		NameCache:      nameCache,
+		Outbox:         replyOutbox,
+		Network:        networkProfile,
+		Account:        account,
+		PromptConsumer: &recordingPromptConsumer{recorder: rec},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create agent: %v", err)
+	}
+
+	report := &ReplayReport{
+		Results: make([]*ReplayResult, 0, len(prompts)),
+	}
+
+	for _, prompt := range prompts {
+		result := &ReplayResult{
+			AgentAddress: prompt.AgentAddress.String(),
+			Block:        prompt.Block,
+			PromptID:     prompt.Event.PromptID,
+			TweetID:      prompt.Event.TweetID,
+			User:         prompt.Event.User.String(),
+			Prompt:       prompt.Event.Prompt,
+			Tweets:       []string{},
+			Replies:      []string{},
+		}
+		report.Results = append(report.Results, result)
+		report.Prompts++
+
+		agentInfo, err := getAgentInfo(ctx, prompt.AgentAddress, prompt.Block)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to get agent info: %v", err)
+			report.Errors++
+			continue
+		}
+		if modelOverride != nil {
+			agentInfo.Model = modelOverride
+		}
+
+		result.AgentName = agentInfo.Name
+		result.Model = snaccount.FeltToShortString(agentInfo.Model)
+
+		tweetText := fmt.Sprintf("@%s :%s: %s", params.twitterUsername, agentInfo.Name, prompt.Event.Prompt)
+		rec.start(result, tweetText)
+		if recorded != nil {
+			recorded.setCurrent(prompt.AgentAddress, prompt.Event.PromptID)
+		}
+
+		err = replayAgent.ReplayPrompt(ctx, &agentInfo, prompt.Event, prompt.Block)
+		if err != nil {
+			result.Error = err.Error()
+			report.Errors++
+		}
+
+		if err := waitForOutbox(ctx, replyOutbox); err != nil {
+			return err
+		}
+		rec.start(nil, "")
+
+		if result.Drained {
+			report.Drains++
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if params.outputFile != "" {
+		file, err := os.Create(params.outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+
+	slog.Info("replay finished", "prompts", report.Prompts, "drains", report.Drains, "errors", report.Errors)
+
+	return nil
+}
+
+// waitForOutbox waits until the outbox delivered the tweets of the prompt
+// being replayed, so that they are recorded against it.
+func waitForOutbox(ctx context.Context, replyOutbox *outbox.Outbox) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for len(replyOutbox.Pending()) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+type agentInfoGetter func(ctx context.Context, addr *felt.Felt, block uint64) (indexer.AgentInfo, error)
+
+func newAgentInfoGetter(params *replayParams, client snaccount.ProviderWrapper) (agentInfoGetter, error) {
+	if params.agentsFile != "" {
+		agents, err := loadAgentExport(params.agentsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load agents: %v", err)
+		}
+
+		return func(ctx context.Context, addr *felt.Felt, block uint64) (indexer.AgentInfo, error) {
+			info, ok := agents[addr.Bytes()]
+			if !ok {
+				return indexer.AgentInfo{}, fmt.Errorf("agent not found in export")
+			}
+			return info, nil
+		}, nil
+	}
+
+	if client == nil || params.registryAddr == "" {
+		return nil, fmt.Errorf("either --agents or --provider-url and --registry-addr are required")
+	}
+
+	registryAddress, err := new(felt.Felt).SetString(params.registryAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry address: %v", err)
+	}
+
+	eventWatcher, err := indexer.NewEventWatcher(&indexer.EventWatcherConfig{
+		Client:          client,
+		RegistryAddress: registryAddress,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event watcher: %v", err)
+	}
+
+	agentIndexer := indexer.NewAgentIndexer(&indexer.AgentIndexerConfig{
+		Client:             client,
+		RegistryAddress:    registryAddress,
+		EventWatcher:       eventWatcher,
+		FetchUnknownAgents: true,
+	})
+
+	return agentIndexer.GetOrFetchAgentInfo, nil
+}
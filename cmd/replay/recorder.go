@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent"
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
+	"github.com/NethermindEth/teeception/pkg/agent/journal"
+	"github.com/NethermindEth/teeception/pkg/twitter"
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+// ReplayResult is what the agent would have done for a single prompt.
+type ReplayResult struct {
+	AgentAddress string   `json:"agent_address"`
+	AgentName    string   `json:"agent_name"`
+	Model        string   `json:"model"`
+	Block        uint64   `json:"block"`
+	PromptID     uint64   `json:"prompt_id"`
+	TweetID      uint64   `json:"tweet_id"`
+	User         string   `json:"user"`
+	Prompt       string   `json:"prompt"`
+	Consumed     bool     `json:"consumed"`
+	Drained      bool     `json:"drained"`
+	DrainTo      string   `json:"drain_to,omitempty"`
+	Tweets       []string `json:"tweets"`
+	Replies      []string `json:"replies"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// recorder collects the side effects of the prompt currently being replayed.
+type recorder struct {
+	mu        sync.Mutex
+	current   *ReplayResult
+	tweetText string
+}
+
+func (r *recorder) start(result *ReplayResult, tweetText string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current = result
+	r.tweetText = tweetText
+}
+
+func (r *recorder) record(f func(result *ReplayResult)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != nil {
+		f(r.current)
+	}
+}
+
+// recordingTwitterClient records tweets and replies instead of posting them.
+type recordingTwitterClient struct {
+	recorder *recorder
+}
+
+var _ twitter.TwitterClient = (*recordingTwitterClient)(nil)
+
+func (c *recordingTwitterClient) Initialize(config *twitter.TwitterClientConfig) error {
+	return nil
+}
+
+func (c *recordingTwitterClient) GetTweetText(tweetID uint64) (string, error) {
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+
+	return c.recorder.tweetText, nil
+}
+
+func (c *recordingTwitterClient) ReplyToTweet(tweetID uint64, reply string) error {
+	c.recorder.record(func(result *ReplayResult) {
+		result.Replies = append(result.Replies, reply)
+	})
+	return nil
+}
+
+func (c *recordingTwitterClient) SendTweet(tweet string) error {
+	c.recorder.record(func(result *ReplayResult) {
+		result.Tweets = append(result.Tweets, tweet)
+	})
+	return nil
+}
+
+// recordingPromptConsumer records consume_prompt calls instead of submitting them.
+type recordingPromptConsumer struct {
+	recorder *recorder
+}
+
+var _ agent.PromptConsumer = (*recordingPromptConsumer)(nil)
+
+func (c *recordingPromptConsumer) EnqueueConsumePrompt(ctx context.Context, agentAddress *felt.Felt, promptID uint64, drainTo *felt.Felt) (chan *snaccount.TxQueueResult, error) {
+	c.recorder.record(func(result *ReplayResult) {
+		result.Consumed = true
+		if !drainTo.Equal(agentAddress) {
+			result.Drained = true
+			result.DrainTo = drainTo.String()
+		}
+	})
+
+	ch := make(chan *snaccount.TxQueueResult, 1)
+	ch <- &snaccount.TxQueueResult{TransactionHash: new(felt.Felt)}
+	return ch, nil
+}
+
+// RecordedResponse is an LLM response captured from a previous run.
+type RecordedResponse struct {
+	AgentAddress *felt.Felt `json:"agent_address"`
+	PromptID     uint64     `json:"prompt_id"`
+	Response     string     `json:"response"`
+	DrainAddress string     `json:"drain_address,omitempty"`
+}
+
+// recordedChatCompletion answers with recorded responses for the prompt
+// currently being replayed.
+type recordedChatCompletion struct {
+	mu        sync.Mutex
+	responses map[journal.Key]RecordedResponse
+	current   journal.Key
+}
+
+var _ chat.ChatCompletion = (*recordedChatCompletion)(nil)
+
+func newRecordedChatCompletion(responses []RecordedResponse) *recordedChatCompletion {
+	c := &recordedChatCompletion{
+		responses: make(map[journal.Key]RecordedResponse, len(responses)),
+	}
+	for _, resp := range responses {
+		c.responses[journal.NewKey(resp.AgentAddress, resp.PromptID)] = resp
+	}
+	return c
+}
+
+func (c *recordedChatCompletion) setCurrent(agentAddress *felt.Felt, promptID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.current = journal.NewKey(agentAddress, promptID)
+}
+
+func (c *recordedChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*chat.ChatCompletionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, ok := c.responses[c.current]
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for prompt %d", c.current.PromptID)
+	}
+
+	result := &chat.ChatCompletionResponse{
+		Response: resp.Response,
+	}
+	if resp.DrainAddress != "" {
+		result.Drain = &chat.ChatCompletionDrainCall{
+			Address: resp.DrainAddress,
+		}
+	}
+
+	return result, nil
+}
+
+func (c *recordedChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	return true, nil
+}
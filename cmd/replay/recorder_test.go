@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+func TestRecordingClients(t *testing.T) {
+	rec := &recorder{}
+	twitterClient := &recordingTwitterClient{recorder: rec}
+	promptConsumer := &recordingPromptConsumer{recorder: rec}
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	// Side effects outside of a replayed prompt are dropped.
+	twitterClient.ReplyToTweet(1, "dropped")
+
+	result := &ReplayResult{Tweets: []string{}, Replies: []string{}}
+	rec.start(result, "@agent :name: prompt")
+
+	tweetText, err := twitterClient.GetTweetText(1)
+	if err != nil || tweetText != "@agent :name: prompt" {
+		t.Errorf("unexpected tweet text %q: %v", tweetText, err)
+	}
+
+	resultCh, err := promptConsumer.EnqueueConsumePrompt(context.Background(), agentAddress, 1, new(felt.Felt).SetUint64(2))
+	if err != nil {
+		t.Fatalf("failed to consume prompt: %v", err)
+	}
+	if res := <-resultCh; res.TransactionHash == nil {
+		t.Error("expected a transaction hash")
+	}
+
+	twitterClient.SendTweet("drained")
+	twitterClient.ReplyToTweet(1, "reply")
+	rec.start(nil, "")
+
+	if !result.Consumed || !result.Drained || result.DrainTo != "0x2" {
+		t.Errorf("expected prompt to be consumed as a drain to 0x2, got %+v", result)
+	}
+	if len(result.Tweets) != 1 || result.Tweets[0] != "drained" {
+		t.Errorf("unexpected tweets: %v", result.Tweets)
+	}
+	if len(result.Replies) != 1 || result.Replies[0] != "reply" {
+		t.Errorf("unexpected replies: %v", result.Replies)
+	}
+
+	result = &ReplayResult{}
+	rec.start(result, "")
+	if _, err := promptConsumer.EnqueueConsumePrompt(context.Background(), agentAddress, 2, agentAddress); err != nil {
+		t.Fatalf("failed to consume prompt: %v", err)
+	}
+	if !result.Consumed || result.Drained {
+		t.Errorf("expected prompt to be consumed without a drain, got %+v", result)
+	}
+}
+
+func TestRecordedChatCompletion(t *testing.T) {
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	chatCompletion := newRecordedChatCompletion([]RecordedResponse{
+		{AgentAddress: agentAddress, PromptID: 1, Response: "no"},
+		{AgentAddress: agentAddress, PromptID: 2, Response: "yes", DrainAddress: "0x2"},
+	})
+
+	chatCompletion.setCurrent(agentAddress, 1)
+	resp, err := chatCompletion.Prompt(context.Background(), "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Response != "no" || resp.Drain != nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	chatCompletion.setCurrent(agentAddress, 2)
+	resp, err = chatCompletion.Prompt(context.Background(), "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Drain == nil || resp.Drain.Address != "0x2" {
+		t.Errorf("expected a drain to 0x2, got %+v", resp)
+	}
+
+	chatCompletion.setCurrent(agentAddress, 3)
+	if _, err := chatCompletion.Prompt(context.Background(), "", "", ""); err == nil {
+		t.Error("expected an error without a recorded response")
+	}
+}
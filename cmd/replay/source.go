@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+// promptPaid is a PromptPaid event along with where it was emitted.
+type promptPaid struct {
+	AgentAddress *felt.Felt
+	Block        uint64
+	Event        *indexer.PromptPaidEvent
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// loadEventExport reads a JSON array of emitted events, as returned by starknet_getEvents.
+func loadEventExport(path string) ([]rpc.EmittedEvent, error) {
+	var events []rpc.EmittedEvent
+	if err := readJSONFile(path, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func fetchEvents(ctx context.Context, client snaccount.ProviderWrapper, fromBlock, toBlock uint64) ([]rpc.EmittedEvent, error) {
+	var events []rpc.EmittedEvent
+	continuationToken := ""
+
+	for {
+		var eventsResp *rpc.EventChunk
+		var err error
+
+		if err := client.Do(func(provider rpc.RpcProvider) error {
+			eventsResp, err = provider.Events(ctx, rpc.EventsInput{
+				EventFilter: rpc.EventFilter{
+					FromBlock: rpc.WithBlockNumber(fromBlock),
+					ToBlock:   rpc.WithBlockNumber(toBlock),
+					Keys:      [][]*felt.Felt{indexer.EventSelectors},
+				},
+				ResultPageRequest: rpc.ResultPageRequest{
+					ContinuationToken: continuationToken,
+					ChunkSize:         1000,
+				},
+			})
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("failed to get events from %d to %d: %w", fromBlock, toBlock, snaccount.FormatRpcError(err))
+		}
+
+		events = append(events, eventsResp.Events...)
+
+		continuationToken = eventsResp.ContinuationToken
+		if continuationToken == "" {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// filterPromptPaid parses the PromptPaid events within [fromBlock, toBlock].
+// A toBlock of 0 means no upper bound.
+func filterPromptPaid(events []rpc.EmittedEvent, fromBlock, toBlock uint64) []promptPaid {
+	prompts := make([]promptPaid, 0)
+	for _, raw := range events {
+		if raw.BlockNumber < fromBlock || (toBlock != 0 && raw.BlockNumber > toBlock) {
+			continue
+		}
+
+		ev, ok := indexer.ParseEvent(raw)
+		if !ok {
+			continue
+		}
+
+		promptPaidEvent, ok := ev.ToPromptPaidEvent()
+		if !ok {
+			continue
+		}
+
+		prompts = append(prompts, promptPaid{
+			AgentAddress: raw.FromAddress,
+			Block:        raw.BlockNumber,
+			Event:        promptPaidEvent,
+		})
+	}
+	return prompts
+}
+
+// AgentExport describes an agent in an agents export file.
+type AgentExport struct {
+	Address      *felt.Felt `json:"address"`
+	Creator      *felt.Felt `json:"creator"`
+	Name         string     `json:"name"`
+	SystemPrompt string     `json:"system_prompt"`
+	PromptPrice  string     `json:"prompt_price"`
+	TokenAddress *felt.Felt `json:"token_address"`
+	EndTime      uint64     `json:"end_time"`
+	Model        string     `json:"model"`
+}
+
+func loadAgentExport(path string) (map[[32]byte]indexer.AgentInfo, error) {
+	var agents []AgentExport
+	if err := readJSONFile(path, &agents); err != nil {
+		return nil, err
+	}
+
+	infos := make(map[[32]byte]indexer.AgentInfo, len(agents))
+	for _, agent := range agents {
+		promptPrice, ok := new(big.Int).SetString(agent.PromptPrice, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid prompt price for agent %s: %q", agent.Address, agent.PromptPrice)
+		}
+
+		model, err := snaccount.ShortStringToFelt(agent.Model)
+		if err != nil {
+			return nil, fmt.Errorf("invalid model for agent %s: %v", agent.Address, err)
+		}
+
+		infos[agent.Address.Bytes()] = indexer.AgentInfo{
+			Address:      agent.Address,
+			Creator:      agent.Creator,
+			Name:         agent.Name,
+			SystemPrompt: agent.SystemPrompt,
+			PromptPrice:  promptPrice,
+			TokenAddress: agent.TokenAddress,
+			EndTime:      agent.EndTime,
+			Model:        model,
+		}
+	}
+
+	return infos, nil
+}
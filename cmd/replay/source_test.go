@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+func writeJSONFile(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadAndFilterPromptPaid(t *testing.T) {
+	promptPaid := starknetgoutils.GetSelectorFromNameFelt("PromptPaid").String()
+	unknown := starknetgoutils.GetSelectorFromNameFelt("Unknown").String()
+
+	event := func(block uint64, keys ...string) map[string]any {
+		return map[string]any{
+			"from_address":     "0xa",
+			"keys":             keys,
+			"data":             []string{"0x0", "0x6869", "0x2"},
+			"block_hash":       "0x1",
+			"block_number":     block,
+			"transaction_hash": "0x2",
+		}
+	}
+
+	path := writeJSONFile(t, []map[string]any{
+		event(5, promptPaid, "0xb", "0x7", "0x2a"),
+		event(6, unknown, "0xb", "0x8", "0x2b"),
+		event(7, promptPaid, "0xb"),
+		event(20, promptPaid, "0xb", "0x9", "0x2c"),
+	})
+
+	events, err := loadEventExport(path)
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	prompts := filterPromptPaid(events, 1, 10)
+	if len(prompts) != 1 {
+		t.Fatalf("expected 1 prompt in range, got %d", len(prompts))
+	}
+
+	prompt := prompts[0]
+	if prompt.AgentAddress.String() != "0xa" || prompt.Block != 5 {
+		t.Errorf("unexpected prompt origin: agent %s, block %d", prompt.AgentAddress, prompt.Block)
+	}
+	if prompt.Event.User.String() != "0xb" || prompt.Event.PromptID != 7 || prompt.Event.TweetID != 42 || prompt.Event.Prompt != "hi" {
+		t.Errorf("unexpected prompt: %+v", prompt.Event)
+	}
+
+	if prompts := filterPromptPaid(events, 0, 0); len(prompts) != 2 {
+		t.Errorf("expected 2 prompts without an upper bound, got %d", len(prompts))
+	}
+}
+
+func TestLoadAgentExport(t *testing.T) {
+	agent := AgentExport{
+		Name:        "agent",
+		PromptPrice: "0x10",
+		EndTime:     100,
+		Model:       "gpt-4o",
+	}
+	agent.Address, _ = starknetgoutils.HexToFelt("0xa")
+
+	infos, err := loadAgentExport(writeJSONFile(t, []AgentExport{agent}))
+	if err != nil {
+		t.Fatalf("failed to load agents: %v", err)
+	}
+
+	info, ok := infos[agent.Address.Bytes()]
+	if !ok {
+		t.Fatal("expected agent to be loaded")
+	}
+	if info.PromptPrice.Int64() != 16 || info.EndTime != 100 || info.Name != "agent" {
+		t.Errorf("unexpected agent info: %+v", info)
+	}
+	if model := snaccount.FeltToShortString(info.Model); model != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %s", model)
+	}
+
+	agent.PromptPrice = "lots"
+	if _, err := loadAgentExport(writeJSONFile(t, []AgentExport{agent})); err == nil {
+		t.Error("expected an error for an invalid prompt price")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"os"
@@ -12,17 +13,21 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/rpc"
 
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/indexer/price"
+	"github.com/NethermindEth/teeception/pkg/network"
 	uiservice "github.com/NethermindEth/teeception/pkg/ui_service"
 	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
 )
 
-var (
-	strkAddress, _ = new(felt.Felt).SetString("4718f5a0fc34cc1af16a1cdee98ffb20c31f5cd61d6ab07201858f4287c938d")
-)
-
 func main() {
 	var (
 		providerURLs         []string
+		wsURL                string
+		subscriberBuffer     int
+		overflowPolicy       string
+		networkName          string
+		networkProfileFile   string
 		maxPageSize          int
 		serverAddr           string
 		registryAddr         string
@@ -32,20 +37,51 @@ func main() {
 		eventTickRate        time.Duration
 		eventStartupTickRate time.Duration
 		userTickRate         time.Duration
+		dbPath               string
+		pragmaOracleAddr     string
+		pragmaPairID         string
+		priceAPIURL          string
+		priceAPIKey          string
+		priceAPIAssetID      string
+		priceAggregation     string
+		priceMaxAge          time.Duration
+		agentURL             string
 	)
 
 	rootCmd := &cobra.Command{
 		Use:   "ui-service",
 		Short: "UI Service for Teeception",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(providerURLs) == 0 ||
-				registryAddr == "" ||
+			if registryAddr == "" ||
 				deploymentBlock == 0 ||
 				maxPageSize == 0 ||
 				serverAddr == "" {
 				return cmd.Help()
 			}
 
+			networkProfile, err := network.Load(networkName, networkProfileFile)
+			if err != nil {
+				slog.Error("failed to load network profile", "error", err)
+				return err
+			}
+
+			if len(providerURLs) == 0 {
+				providerURLs = networkProfile.DefaultRpcUrls
+			}
+
+			subscriberOverflowPolicy, err := indexer.ParseOverflowPolicy(overflowPolicy)
+			if err != nil {
+				slog.Error("invalid subscriber overflow policy", "error", err)
+				return err
+			}
+			// The UI indexers build their state from every event, so dropped
+			// events would corrupt it for good.
+			if subscriberOverflowPolicy == indexer.OverflowDropOldest {
+				err := fmt.Errorf("subscriber overflow policy %q loses events and is not supported by the ui service", subscriberOverflowPolicy)
+				slog.Error("invalid subscriber overflow policy", "error", err)
+				return err
+			}
+
 			registryAddress, err := new(felt.Felt).SetString(registryAddr)
 			if err != nil {
 				slog.Error("invalid registry address", "error", err)
@@ -71,21 +107,47 @@ func main() {
 				return err
 			}
 
+			// STRK is valued at $1 if no price feed is configured. The feeds only
+			// price STRK, other tokens are unpriced and left out of USD values.
 			tokenRates := make(map[[32]byte]*big.Int)
-			tokenRates[strkAddress.Bytes()] = big.NewInt(1)
+			tokenRates[networkProfile.StrkTokenAddress.Bytes()] = price.USDRate(1)
+
+			priceFeed, err := newPriceFeed(&priceFeedFlags{
+				client:           rateLimitedClient,
+				strkTokenAddress: networkProfile.StrkTokenAddress,
+				pragmaOracleAddr: pragmaOracleAddr,
+				pragmaPairID:     pragmaPairID,
+				apiURL:           priceAPIURL,
+				apiKey:           priceAPIKey,
+				apiAssetID:       priceAPIAssetID,
+				aggregation:      priceAggregation,
+				maxAge:           priceMaxAge,
+			})
+			if err != nil {
+				slog.Error("failed to create price feed", "error", err)
+				return err
+			}
 
 			uiService, err := uiservice.NewUIService(&uiservice.UIServiceConfig{
-				Client:               rateLimitedClient,
-				MaxPageSize:          maxPageSize,
-				ServerAddr:           serverAddr,
-				RegistryAddress:      registryAddress,
-				StartingBlock:        deploymentBlock,
-				TokenRates:           tokenRates,
-				PriceTickRate:        priceTickRate,
-				EventTickRate:        eventTickRate,
-				EventStartupTickRate: eventStartupTickRate,
-				UserTickRate:         userTickRate,
-				AgentBalanceTickRate: balanceTickRate,
+				Client:                   rateLimitedClient,
+				WebsocketURL:             wsURL,
+				SubscriberBufferSize:     subscriberBuffer,
+				SubscriberOverflowPolicy: subscriberOverflowPolicy,
+				Network:                  networkProfile,
+				MaxPageSize:              maxPageSize,
+				ServerAddr:               serverAddr,
+				RegistryAddress:          registryAddress,
+				StartingBlock:            deploymentBlock,
+				PriceFeed:                priceFeed,
+				TokenRates:               tokenRates,
+				PriceMaxAge:              priceMaxAge,
+				PriceTickRate:            priceTickRate,
+				EventTickRate:            eventTickRate,
+				EventStartupTickRate:     eventStartupTickRate,
+				UserTickRate:             userTickRate,
+				AgentBalanceTickRate:     balanceTickRate,
+				DBPath:                   dbPath,
+				AgentURL:                 agentURL,
 			})
 			if err != nil {
 				slog.Error("failed to create UI service", "error", err)
@@ -96,7 +158,12 @@ func main() {
 		},
 	}
 
-	rootCmd.Flags().StringArrayVar(&providerURLs, "provider-url", nil, "Starknet provider URL (can be specified multiple times)")
+	rootCmd.Flags().StringArrayVar(&providerURLs, "provider-url", nil, "Starknet provider URL (can be specified multiple times, defaults to the network's RPCs)")
+	rootCmd.Flags().StringVar(&wsURL, "ws-url", "", "Starknet JSON-RPC WebSocket URL for push-based event ingestion (polling only if empty)")
+	rootCmd.Flags().IntVar(&subscriberBuffer, "subscriber-buffer-size", 1000, "Number of event batches queued for each indexer")
+	rootCmd.Flags().StringVar(&overflowPolicy, "subscriber-overflow-policy", string(indexer.OverflowBlock), "What to do when an indexer's event queue is full (block or resync)")
+	rootCmd.Flags().StringVar(&networkName, "network", network.NameSepolia, "Network profile (mainnet, sepolia, devnet or custom)")
+	rootCmd.Flags().StringVar(&networkProfileFile, "network-profile-file", "", "JSON network profile for the custom network")
 	rootCmd.Flags().IntVar(&maxPageSize, "page-size", 50, "Max page size for pagination")
 	rootCmd.Flags().StringVar(&serverAddr, "server-addr", ":8000", "Server address to listen on")
 	rootCmd.Flags().StringVar(&registryAddr, "registry-addr", "", "Agent registry contract address")
@@ -106,6 +173,15 @@ func main() {
 	rootCmd.Flags().DurationVar(&eventTickRate, "event-tick-rate", 5*time.Second, "Event watcher tick rate")
 	rootCmd.Flags().DurationVar(&eventStartupTickRate, "event-startup-tick-rate", 1*time.Second, "Event watcher startup tick rate")
 	rootCmd.Flags().DurationVar(&userTickRate, "user-tick-rate", 1*time.Minute, "User indexer sorting tick rate")
+	rootCmd.Flags().StringVar(&pragmaOracleAddr, "pragma-oracle-addr", "", "Pragma oracle contract address to read STRK prices from")
+	rootCmd.Flags().StringVar(&pragmaPairID, "pragma-pair-id", "STRK/USD", "Pragma pair STRK is priced with")
+	rootCmd.Flags().StringVar(&priceAPIURL, "price-api-url", "", "Base URL of a CoinGecko compatible price API to read STRK prices from")
+	rootCmd.Flags().StringVar(&priceAPIKey, "price-api-key", "", "Price API key")
+	rootCmd.Flags().StringVar(&priceAPIAssetID, "price-api-asset-id", "starknet", "Price API ID STRK is priced with")
+	rootCmd.Flags().StringVar(&priceAggregation, "price-aggregation", string(price.AggregationFallback), "How prices of several feeds are combined (fallback or median)")
+	rootCmd.Flags().DurationVar(&priceMaxAge, "price-max-age", 15*time.Minute, "Age after which prices are considered stale (never if 0)")
+	rootCmd.Flags().StringVar(&dbPath, "db-path", "", "Path of the database the indexers persist their state to (in-memory only if empty)")
+	rootCmd.Flags().StringVar(&agentURL, "agent-url", "", "Base URL of the agent API to fetch prompt replies from (replies are not indexed if empty)")
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/price"
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+type priceFeedFlags struct {
+	client           starknet.ProviderWrapper
+	strkTokenAddress *felt.Felt
+	pragmaOracleAddr string
+	pragmaPairID     string
+	apiURL           string
+	apiKey           string
+	apiAssetID       string
+	aggregation      string
+	maxAge           time.Duration
+}
+
+// newPriceFeed creates the live price feeds configured by flags, combined if
+// there are several of them. It returns nil if none is configured.
+func newPriceFeed(flags *priceFeedFlags) (price.PriceFeed, error) {
+	aggregation, err := price.ParseAggregation(flags.aggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	var feeds []price.PriceFeed
+
+	if flags.pragmaOracleAddr != "" {
+		oracleAddress, err := new(felt.Felt).SetString(flags.pragmaOracleAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pragma oracle address: %w", err)
+		}
+
+		feed, err := price.NewPragmaPriceFeed(&price.PragmaPriceFeedConfig{
+			Client:        flags.client,
+			OracleAddress: oracleAddress,
+			PairIDs:       map[[32]byte]string{flags.strkTokenAddress.Bytes(): flags.pragmaPairID},
+			MaxAge:        flags.maxAge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pragma price feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	if flags.apiURL != "" {
+		feed, err := price.NewHTTPPriceFeed(&price.HTTPPriceFeedConfig{
+			BaseURL:  flags.apiURL,
+			APIKey:   flags.apiKey,
+			AssetIDs: map[[32]byte]string{flags.strkTokenAddress.Bytes(): flags.apiAssetID},
+			MaxAge:   flags.maxAge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http price feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	switch len(feeds) {
+	case 0:
+		return nil, nil
+	case 1:
+		return feeds[0], nil
+	default:
+		return price.NewCompositePriceFeed(&price.CompositePriceFeedConfig{
+			Feeds:       feeds,
+			Aggregation: aggregation,
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 
 	"github.com/NethermindEth/teeception/pkg/agent/quote"
+	"github.com/NethermindEth/teeception/pkg/network"
 )
 
 var (
@@ -92,6 +93,7 @@ type VerifyParams struct {
 	QuoteBytes    []byte
 	AppID         string
 	BaseDstackURL string
+	Network       *network.Profile
 }
 
 func fetchAndParseHTML(appID, baseDstackURL string) (string, string, error) {
@@ -268,10 +270,17 @@ func verify(params VerifyParams) (QuoteData, error) {
 	fmt.Printf("%s\n", color.New(color.FgYellow).Sprint(appComposeData.DockerComposeFile))
 
 	fmt.Printf("\n%s Report Data:\n", info("📋"))
+	fmt.Printf("Network: %s (%s)\n", params.Network.Name, params.Network.ChainID)
 	fmt.Printf("TEE Address: %s\n", quoteData.ReportData.Address)
 	fmt.Printf("AgentRegistry Address: %s\n", quoteData.ReportData.ContractAddress)
 	fmt.Printf("Twitter Username: %s\n", quoteData.ReportData.TwitterUsername)
 
+	if params.Network.ExplorerContractURL != "" {
+		fmt.Printf("\n%s Explorer Links:\n", info("🔗"))
+		fmt.Printf("TEE Account: %s\n", params.Network.ContractURL(quoteData.ReportData.Address))
+		fmt.Printf("AgentRegistry: %s\n", params.Network.ContractURL(quoteData.ReportData.ContractAddress))
+	}
+
 	return quoteData, nil
 }
 
@@ -280,6 +289,8 @@ func main() {
 	var appID string
 	var baseDstackURL string
 	var submit bool
+	var networkName string
+	var networkProfileFile string
 
 	rootCmd := &cobra.Command{
 		Use:   "verify",
@@ -293,10 +304,17 @@ func main() {
 				os.Exit(1)
 			}
 
+			networkProfile, err := network.Load(networkName, networkProfileFile)
+			if err != nil {
+				fmt.Printf("%s Error loading network profile: %v\n", fail("❌"), err)
+				os.Exit(1)
+			}
+
 			params := VerifyParams{
 				QuoteBytes:    quoteBytes,
 				AppID:         appID,
 				BaseDstackURL: baseDstackURL,
+				Network:       networkProfile,
 			}
 
 			valid := true
@@ -343,10 +361,14 @@ func main() {
 	rootCmd.Flags().StringVar(&appID, "app-id", "", "Application ID")
 	rootCmd.Flags().StringVar(&baseDstackURL, "base-dstack-url", "dstack-prod5.phala.network", "Base Dstack URL")
 	rootCmd.Flags().BoolVar(&submit, "submit", false, "Submit quote to ra-quote-explorer")
+	rootCmd.Flags().StringVar(&networkName, "network", network.NameSepolia, "Network profile (mainnet, sepolia, devnet or custom)")
+	rootCmd.Flags().StringVar(&networkProfileFile, "network-profile-file", "", "JSON network profile for the custom network")
 
 	rootCmd.MarkFlagRequired("quote")
 	rootCmd.MarkFlagRequired("app-id")
 
+	rootCmd.AddCommand(newReceiptCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Printf("%s %v\n", fail("❌"), err)
 		os.Exit(1)
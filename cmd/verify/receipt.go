@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/spf13/cobra"
+
+	"github.com/NethermindEth/teeception/pkg/agent/receipt"
+)
+
+type receiptParams struct {
+	receiptPath  string
+	agentURL     string
+	agentAddress string
+	promptID     uint64
+	teeAddress   string
+	prompt       string
+	systemPrompt string
+	metadata     string
+	model        string
+}
+
+func newReceiptCmd() *cobra.Command {
+	params := &receiptParams{}
+
+	cmd := &cobra.Command{
+		Use:   "receipt",
+		Short: "Verify a signed decision receipt",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("\n%s Starting receipt verification...\n", info("🧾"))
+
+			r, err := loadReceipt(params)
+			if err != nil {
+				fmt.Printf("%s Error loading receipt: %v\n", fail("❌"), err)
+				os.Exit(1)
+			}
+
+			if err := verifyReceipt(r, params); err != nil {
+				fmt.Printf("\n%s Receipt is invalid: %v\n", fail("❌"), err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("\n%s Receipt is valid! All checks passed.\n\n", success("✅"))
+		},
+	}
+
+	cmd.Flags().StringVar(&params.receiptPath, "receipt", "", "Path to a receipt file")
+	cmd.Flags().StringVar(&params.agentURL, "agent-url", "", "Agent API URL to fetch the receipt from, instead of a file")
+	cmd.Flags().StringVar(&params.agentAddress, "agent-address", "", "Agent contract address, used with --agent-url")
+	cmd.Flags().Uint64Var(&params.promptID, "prompt-id", 0, "Prompt ID, used with --agent-url")
+	cmd.Flags().StringVar(&params.teeAddress, "tee-address", "", "Expected TEE account address, as attested in the quote's report data")
+	cmd.Flags().StringVar(&params.prompt, "prompt", "", "Expected prompt")
+	cmd.Flags().StringVar(&params.systemPrompt, "system-prompt", "", "Expected system prompt")
+	cmd.Flags().StringVar(&params.metadata, "metadata", "", "Expected prompt metadata")
+	cmd.Flags().StringVar(&params.model, "model", "", "Expected model")
+
+	return cmd
+}
+
+func loadReceipt(params *receiptParams) (*receipt.Receipt, error) {
+	var data []byte
+	var err error
+
+	if params.receiptPath != "" {
+		data, err = os.ReadFile(params.receiptPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read receipt: %w", err)
+		}
+	} else if params.agentURL != "" && params.agentAddress != "" {
+		url := fmt.Sprintf("%s/receipts/%s/%d", strings.TrimSuffix(params.agentURL, "/"), params.agentAddress, params.promptID)
+		resp, err := http.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch receipt: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch receipt: %s", resp.Status)
+		}
+
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read receipt: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("either --receipt or --agent-url and --agent-address are required")
+	}
+
+	var r receipt.Receipt
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal receipt: %w", err)
+	}
+
+	return &r, nil
+}
+
+func verifyReceipt(r *receipt.Receipt, params *receiptParams) error {
+	fmt.Printf("\n%s Receipt:\n", info("📋"))
+	fmt.Printf("Agent Address: %s\n", r.AgentAddress)
+	fmt.Printf("Prompt ID: %d\n", r.PromptID)
+	fmt.Printf("Response: %s\n", r.Response)
+	fmt.Printf("Drain To: %s\n", r.DrainTo)
+	fmt.Printf("Consume Tx Hash: %s\n", r.ConsumeTxHash)
+	fmt.Printf("Signer: %s\n", r.Signer)
+
+	if r.Version != receipt.Version {
+		return fmt.Errorf("unsupported receipt version %d", r.Version)
+	}
+
+	if err := r.Verify(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%s Signature verified successfully\n", success("✓"))
+
+	if params.teeAddress != "" {
+		teeAddress, err := new(felt.Felt).SetString(params.teeAddress)
+		if err != nil {
+			return fmt.Errorf("invalid TEE address: %w", err)
+		}
+		if teeAddress.Cmp(r.Signer) != 0 {
+			return fmt.Errorf("signer mismatch, expected %s, got %s", teeAddress, r.Signer)
+		}
+		fmt.Printf("%s Signer matches the TEE address\n", success("✓"))
+	} else {
+		fmt.Printf("%s No --tee-address given, the signer is not checked against the quote\n", warn("⚠️"))
+	}
+
+	checks := []struct {
+		name     string
+		expected string
+		hash     *felt.Felt
+	}{
+		{"prompt", params.prompt, r.PromptHash},
+		{"system prompt", params.systemPrompt, r.SystemPromptHash},
+		{"metadata", params.metadata, r.MetadataHash},
+		{"model", params.model, r.ModelHash},
+	}
+
+	for _, check := range checks {
+		if check.expected == "" {
+			continue
+		}
+		if receipt.HashString(check.expected).Cmp(check.hash) != 0 {
+			return fmt.Errorf("%s hash mismatch", check.name)
+		}
+		fmt.Printf("%s %s matches\n", success("✓"), strings.ToUpper(check.name[:1])+check.name[1:])
+	}
+
+	return nil
+}
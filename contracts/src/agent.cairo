@@ -52,8 +52,9 @@ pub trait IAgent<TContractState> {
     /// ```
     fn consume_prompt(ref self: TContractState, prompt_id: u64, drain_to: ContractAddress);
 
-    /// @notice Withdraw remaining tokens after agent is finalized
-    /// @dev Only callable by creator after end_time or when drained. Example:
+    /// @notice Withdraw remaining tokens to the creator after agent is finalized
+    /// @dev Only callable by creator, or by registry on behalf of the TEE, after end_time or
+    /// when drained. Example:
     /// ```
     /// // After end_time has passed or agent is drained
     /// agent.withdraw();
@@ -496,12 +497,15 @@ pub mod Agent {
         /// @inheritdoc IAgent
         fn withdraw(ref self: ContractState) {
             let caller = get_caller_address();
-            assert(caller == self.creator.read(), 'Only creator can withdraw');
+            let creator = self.creator.read();
+            assert(
+                caller == creator || caller == self.registry.read(), 'Only creator or registry',
+            );
 
             self._assert_finalized();
 
-            let drained_amount = self._drain(caller);
-            self.emit(Event::Withdrawn(Withdrawn { to: caller, amount: drained_amount }));
+            let drained_amount = self._drain(creator);
+            self.emit(Event::Withdrawn(Withdrawn { to: creator, amount: drained_amount }));
         }
 
         /// @inheritdoc IAgent
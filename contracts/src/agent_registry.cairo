@@ -106,6 +106,11 @@ pub trait IAgentRegistry<TContractState> {
         ref self: TContractState, agent: ContractAddress, prompt_id: u64, drain_to: ContractAddress,
     );
 
+    /// @notice Withdraws the prize pool of a finalized agent to its creator
+    /// @param agent The agent contract address
+    /// @dev Only callable by TEE
+    fn withdraw_agent(ref self: TContractState, agent: ContractAddress);
+
     /// @notice Withdraws funds from the contract. Used to collect protocol fees
     /// @dev Only callable by owner
     fn withdraw(
@@ -376,6 +381,14 @@ pub mod AgentRegistry {
             IAgentDispatcher { contract_address: agent }.consume_prompt(prompt_id, drain_to);
         }
 
+        /// @inheritdoc IAgentRegistry
+        fn withdraw_agent(ref self: ContractState, agent: ContractAddress) {
+            self._assert_caller_is_tee();
+            self._assert_agent_registered(agent);
+
+            IAgentDispatcher { contract_address: agent }.withdraw();
+        }
+
         /// @inheritdoc IAgentRegistry
         fn unencumber(ref self: ContractState) {
             self._assert_caller_is_owner();
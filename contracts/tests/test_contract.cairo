@@ -876,7 +876,7 @@ fn test_early_withdraw() {
 }
 
 #[test]
-#[should_panic(expected: ('Only creator can withdraw',))]
+#[should_panic(expected: ('Only creator or registry',))]
 fn test_unauthorized_withdraw() {
     let setup = setup();
 
@@ -905,6 +905,87 @@ fn test_unauthorized_withdraw() {
     agent.withdraw(); // Should fail
 }
 
+#[test]
+fn test_tee_withdraw_agent() {
+    let setup = setup();
+
+    let mut spy = spy_events();
+
+    start_cheat_caller_address(setup.registry.contract_address, setup.creator);
+    let agent_address = setup
+        .registry
+        .register_agent(
+            "test",
+            "test",
+            setup.model,
+            setup.token_address,
+            setup.prompt_price,
+            setup.initial_balance,
+            setup.end_time,
+        );
+    stop_cheat_caller_address(setup.registry.contract_address);
+
+    let agent = IAgentDispatcher { contract_address: agent_address };
+
+    // Move time past end_time
+    start_cheat_block_timestamp_global(setup.end_time + 1);
+
+    let initial_creator_balance = setup.token.balance_of(setup.creator);
+    let pool_prize = agent.get_prize_pool();
+
+    // TEE withdraws on behalf of the creator
+    start_cheat_caller_address(setup.registry.contract_address, setup.tee);
+    setup.registry.withdraw_agent(agent_address);
+    stop_cheat_caller_address(setup.registry.contract_address);
+
+    assert(
+        setup.token.balance_of(setup.creator) == initial_creator_balance + pool_prize,
+        'Creator wrong balance',
+    );
+    assert(setup.token.balance_of(setup.tee) == 0, 'TEE should not be paid');
+    assert(agent.get_is_drained(), 'Should be drained');
+
+    spy
+        .assert_emitted(
+            @array![
+                (
+                    agent_address,
+                    Agent::Event::Withdrawn(
+                        Agent::Withdrawn { to: setup.creator, amount: pool_prize },
+                    ),
+                ),
+            ],
+        );
+
+    stop_cheat_block_timestamp_global();
+}
+
+#[test]
+#[should_panic(expected: ('Only tee can call',))]
+fn test_unauthorized_withdraw_agent() {
+    let setup = setup();
+
+    start_cheat_caller_address(setup.registry.contract_address, setup.creator);
+    let agent_address = setup
+        .registry
+        .register_agent(
+            "test",
+            "test",
+            setup.model,
+            setup.token_address,
+            setup.prompt_price,
+            setup.initial_balance,
+            setup.end_time,
+        );
+    stop_cheat_caller_address(setup.registry.contract_address);
+
+    start_cheat_block_timestamp_global(setup.end_time + 1);
+
+    let unauthorized = starknet::contract_address_const::<0x456>();
+    start_cheat_caller_address(setup.registry.contract_address, unauthorized);
+    setup.registry.withdraw_agent(agent_address); // Should fail
+}
+
 #[test]
 #[should_panic(expected: ('Agent already been finalized',))]
 fn test_pay_after_end() {
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math/big"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,15 +16,25 @@ import (
 	"github.com/NethermindEth/starknet.go/rpc"
 	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
 	"github.com/alitto/pond/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/sashabaranov/go-openai"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/NethermindEth/teeception/pkg/agent/chat"
 	"github.com/NethermindEth/teeception/pkg/agent/debug"
+	"github.com/NethermindEth/teeception/pkg/agent/drain"
+	"github.com/NethermindEth/teeception/pkg/agent/expiry"
+	"github.com/NethermindEth/teeception/pkg/agent/journal"
+	"github.com/NethermindEth/teeception/pkg/agent/outbox"
+	"github.com/NethermindEth/teeception/pkg/agent/policy"
 	"github.com/NethermindEth/teeception/pkg/agent/quote"
+	"github.com/NethermindEth/teeception/pkg/agent/receipt"
 	"github.com/NethermindEth/teeception/pkg/agent/setup"
+	"github.com/NethermindEth/teeception/pkg/agent/tasks"
 	"github.com/NethermindEth/teeception/pkg/agent/validation"
 	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/metrics"
+	"github.com/NethermindEth/teeception/pkg/network"
 	"github.com/NethermindEth/teeception/pkg/twitter"
 	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
 	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
@@ -31,9 +43,22 @@ import (
 var (
 	consumePromptSelector = starknetgoutils.GetSelectorFromNameFelt("consume_prompt")
 	balanceOfSelector     = starknetgoutils.GetSelectorFromNameFelt("balance_of")
-	ethAddress, _         = starknetgoutils.HexToFelt("0x049d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7")
 )
 
+// DefaultModelRoutes maps the model names agents register with on-chain to the
+// backends serving them.
+var DefaultModelRoutes = map[string]chat.BackendConfig{
+	"gpt-4":       {Backend: chat.BackendOpenAI, Model: openai.GPT4},
+	"gpt-4o":      {Backend: chat.BackendOpenAI, Model: openai.GPT4o},
+	"gpt-4o-mini": {Backend: chat.BackendOpenAI, Model: openai.GPT4oMini},
+}
+
+const alreadyDrainedReply = "Too late! This agent was already drained by an earlier prompt."
+
+// DefaultNameValidatorModel is the route used to validate agent names when it
+// is configured and no fallback is set.
+const DefaultNameValidatorModel = "gpt-4"
+
 const (
 	TwitterClientModeEnv   = "env"
 	TwitterClientModeApi   = "api"
@@ -46,12 +71,20 @@ type AgentConfigParams struct {
 	IsUnencumbered               bool
 	UnencumberData               *setup.UnencumberData
 	OpenAIKey                    string
+	ModelRoutes                  map[string]chat.BackendConfig
+	FallbackModel                *chat.BackendConfig
 	DstackTappdEndpoint          string
+	JournalFile                  string
+	ReceiptsFile                 string
+	Network                      *network.Profile
 	StarknetRpcUrls              []string
+	StarknetWsUrl                string
 	StarknetPrivateKeySeed       []byte
 	AgentRegistryAddress         *felt.Felt
 	AgentRegistryDeploymentBlock uint64
 	TaskConcurrency              int
+	MaxPromptsPerUser            int
+	MaxPromptsPerAgent           int
 	TickRate                     time.Duration
 	SafeBlockDelta               uint64
 	MaxSystemPromptTokens        int
@@ -81,12 +114,22 @@ type AgentConfig struct {
 	AgentIndexer *indexer.AgentIndexer
 	EventWatcher *indexer.EventWatcher
 	NameCache    *validation.NameCache
+	Journal      *journal.Journal
+	Outbox       *outbox.Outbox
+	Receipts     *receipt.Store
+	Network      *network.Profile
+
+	// ResumedFromJournal makes the agent look up the agents registered before
+	// the journal checkpoint, whose expiry it would otherwise not track.
+	ResumedFromJournal bool
 
 	Account                *snaccount.StarknetAccount
 	AccountDeploymentState AgentAccountDeploymentState
 	TxQueue                *snaccount.TxQueue
+	PromptConsumer         PromptConsumer
 
-	Pool pond.Pool
+	Pool      pond.Pool
+	Scheduler *policy.Scheduler
 
 	StartupBlockNumber   uint64
 	AgentRegistryAddress *felt.Felt
@@ -113,8 +156,12 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 		return nil, fmt.Errorf("invalid twitter client mode: %s", params.TwitterClientMode)
 	}
 
-	openaiChatCompletion := chat.NewOpenAIChatCompletionOpenAI(openai.GPT4, params.OpenAIKey)
-	tokenLimitChatCompletion, err := chat.NewTokenLimitChatCompletion(openaiChatCompletion, params.MaxSystemPromptTokens, params.MaxPromptTokens)
+	modelRouterChatCompletion, err := NewModelRouterChatCompletion(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model router chat completion: %v", err)
+	}
+
+	tokenLimitChatCompletion, err := chat.NewTokenLimitChatCompletion(modelRouterChatCompletion, params.MaxSystemPromptTokens, params.MaxPromptTokens)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token limit chat completion: %v", err)
 	}
@@ -122,10 +169,81 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 	dstackTappdClient := tappd.NewTappdClient(tappd.WithEndpoint(params.DstackTappdEndpoint))
 	quoter := quote.NewTappdQuoter(dstackTappdClient)
 
-	slog.Info("connecting to starknet")
+	if params.JournalFile == "" {
+		params.JournalFile = envGetAgentJournalFile()
+	}
+
+	lastIndexedBlock := max(params.AgentRegistryDeploymentBlock, 1) - 1
+	resumeFromJournal := false
+
+	var promptJournal *journal.Journal
+	if params.JournalFile != "" {
+		journalKey, err := setup.DeriveSealingKey(context.Background(), dstackTappdClient, setup.JournalSealingKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive journal sealing key: %v", err)
+		}
+
+		promptJournal, err = journal.Open(&journal.Config{
+			Path:   params.JournalFile,
+			Sealer: setup.NewSealer(journalKey),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open prompt journal: %v", err)
+		}
+
+		if promptJournal.LastBlock() > lastIndexedBlock {
+			slog.Info("resuming from prompt journal checkpoint", "block", promptJournal.LastBlock())
+			lastIndexedBlock = promptJournal.LastBlock()
+			resumeFromJournal = true
+		}
+	}
+
+	if params.ReceiptsFile == "" {
+		params.ReceiptsFile = envGetAgentReceiptsFile()
+	}
+
+	receiptsConfig := &receipt.StoreConfig{
+		Path: params.ReceiptsFile,
+	}
+	if params.ReceiptsFile != "" {
+		receiptsKey, err := setup.DeriveSealingKey(context.Background(), dstackTappdClient, setup.ReceiptsSealingKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive receipts sealing key: %v", err)
+		}
+		receiptsConfig.Sealer = setup.NewSealer(receiptsKey)
+	}
+
+	receipts, err := receipt.NewStore(receiptsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open receipt store: %v", err)
+	}
+
+	if params.StarknetWsUrl == "" {
+		params.StarknetWsUrl = envGetStarknetWsUrl()
+	}
+
+	if params.Network == nil {
+		params.Network, err = network.Load(envGetNetwork(), envGetNetworkProfileFile())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load network profile: %v", err)
+		}
+	}
 
-	providers := make([]rpc.RpcProvider, 0, len(params.StarknetRpcUrls))
+	rpcUrls := make([]string, 0, len(params.StarknetRpcUrls))
 	for _, url := range params.StarknetRpcUrls {
+		if url != "" {
+			rpcUrls = append(rpcUrls, url)
+		}
+	}
+	if len(rpcUrls) == 0 {
+		slog.Warn("no starknet rpc urls configured, using network defaults", "network", params.Network.Name)
+		rpcUrls = params.Network.DefaultRpcUrls
+	}
+
+	slog.Info("connecting to starknet", "network", params.Network.Name)
+
+	providers := make([]rpc.RpcProvider, 0, len(rpcUrls))
+	for _, url := range rpcUrls {
 		starknetClient, err := rpc.NewProvider(url)
 		if err != nil {
 			return nil, err
@@ -149,8 +267,9 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 		IndexChunkSize:  1000,
 		RegistryAddress: params.AgentRegistryAddress,
 		InitialState: &indexer.EventWatcherInitialState{
-			LastIndexedBlock: max(params.AgentRegistryDeploymentBlock, 1) - 1,
+			LastIndexedBlock: lastIndexedBlock,
 		},
+		WebsocketURL: params.StarknetWsUrl,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create event watcher: %v", err)
@@ -161,8 +280,9 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 		RegistryAddress: params.AgentRegistryAddress,
 		EventWatcher:    eventWatcher,
 		InitialState: &indexer.AgentIndexerInitialState{
-			Db: indexer.NewAgentIndexerDatabaseInMemory(max(params.AgentRegistryDeploymentBlock, 1) - 1),
+			Db: indexer.NewAgentIndexerDatabaseInMemory(lastIndexedBlock),
 		},
+		FetchUnknownAgents: resumeFromJournal,
 	})
 
 	privateKey := snaccount.NewPrivateKey(params.StarknetPrivateKeySeed)
@@ -192,8 +312,34 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 		return nil, fmt.Errorf("failed to get startup block number: %v", err)
 	}
 
+	var chainID string
+	if err := starknetClient.Do(func(provider rpc.RpcProvider) error {
+		chainID, err = provider.ChainID(context.Background())
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %v", err)
+	}
+	if params.Network.ChainID != "" && chainID != params.Network.ChainID {
+		return nil, fmt.Errorf("chain id mismatch for network %s: expected %s, got %s", params.Network.Name, params.Network.ChainID, chainID)
+	}
+
 	nameCache := validation.NewNameCacheWithConcurrency(tokenLimitChatCompletion, 10)
 
+	replyOutbox := outbox.NewOutbox(&outbox.OutboxConfig{
+		TwitterClient:  twitterClient,
+		MaxAttempts:    8,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     15 * time.Minute,
+	})
+
+	pool := pond.NewPool(params.TaskConcurrency)
+	scheduler := policy.NewScheduler(&policy.SchedulerConfig{
+		Pool:          pool,
+		MaxConcurrent: params.TaskConcurrency,
+		MaxPerUser:    params.MaxPromptsPerUser,
+		MaxPerAgent:   params.MaxPromptsPerAgent,
+	})
+
 	return &AgentConfig{
 		TwitterClient:       twitterClient,
 		TwitterClientConfig: params.TwitterClientConfig,
@@ -205,13 +351,20 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 		StarknetClient: starknetClient,
 		Quoter:         quoter,
 		NameCache:      nameCache,
+		Journal:        promptJournal,
+		Outbox:         replyOutbox,
+		Receipts:       receipts,
+		Network:        params.Network,
+
+		ResumedFromJournal: resumeFromJournal,
 
 		AgentIndexer: agentIndexer,
 		EventWatcher: eventWatcher,
 		Account:      account,
 		TxQueue:      txQueue,
 
-		Pool: pond.NewPool(params.TaskConcurrency),
+		Pool:      pool,
+		Scheduler: scheduler,
 
 		StartupBlockNumber:   startupBlockNumber,
 		AgentRegistryAddress: params.AgentRegistryAddress,
@@ -219,6 +372,74 @@ func NewAgentConfigFromParams(params *AgentConfigParams) (*AgentConfig, error) {
 	}, nil
 }
 
+// NewModelRouterChatCompletion creates the chat completion routing prompts to the
+// backend of each agent's model, as configured in params.
+func NewModelRouterChatCompletion(params *AgentConfigParams) (*chat.ModelRouterChatCompletion, error) {
+	modelRoutes := params.ModelRoutes
+	if len(modelRoutes) == 0 {
+		modelRoutes = DefaultModelRoutes
+	}
+
+	var withDefaultAPIKey func(backendConfig chat.BackendConfig) chat.BackendConfig
+	withDefaultAPIKey = func(backendConfig chat.BackendConfig) chat.BackendConfig {
+		if backendConfig.APIKey == "" && (backendConfig.Backend == "" || backendConfig.Backend == chat.BackendOpenAI) {
+			backendConfig.APIKey = params.OpenAIKey
+		}
+
+		members := make([]chat.BackendConfig, 0, len(backendConfig.Members))
+		for _, member := range backendConfig.Members {
+			members = append(members, withDefaultAPIKey(member))
+		}
+		backendConfig.Members = members
+
+		return backendConfig
+	}
+
+	newChatCompletion := func(backendConfig chat.BackendConfig) (chat.ChatCompletion, error) {
+		return chat.NewChatCompletionFromBackendConfig(withDefaultAPIKey(backendConfig))
+	}
+
+	routes := make(map[string]chat.ChatCompletion, len(modelRoutes))
+	for model, backendConfig := range modelRoutes {
+		slog.Info("routing model", "model", model, "backend", backendConfig.Backend, "backend_model", backendConfig.Model)
+
+		chatCompletion, err := newChatCompletion(backendConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backend for model %s: %v", model, err)
+		}
+		routes[model] = chatCompletion
+	}
+
+	var fallback chat.ChatCompletion
+	if params.FallbackModel != nil {
+		slog.Info("routing unknown models to fallback", "backend", params.FallbackModel.Backend, "backend_model", params.FallbackModel.Model)
+
+		var err error
+		fallback, err = newChatCompletion(*params.FallbackModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback backend: %v", err)
+		}
+	}
+
+	// Names are validated by the fallback if set, and otherwise by one of the
+	// routed backends so that deployments without OpenAI can still start.
+	var nameValidator chat.ChatCompletion
+	if fallback == nil {
+		nameValidatorModel := DefaultNameValidatorModel
+		if _, ok := routes[nameValidatorModel]; !ok {
+			nameValidatorModel = slices.Min(slices.Collect(maps.Keys(routes)))
+		}
+		slog.Info("validating names with model", "model", nameValidatorModel)
+		nameValidator = routes[nameValidatorModel]
+	}
+
+	return chat.NewModelRouterChatCompletion(chat.ModelRouterChatCompletionConfig{
+		Routes:        routes,
+		Fallback:      fallback,
+		NameValidator: nameValidator,
+	})
+}
+
 type Agent struct {
 	twitterClient       twitter.TwitterClient
 	twitterClientConfig *twitter.TwitterClientConfig
@@ -233,12 +454,24 @@ type Agent struct {
 	agentIndexer *indexer.AgentIndexer
 	eventWatcher *indexer.EventWatcher
 	nameCache    *validation.NameCache
+	journal      *journal.Journal
+	outbox       *outbox.Outbox
+	network      *network.Profile
+	drainGuard   *drain.Guard
+
+	expiryScheduler *expiry.Scheduler
+	seenPrompts     *lru.Cache[journal.Key, seenPrompt]
+	seedExpiry      bool
+	canWithdraw     *bool
+	promptTasks     *tasks.Tracker
+	receipts        *receipt.Store
 
 	account                *snaccount.StarknetAccount
 	accountDeploymentState AgentAccountDeploymentState
 	txQueue                *snaccount.TxQueue
+	promptConsumer         PromptConsumer
 
-	pool pond.Pool
+	scheduler *policy.Scheduler
 
 	startupBlockNumber   uint64
 	agentRegistryAddress *felt.Felt
@@ -247,10 +480,51 @@ type Agent struct {
 	eventCh chan *indexer.EventSubscriptionData
 }
 
+// seenPromptsSize is the number of most recent prompts remembered to ignore
+// PromptPaid events delivered again, e.g. after a chain reorganization.
+const seenPromptsSize = 100000
+
+// seenPrompt is the tweet and block of a prompt seen since startup.
+type seenPrompt struct {
+	tweetID uint64
+	block   uint64
+}
+
 func NewAgent(config *AgentConfig) (*Agent, error) {
 	slog.Info("agent initialized successfully", "account_address", config.Account.Address())
 
-	return &Agent{
+	promptConsumer := config.PromptConsumer
+	if promptConsumer == nil {
+		promptConsumer = NewTxQueuePromptConsumer(config.TxQueue, config.AgentRegistryAddress)
+	}
+
+	networkProfile := config.Network
+	if networkProfile == nil {
+		slog.Warn("no network profile configured, defaulting to " + network.NameSepolia)
+		networkProfile = network.Sepolia
+	}
+
+	scheduler := config.Scheduler
+	if scheduler == nil && config.Pool != nil {
+		scheduler = policy.NewScheduler(&policy.SchedulerConfig{
+			Pool: config.Pool,
+		})
+	}
+
+	seenPrompts, err := lru.New[journal.Key, seenPrompt](seenPromptsSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seen prompts cache: %v", err)
+	}
+
+	receipts := config.Receipts
+	if receipts == nil {
+		receipts, err = receipt.NewStore(&receipt.StoreConfig{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create receipt store: %v", err)
+		}
+	}
+
+	a := &Agent{
 		twitterClient:       config.TwitterClient,
 		twitterClientConfig: config.TwitterClientConfig,
 
@@ -261,21 +535,39 @@ func NewAgent(config *AgentConfig) (*Agent, error) {
 		starknetClient: config.StarknetClient,
 		quoter:         config.Quoter,
 		nameCache:      config.NameCache,
+		journal:        config.Journal,
+		outbox:         config.Outbox,
+		network:        networkProfile,
+
+		expiryScheduler: expiry.NewScheduler(&expiry.SchedulerConfig{}),
+		seenPrompts:     seenPrompts,
+		seedExpiry:      config.ResumedFromJournal,
+		promptTasks:     tasks.NewTracker(),
+		receipts:        receipts,
 
 		agentIndexer:           config.AgentIndexer,
 		eventWatcher:           config.EventWatcher,
 		account:                config.Account,
 		accountDeploymentState: config.AccountDeploymentState,
 		txQueue:                config.TxQueue,
+		promptConsumer:         promptConsumer,
 
-		pool: config.Pool,
+		scheduler: scheduler,
 
 		startupBlockNumber:   config.StartupBlockNumber,
 		agentRegistryAddress: config.AgentRegistryAddress,
 		agentRegistryBlock:   config.AgentRegistryBlock,
 
 		eventCh: make(chan *indexer.EventSubscriptionData, 1000),
-	}, nil
+	}
+
+	a.drainGuard = drain.NewGuard(a.lookupDrained)
+
+	if a.outbox != nil {
+		a.outbox.SetOnDelivered(a.onOutgoingDelivered)
+	}
+
+	return a, nil
 }
 
 func (a *Agent) Run(ctx context.Context) error {
@@ -302,7 +594,10 @@ func (a *Agent) Run(ctx context.Context) error {
 		return a.nameCache.Run(ctx)
 	})
 	g.Go(func() error {
-		eventSubID := a.eventWatcher.Subscribe(indexer.EventAgentRegistered|indexer.EventPromptPaid|indexer.EventPromptConsumed|indexer.EventTeeUnencumbered, a.eventCh)
+		eventSubID := a.eventWatcher.SubscribeWithConfig(&indexer.EventSubscriberConfig{
+			Type: indexer.EventAgentRegistered | indexer.EventPromptPaid | indexer.EventPromptConsumed | indexer.EventPromptReclaimed | indexer.EventTeeUnencumbered,
+			Name: "agent",
+		}, a.eventCh)
 		defer a.eventWatcher.Unsubscribe(eventSubID)
 
 		return a.eventWatcher.Run(ctx)
@@ -313,31 +608,65 @@ func (a *Agent) Run(ctx context.Context) error {
 	g.Go(func() error {
 		return a.txQueue.Run(ctx)
 	})
+	g.Go(func() error {
+		return a.outbox.Run(ctx)
+	})
+	g.Go(func() error {
+		return a.runExpiryScheduler(ctx)
+	})
+	if a.seedExpiry {
+		g.Go(func() error {
+			return a.seedExpiryScheduler(ctx)
+		})
+	}
+	g.Go(func() error {
+		return a.scheduler.Run(ctx)
+	})
 	g.Go(func() error {
 		return a.ProcessEvents(ctx)
 	})
 
+	a.resumeJournaledPrompts(ctx)
+
 	return g.Wait()
 }
 
 type agentEventStartupController struct {
-	startupTasks       map[[32]byte]map[uint64]func()
+	startupTasks       map[[32]byte]map[uint64]*policy.Task
 	finishedStartup    bool
 	startupBlockNumber uint64
 	blockNumber        uint64
 }
 
-func (a *agentEventStartupController) ClearStartupTask(agentAddressBytes [32]byte, promptID uint64) {
+// ClearStartupTask drops the startup task of a prompt, also if it is only added
+// later. It reports whether a task was dropped.
+func (a *agentEventStartupController) ClearStartupTask(agentAddressBytes [32]byte, promptID uint64) bool {
 	if _, ok := a.startupTasks[agentAddressBytes]; !ok {
-		a.startupTasks[agentAddressBytes] = make(map[uint64]func())
+		a.startupTasks[agentAddressBytes] = make(map[uint64]*policy.Task)
 	}
 
+	cleared := a.startupTasks[agentAddressBytes][promptID] != nil
 	a.startupTasks[agentAddressBytes][promptID] = nil
+
+	return cleared
 }
 
-func (a *agentEventStartupController) AddStartupTask(agentAddressBytes [32]byte, promptID uint64, task func()) {
+// RevertStartupTask drops the startup task of a prompt whose block was
+// reverted, so that a prompt paid again in its place can be added. It reports
+// whether a task was dropped.
+func (a *agentEventStartupController) RevertStartupTask(agentAddressBytes [32]byte, promptID uint64) bool {
+	task, ok := a.startupTasks[agentAddressBytes][promptID]
+	if !ok {
+		return false
+	}
+
+	delete(a.startupTasks[agentAddressBytes], promptID)
+	return task != nil
+}
+
+func (a *agentEventStartupController) AddStartupTask(agentAddressBytes [32]byte, promptID uint64, task *policy.Task) {
 	if _, ok := a.startupTasks[agentAddressBytes]; !ok {
-		a.startupTasks[agentAddressBytes] = make(map[uint64]func())
+		a.startupTasks[agentAddressBytes] = make(map[uint64]*policy.Task)
 	}
 
 	_, ok := a.startupTasks[agentAddressBytes][promptID]
@@ -362,17 +691,14 @@ func (a *agentEventStartupController) ShouldFinish() bool {
 	return a.isPastOrAtStartupBlock(a.blockNumber) && !a.finishedStartup
 }
 
-func (a *agentEventStartupController) FinishStartup(pool pond.Pool) {
+func (a *agentEventStartupController) FinishStartup(scheduler *policy.Scheduler) {
 	for _, tasks := range a.startupTasks {
 		for _, task := range tasks {
 			if task == nil {
 				continue
 			}
 
-			err := pool.Go(task)
-			if err != nil {
-				slog.Error("failed to pool startup task", "error", err)
-			}
+			scheduler.Submit(task)
 		}
 	}
 
@@ -382,25 +708,32 @@ func (a *agentEventStartupController) FinishStartup(pool pond.Pool) {
 
 func (a *Agent) ProcessEvents(ctx context.Context) error {
 	startupController := &agentEventStartupController{
-		startupTasks:       make(map[[32]byte]map[uint64]func()),
+		startupTasks:       make(map[[32]byte]map[uint64]*policy.Task),
 		finishedStartup:    false,
 		startupBlockNumber: a.startupBlockNumber,
 	}
 
 	for {
 		if startupController.ShouldFinish() {
-			startupController.FinishStartup(a.pool)
+			startupController.FinishStartup(a.scheduler)
 		}
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case data := <-a.eventCh:
+			if data.Rollback {
+				a.onBlocksReverted(data.ToBlock, startupController)
+				continue
+			}
+
 			for _, ev := range data.Events {
 				if ev.Type == indexer.EventTeeUnencumbered {
 					a.onTeeUnencumberedEvent(ev)
 				} else if ev.Type == indexer.EventPromptConsumed {
 					a.onPromptConsumedEvent(ev, startupController)
+				} else if ev.Type == indexer.EventPromptReclaimed {
+					a.onPromptReclaimedEvent(ev, startupController)
 				} else if ev.Type == indexer.EventPromptPaid {
 					a.onPromptPaidEvent(ctx, ev, startupController)
 				} else if ev.Type == indexer.EventAgentRegistered {
@@ -409,6 +742,39 @@ func (a *Agent) ProcessEvents(ctx context.Context) error {
 			}
 
 			startupController.SetBlockNumber(data.ToBlock)
+			a.checkpointJournal(data.ToBlock)
+		}
+	}
+}
+
+// onBlocksReverted forgets the prompts paid after block, so that the prompts
+// the chain includes again in their place are processed, and rewinds the
+// journal checkpoint to block. Prompts already answered cannot be taken back
+// and are kept.
+func (a *Agent) onBlocksReverted(block uint64, startupController *agentEventStartupController) {
+	slog.Warn("blocks reverted by chain reorganization", "block", block)
+
+	reverted := make([]journal.Key, 0)
+	for _, key := range a.seenPrompts.Keys() {
+		if seen, ok := a.seenPrompts.Peek(key); ok && seen.block > block {
+			a.seenPrompts.Remove(key)
+			reverted = append(reverted, key)
+		}
+	}
+
+	if a.journal != nil {
+		dropped, err := a.journal.Rewind(block)
+		if err != nil {
+			slog.Error("failed to rewind prompt journal", "block", block, "error", err)
+		}
+		for _, entry := range dropped {
+			reverted = append(reverted, journal.NewKey(entry.AgentAddress, entry.PromptID))
+		}
+	}
+
+	for _, key := range reverted {
+		if startupController.RevertStartupTask(key.AgentAddress, key.PromptID) {
+			a.promptTasks.Forget(new(felt.Felt).SetBytes(key.AgentAddress[:]), key.PromptID)
 		}
 	}
 }
@@ -425,6 +791,13 @@ func (a *Agent) onAgentRegisteredEvent(ev *indexer.Event, startupController *age
 	}
 
 	a.nameCache.EnqueueForValidation(agentRegisteredEvent.Name)
+
+	a.expiryScheduler.Track(&expiry.Agent{
+		Address: agentRegisteredEvent.Agent,
+		Creator: agentRegisteredEvent.Creator,
+		Name:    agentRegisteredEvent.Name,
+		EndTime: agentRegisteredEvent.EndTime,
+	})
 }
 
 func (a *Agent) onTeeUnencumberedEvent(ev *indexer.Event) {
@@ -457,7 +830,35 @@ func (a *Agent) onPromptConsumedEvent(ev *indexer.Event, startupController *agen
 
 	slog.Info("noticed prompt was already consumed", "agent_address", ev.Raw.FromAddress, "prompt_id", promptConsumedEvent.PromptID)
 
-	startupController.ClearStartupTask(ev.Raw.FromAddress.Bytes(), promptConsumedEvent.PromptID)
+	if startupController.ClearStartupTask(ev.Raw.FromAddress.Bytes(), promptConsumedEvent.PromptID) {
+		a.promptTasks.Forget(ev.Raw.FromAddress, promptConsumedEvent.PromptID)
+	}
+
+	if entry, ok := a.getJournalEntry(ev.Raw.FromAddress, promptConsumedEvent.PromptID); ok && entry.Stage < journal.StageConsumeSubmitted {
+		a.recordPromptStage(&entry, journal.StageSkipped)
+	}
+}
+
+func (a *Agent) onPromptReclaimedEvent(ev *indexer.Event, startupController *agentEventStartupController) {
+	promptReclaimedEvent, ok := ev.ToPromptReclaimedEvent()
+	if !ok {
+		slog.Warn("failed to convert event to prompt reclaimed event", "event", ev)
+		return
+	}
+
+	slog.Info("prompt was reclaimed", "agent_address", ev.Raw.FromAddress, "prompt_id", promptReclaimedEvent.PromptID, "reclaimer", promptReclaimedEvent.Reclaimer)
+
+	if startupController.ClearStartupTask(ev.Raw.FromAddress.Bytes(), promptReclaimedEvent.PromptID) {
+		a.promptTasks.Forget(ev.Raw.FromAddress, promptReclaimedEvent.PromptID)
+	}
+
+	if a.promptTasks.Reclaim(ev.Raw.FromAddress, promptReclaimedEvent.PromptID) {
+		return
+	}
+
+	if entry, ok := a.getJournalEntry(ev.Raw.FromAddress, promptReclaimedEvent.PromptID); ok && entry.Stage < journal.StageConsumeSubmitted {
+		a.recordPromptStage(&entry, journal.StageSkipped)
+	}
 }
 
 func (a *Agent) onPromptPaidEvent(ctx context.Context, ev *indexer.Event, startupController *agentEventStartupController) {
@@ -468,51 +869,143 @@ func (a *Agent) onPromptPaidEvent(ctx context.Context, ev *indexer.Event, startu
 	}
 
 	slog.Info("received prompt paid event", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID)
+	metrics.PromptsReceived.Inc()
 
-	task := func() {
-		slog.Info("processing prompt paid event",
-			"agent_address", ev.Raw.FromAddress,
-			"tweet_id", promptPaidEvent.TweetID,
-			"prompt_id", promptPaidEvent.PromptID)
+	// A prompt id paid again for another tweet comes from a block included in
+	// place of a reverted one, and is a new prompt.
+	if entry, ok := a.getJournalEntry(ev.Raw.FromAddress, promptPaidEvent.PromptID); ok {
+		if entry.TweetID == promptPaidEvent.TweetID {
+			slog.Info("prompt already journaled", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "stage", entry.Stage)
+			return
+		}
+		slog.Warn("prompt id was paid again for another tweet", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "journaled_tweet_id", entry.TweetID)
+	}
 
-		agentInfo, err := a.agentIndexer.GetOrFetchAgentInfo(ctx, ev.Raw.FromAddress, ev.Raw.BlockNumber)
-		if err != nil {
-			slog.Warn("failed to get agent info", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "error", err)
+	// The journal may be disabled or have pruned the prompt, so prompts seen
+	// since startup are remembered separately.
+	key := journal.NewKey(ev.Raw.FromAddress, promptPaidEvent.PromptID)
+	if seen, ok := a.seenPrompts.Get(key); ok {
+		if seen.tweetID == promptPaidEvent.TweetID {
+			slog.Info("prompt already seen", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID)
 			return
 		}
+		slog.Warn("prompt id was paid again for another tweet", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "seen_tweet_id", seen.tweetID)
+	}
+	a.seenPrompts.Add(key, seenPrompt{tweetID: promptPaidEvent.TweetID, block: ev.Raw.BlockNumber})
 
+	entry := newPromptEntry(ev.Raw.FromAddress, promptPaidEvent, ev.Raw.BlockNumber)
+	a.recordPromptStage(&entry, journal.StagePaid)
+
+	task := a.newPromptTask(ctx, entry)
+
+	if startupController.IsStartupPhase() {
+		slog.Info("adding startup task", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID)
+		startupController.AddStartupTask(ev.Raw.FromAddress.Bytes(), promptPaidEvent.PromptID, task)
+	} else {
+		a.submitPromptTask(task)
+	}
+}
+
+// currentPromptEntry returns the latest state of a queued prompt. It reports
+// false if a chain reorganization reverted the prompt, or paid its id again
+// for another tweet, since it was queued.
+func (a *Agent) currentPromptEntry(entry journal.Entry) (journal.Entry, bool) {
+	if current, ok := a.getJournalEntry(entry.AgentAddress, entry.PromptID); ok {
+		return current, current.TweetID == entry.TweetID
+	}
+
+	seen, ok := a.seenPrompts.Peek(journal.NewKey(entry.AgentAddress, entry.PromptID))
+	return entry, ok && seen.tweetID == entry.TweetID
+}
+
+// newPromptTask wraps processing of a journaled prompt for the scheduler.
+func (a *Agent) newPromptTask(ctx context.Context, entry journal.Entry) *policy.Task {
+	a.promptTasks.Queue(entry.AgentAddress, entry.PromptID)
+
+	return &policy.Task{
+		AgentAddress: entry.AgentAddress,
+		User:         entry.User,
+		PromptID:     entry.PromptID,
+		Prompt:       entry.Prompt,
+		Run: func() {
+			a.processPrompt(ctx, entry)
+		},
+	}
+}
+
+// submitPromptTask queues a prompt. Prompts deferred by the scheduler stay
+// journaled as paid until they run.
+func (a *Agent) submitPromptTask(task *policy.Task) {
+	if delay := a.scheduler.Submit(task); delay > 0 {
+		metrics.PromptsDeferred.Inc()
+	}
+}
+
+// processPrompt runs a prompt from its last journaled stage to completion.
+func (a *Agent) processPrompt(ctx context.Context, entry journal.Entry) {
+	ctx, done, ok := a.promptTasks.Start(ctx, entry.AgentAddress, entry.PromptID)
+	if !ok {
+		slog.Info("prompt was reclaimed or is already being processed, skipping", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID)
+		return
+	}
+	defer done()
+
+	entry, ok = a.currentPromptEntry(entry)
+	if !ok || entry.Stage.IsFinal() {
+		slog.Info("prompt was reverted or already finished, skipping", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID)
+		return
+	}
+
+	slog.Info("processing prompt paid event",
+		"agent_address", entry.AgentAddress,
+		"tweet_id", entry.TweetID,
+		"prompt_id", entry.PromptID,
+		"stage", entry.Stage)
+
+	agentInfo, err := a.agentIndexer.GetOrFetchAgentInfo(ctx, entry.AgentAddress, entry.Block)
+	if err != nil {
+		slog.Warn("failed to get agent info", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "error", err)
+		return
+	}
+
+	a.trackAgentExpiry(&agentInfo)
+
+	// Once consumption was submitted, the prompt is expected to be consumed and
+	// the agent may have expired in the meantime. Once replies are queued, only
+	// their delivery is left.
+	if entry.Stage < journal.StageConsumeSubmitted && len(entry.Outgoing) == 0 {
 		timeNow := uint64(time.Now().Unix())
 
 		if timeNow >= agentInfo.EndTime {
-			slog.Info("agent is expired", "agent_address", ev.Raw.FromAddress, "end_time", agentInfo.EndTime)
+			slog.Info("agent is expired", "agent_address", entry.AgentAddress, "end_time", agentInfo.EndTime)
+			a.recordPromptStage(&entry, journal.StageSkipped)
 			return
 		}
 
-		isPromptConsumed, err := a.isPromptConsumed(ctx, ev.Raw.FromAddress, promptPaidEvent.PromptID)
+		isPromptConsumed, err := a.isPromptConsumed(ctx, entry.AgentAddress, entry.PromptID)
 		if err != nil {
-			slog.Warn("failed to check if prompt is consumed", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "error", err)
+			slog.Warn("failed to check if prompt is consumed", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "error", err)
 			return
 		}
 
 		if isPromptConsumed {
-			slog.Info("prompt already consumed", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID)
+			slog.Info("prompt already consumed", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID)
+			a.recordPromptStage(&entry, journal.StageSkipped)
 			return
 		}
-
-		err = a.ProcessPromptPaidEvent(ctx, ev.Raw.FromAddress, promptPaidEvent, ev.Raw.BlockNumber)
-		if err != nil {
-			slog.Warn("failed to process prompt paid event", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "error", err)
-		}
 	}
 
-	if startupController.IsStartupPhase() {
-		slog.Info("adding startup task", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID)
-		startupController.AddStartupTask(ev.Raw.FromAddress.Bytes(), promptPaidEvent.PromptID, task)
-	} else {
-		err := a.pool.Go(task)
-		if err != nil {
-			slog.Error("failed to pool startup task", "agent_address", ev.Raw.FromAddress, "prompt_id", promptPaidEvent.PromptID, "error", err)
+	err = a.reactToTweet(ctx, &agentInfo, &entry)
+	if err != nil && a.promptTasks.IsReclaimed(entry.AgentAddress, entry.PromptID) {
+		slog.Info("prompt was reclaimed while processing", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "error", err)
+		if entry.Stage < journal.StageConsumeSubmitted {
+			a.recordPromptStage(&entry, journal.StageSkipped)
 		}
+	} else if err != nil {
+		slog.Warn("failed to process prompt paid event", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "error", err)
+		metrics.PromptsFailed.Inc()
+	} else {
+		metrics.PromptsProcessed.Inc()
 	}
 }
 
@@ -522,56 +1015,161 @@ func (a *Agent) ProcessPromptPaidEvent(ctx context.Context, agentAddress *felt.F
 		return fmt.Errorf("failed to get agent info: %v", err)
 	}
 
-	return a.reactToTweet(ctx, &agentInfo, promptPaidEvent)
-}
+	entry, ok := a.getJournalEntry(agentAddress, promptPaidEvent.PromptID)
+	if !ok {
+		entry = newPromptEntry(agentAddress, promptPaidEvent, block)
+	}
 
-func (a *Agent) reactToTweet(ctx context.Context, agentInfo *indexer.AgentInfo, promptPaidEvent *indexer.PromptPaidEvent) error {
-	slog.Info("generating AI response", "tweet_id", promptPaidEvent.TweetID)
+	return a.reactToTweet(ctx, &agentInfo, &entry)
+}
 
-	expectedTweet := fmt.Sprintf("@%s :%s: %s", a.twitterClientConfig.Username, agentInfo.Name, promptPaidEvent.Prompt)
-	if len(expectedTweet) > 280 {
-		return fmt.Errorf("prompt is too long, expected %d tokens, got %d", 280, len(expectedTweet))
+func (a *Agent) reactToTweet(ctx context.Context, agentInfo *indexer.AgentInfo, entry *journal.Entry) error {
+	promptPaidEvent := &indexer.PromptPaidEvent{
+		User:     entry.User,
+		PromptID: entry.PromptID,
+		TweetID:  entry.TweetID,
+		Prompt:   entry.Prompt,
 	}
 
-	metadata := a.buildChatMetadata(agentInfo, promptPaidEvent)
-	resp, err := a.chatCompletion.Prompt(ctx, metadata, agentInfo.SystemPrompt, promptPaidEvent.Prompt)
-	if err != nil {
-		return fmt.Errorf("failed to generate AI response: %v", err)
+	if entry.Stage < journal.StageAnswered {
+		slog.Info("generating AI response", "tweet_id", promptPaidEvent.TweetID)
+
+		expectedTweet := fmt.Sprintf("@%s :%s: %s", a.twitterClientConfig.Username, agentInfo.Name, promptPaidEvent.Prompt)
+		if len(expectedTweet) > 280 {
+			a.recordPromptStage(entry, journal.StageSkipped)
+			return fmt.Errorf("prompt is too long, expected %d tokens, got %d", 280, len(expectedTweet))
+		}
+
+		model := snaccount.FeltToShortString(agentInfo.Model)
+		slog.Info("routing prompt to agent model", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "model", model)
+
+		metadata := a.buildChatMetadata(agentInfo, promptPaidEvent)
+		promptStart := time.Now()
+		resp, err := a.chatCompletion.Prompt(chat.WithModel(ctx, model), metadata, agentInfo.SystemPrompt, promptPaidEvent.Prompt)
+		metrics.LLMLatency.WithLabelValues(model).Observe(time.Since(promptStart).Seconds())
+		if err != nil {
+			metrics.LLMErrors.WithLabelValues(model).Inc()
+			return fmt.Errorf("failed to generate AI response: %v", err)
+		}
+
+		entry.Response = resp.Response
+
+		if resp.Drain != nil {
+			respAddress, err := starknetgoutils.HexToFelt(resp.Drain.Address)
+			if err != nil {
+				slog.Warn("failed to convert address to felt", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", err)
+				entry.ErrorReply = "Seems like the drain address is invalid. Please try again."
+			} else {
+				entry.DrainTo = respAddress
+				entry.DecidedDrainTo = respAddress
+			}
+		}
+
+		a.recordPromptStage(entry, journal.StageAnswered)
+	} else {
+		slog.Info("resuming prompt from journal", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "stage", entry.Stage)
 	}
 
-	isDrain := resp.Drain != nil
+	isDrain := entry.DrainTo != nil
 	drainTo := agentInfo.Address
-	errorReply := ""
-
-	drainTarget := ""
 	if isDrain {
-		drainTarget = resp.Drain.Address
+		drainTo = entry.DrainTo
 	}
-	slog.Info("reacting to tweet", "agent_address", agentInfo.Address, "tweet_id", promptPaidEvent.TweetID, "prompt_id", promptPaidEvent.PromptID, "prompt", promptPaidEvent.Prompt, "is_drain", isDrain, "drain_target", drainTarget)
 
-	if isDrain {
-		respAddress, err := starknetgoutils.HexToFelt(resp.Drain.Address)
-		if err != nil {
-			slog.Warn("failed to convert address to felt", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", err)
+	slog.Info("reacting to tweet", "agent_address", agentInfo.Address, "tweet_id", promptPaidEvent.TweetID, "prompt_id", promptPaidEvent.PromptID, "prompt", promptPaidEvent.Prompt, "is_drain", isDrain, "drain_target", entry.DrainTo)
 
-			isDrain = false
-			errorReply = "Seems like the drain address is invalid. Please try again."
-		} else {
-			drainTo = respAddress
-		}
+	if entry.Stage < journal.StageConsumeSubmitted && a.promptTasks.IsReclaimed(agentInfo.Address, promptPaidEvent.PromptID) {
+		a.recordPromptStage(entry, journal.StageSkipped)
+		return fmt.Errorf("prompt was reclaimed")
 	}
 
-	txHash := new(felt.Felt)
-	if !debug.IsDebugDisableConsumption() {
-		var err error
-		txHash, err = a.consumePrompt(ctx, agentInfo.Address, promptPaidEvent.PromptID, drainTo)
-		if err != nil {
-			slog.Warn("failed to consume prompt", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", snaccount.FormatRpcError(err))
-			return fmt.Errorf("failed to consume prompt: %v", err)
+	if entry.Stage < journal.StageConsumeConfirmed && !debug.IsDebugDisableConsumption() {
+		alreadyConsumed := false
+		if entry.Stage == journal.StageConsumeSubmitted {
+			// The previous submission may or may not have made it on-chain.
+			isPromptConsumed, err := a.isPromptConsumed(ctx, agentInfo.Address, promptPaidEvent.PromptID)
+			if err != nil {
+				return fmt.Errorf("failed to check if prompt is consumed: %v", err)
+			}
+			alreadyConsumed = isPromptConsumed
 		}
+
+		if !alreadyConsumed && !entry.SkipConsume {
+			unlock := a.drainGuard.Lock(agentInfo.Address)
+
+			// Waits for a drain enqueued by an earlier prompt to settle.
+			isDrained, err := a.drainGuard.IsDrained(ctx, agentInfo.Address)
+			if err != nil {
+				unlock()
+				return fmt.Errorf("failed to check if agent is drained: %v", err)
+			}
+
+			if isDrained {
+				unlock()
+
+				slog.Info("agent already drained by an earlier prompt, skipping consumption", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "was_drain", isDrain)
+
+				isDrain = false
+				entry.DrainTo = nil
+				entry.ErrorReply = alreadyDrainedReply
+				entry.SkipConsume = true
+				a.recordPromptStage(entry, journal.StageAnswered)
+			} else {
+				a.recordPromptStage(entry, journal.StageConsumeSubmitted)
+
+				finishDrain := func(bool) {}
+				if isDrain {
+					finishDrain = a.drainGuard.BeginDrain(agentInfo.Address)
+				}
+
+				resultCh, err := a.promptConsumer.EnqueueConsumePrompt(ctx, agentInfo.Address, promptPaidEvent.PromptID, drainTo)
+				unlock()
+
+				if err != nil {
+					finishDrain(false)
+					slog.Warn("failed to consume prompt", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", snaccount.FormatRpcError(err))
+					return fmt.Errorf("failed to consume prompt: %v", err)
+				}
+
+				txHash, err := snaccount.WaitForResult(ctx, resultCh)
+				finishDrain(err == nil)
+				if err != nil {
+					slog.Warn("failed to consume prompt", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", snaccount.FormatRpcError(err))
+					return fmt.Errorf("failed to wait for transaction result: %v", err)
+				}
+
+				slog.Info("transaction broadcast successful", "tx_hash", txHash)
+
+				entry.TxHash = txHash
+				if isDrain {
+					metrics.PromptsDrained.Inc()
+				}
+			}
+		}
+
+		if !entry.SkipConsume {
+			a.recordPromptStage(entry, journal.StageConsumeConfirmed)
+		}
+	}
+
+	// The receipt commits to the consume transaction, so it is issued once the
+	// outcome of the answer is settled, and only once.
+	if !entry.ReceiptIssued && a.issueReceipt(ctx, agentInfo, entry) {
+		entry.ReceiptIssued = true
+		a.recordPromptStage(entry, entry.Stage)
+	}
+
+	txHash := entry.TxHash
+	if txHash == nil {
+		txHash = new(felt.Felt)
+	}
+
+	if debug.IsDebugDisableReplies() {
+		a.recordPromptStage(entry, journal.StageReplied)
+		return nil
 	}
 
-	if !debug.IsDebugDisableReplies() {
+	if len(entry.Outgoing) == 0 {
 		slog.Info("fetching tweet text", "tweet_id", promptPaidEvent.TweetID)
 		tweetText, err := a.twitterClient.GetTweetText(promptPaidEvent.TweetID)
 		if err != nil {
@@ -583,78 +1181,208 @@ func (a *Agent) reactToTweet(ctx context.Context, agentInfo *indexer.AgentInfo,
 		if err != nil {
 			slog.Warn("tweet text validation failed", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "error", err)
 			if !debug.IsDebugDisableTweetValidation() {
+				a.recordPromptStage(entry, journal.StageSkipped)
 				return nil
 			}
 		}
 
-		tweetAgentIdentifier := agentInfo.Name
-
-		nameValidCtx, _ := context.WithTimeout(ctx, 30*time.Second)
-		isNameValid, err := a.nameCache.IsValidWithWait(nameValidCtx, agentInfo.Name)
-		if err != nil {
-			slog.Error("error while checking name validity", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "name", agentInfo.Name, "error", err)
-			isNameValid = false
-		}
-		if !isNameValid {
-			slog.Warn("agent name is not valid", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "name", agentInfo.Name)
-			tweetAgentIdentifier = agentInfo.Address.String()
-		}
+		tweetAgentIdentifier := a.agentTweetIdentifier(ctx, agentInfo.Address, agentInfo.Name)
 
 		if isDrain {
-			slog.Info("sending tweet", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "drain_to", resp.Drain.Address)
-			tweet := fmt.Sprintf(":%s: was drained! Check it out on https://sepolia.voyager.online/tx/%s. Congratulations!", tweetAgentIdentifier, txHash)
-			err := a.twitterClient.SendTweet(tweet)
-			if err != nil {
-				slog.Warn("failed to send tweet", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "error", err)
-			}
-
-			slog.Info("replying as drained to", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "drain_to", resp.Drain.Address)
-			reply := fmt.Sprintf(":%s: Drained! Check it out on https://sepolia.voyager.online/tx/%s. Congratulations!", tweetAgentIdentifier, txHash)
-			err = a.twitterClient.ReplyToTweet(promptPaidEvent.TweetID, reply)
-			if err != nil {
-				slog.Warn("failed to reply to tweet", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "error", err)
-			}
+			slog.Info("sending tweet", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "drain_to", entry.DrainTo)
+			entry.Outgoing = append(entry.Outgoing, journal.Outgoing{
+				Kind: string(outbox.ItemKindTweet),
+				Text: fmt.Sprintf(":%s: was drained! Check it out on %s. Congratulations!", tweetAgentIdentifier, a.network.TxURL(txHash)),
+			})
+
+			slog.Info("replying as drained to", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "drain_to", entry.DrainTo)
+			entry.Outgoing = append(entry.Outgoing, journal.Outgoing{
+				Kind:    string(outbox.ItemKindReply),
+				TweetID: promptPaidEvent.TweetID,
+				Text:    fmt.Sprintf(":%s: Drained! Check it out on %s. Congratulations!", tweetAgentIdentifier, a.network.TxURL(txHash)),
+			})
 		}
 
 		var reply string
-		if len(errorReply) > 0 {
-			reply = errorReply
+		if len(entry.ErrorReply) > 0 {
+			reply = entry.ErrorReply
 		} else {
-			reply = resp.Response
+			reply = entry.Response
 		}
 
 		if strings.TrimSpace(reply) != "" {
 			slog.Info("replying to", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "reply", reply)
-			err = a.twitterClient.ReplyToTweet(promptPaidEvent.TweetID, fmt.Sprintf(":%s: %s", tweetAgentIdentifier, reply))
-			if err != nil {
-				slog.Warn("failed to reply to tweet", "agent_address", agentInfo.Address, "prompt_id", promptPaidEvent.PromptID, "tweet_id", promptPaidEvent.TweetID, "error", err)
-			}
+			entry.Outgoing = append(entry.Outgoing, journal.Outgoing{
+				Kind:    string(outbox.ItemKindReply),
+				TweetID: promptPaidEvent.TweetID,
+				Text:    fmt.Sprintf(":%s: %s", tweetAgentIdentifier, reply),
+			})
+		}
+
+		if len(entry.Outgoing) == 0 {
+			a.recordPromptStage(entry, journal.StageReplied)
+			return nil
 		}
+
+		// The outgoing tweets are journaled before they are queued so that
+		// they are queued again if the agent restarts before delivering them.
+		a.recordPromptStage(entry, entry.Stage)
 	}
 
+	a.enqueueOutgoing(entry)
+
 	return nil
 }
 
-func (a *Agent) consumePrompt(ctx context.Context, agentAddress *felt.Felt, promptID uint64, drainTo *felt.Felt) (*felt.Felt, error) {
-	fnCall := rpc.FunctionCall{
-		ContractAddress:    a.agentRegistryAddress,
-		EntryPointSelector: consumePromptSelector,
-		Calldata:           []*felt.Felt{agentAddress, new(felt.Felt).SetUint64(promptID), drainTo},
+// enqueueOutgoing queues the undelivered tweets of a prompt. The prompt is
+// recorded as replied by onOutgoingDelivered once all of them are delivered.
+func (a *Agent) enqueueOutgoing(entry *journal.Entry) {
+	for idx, outgoing := range entry.Outgoing {
+		if outgoing.Delivered {
+			continue
+		}
+
+		a.outbox.Enqueue(outbox.Item{
+			Kind:         outbox.ItemKind(outgoing.Kind),
+			AgentAddress: entry.AgentAddress,
+			PromptID:     entry.PromptID,
+			Index:        idx,
+			TweetID:      outgoing.TweetID,
+			Text:         outgoing.Text,
+		})
 	}
+}
 
-	ch, err := a.txQueue.Enqueue(ctx, []rpc.FunctionCall{fnCall})
-	if err != nil {
-		return nil, fmt.Errorf("failed to enqueue transaction: %v", err)
+// onOutgoingDelivered marks an outgoing tweet of a prompt as delivered, and
+// the prompt as replied once all of its tweets are.
+func (a *Agent) onOutgoingDelivered(item outbox.Item) {
+	if item.AgentAddress == nil || item.Kind == outbox.ItemKindAnnouncement {
+		return
+	}
+
+	entry, ok := a.getJournalEntry(item.AgentAddress, item.PromptID)
+	if !ok || entry.Stage.IsFinal() || item.Index >= len(entry.Outgoing) {
+		return
 	}
 
-	txHash, err := snaccount.WaitForResult(ctx, ch)
+	entry.Outgoing[item.Index].Delivered = true
+
+	stage := entry.Stage
+	if entry.IsDelivered() {
+		stage = journal.StageReplied
+	}
+	a.recordPromptStage(&entry, stage)
+}
+
+// issueReceipt signs and stores a receipt of the agent's decision on a prompt,
+// reporting whether it succeeded.
+func (a *Agent) issueReceipt(ctx context.Context, agentInfo *indexer.AgentInfo, entry *journal.Entry) bool {
+	promptPaidEvent := &indexer.PromptPaidEvent{
+		User:     entry.User,
+		PromptID: entry.PromptID,
+		TweetID:  entry.TweetID,
+		Prompt:   entry.Prompt,
+	}
+
+	r := receipt.New(&receipt.Params{
+		AgentAddress:  agentInfo.Address,
+		PromptID:      entry.PromptID,
+		Prompt:        entry.Prompt,
+		SystemPrompt:  agentInfo.SystemPrompt,
+		Metadata:      a.buildChatMetadata(agentInfo, promptPaidEvent),
+		Model:         snaccount.FeltToShortString(agentInfo.Model),
+		Response:      entry.Response,
+		DrainTo:       entry.DecidedDrainTo,
+		ConsumeTxHash: entry.TxHash,
+	})
+
+	if err := r.Sign(ctx, a.account); err != nil {
+		slog.Error("failed to sign receipt", "agent_address", agentInfo.Address, "prompt_id", entry.PromptID, "error", err)
+		return false
+	}
+
+	if err := a.receipts.Add(r); err != nil {
+		slog.Error("failed to store receipt", "agent_address", agentInfo.Address, "prompt_id", entry.PromptID, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// agentTweetIdentifier returns the name to tweet an agent as, falling back to
+// its address if the name is not appropriate.
+func (a *Agent) agentTweetIdentifier(ctx context.Context, agentAddress *felt.Felt, name string) string {
+	nameValidCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	isNameValid, err := a.nameCache.IsValidWithWait(nameValidCtx, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to wait for transaction result: %v", err)
+		slog.Error("error while checking name validity", "agent_address", agentAddress, "name", name, "error", err)
+		isNameValid = false
+	}
+	if !isNameValid {
+		slog.Warn("agent name is not valid", "agent_address", agentAddress, "name", name)
+		return agentAddress.String()
+	}
+
+	return name
+}
+
+func newPromptEntry(agentAddress *felt.Felt, promptPaidEvent *indexer.PromptPaidEvent, block uint64) journal.Entry {
+	return journal.Entry{
+		AgentAddress: agentAddress,
+		PromptID:     promptPaidEvent.PromptID,
+		TweetID:      promptPaidEvent.TweetID,
+		User:         promptPaidEvent.User,
+		Prompt:       promptPaidEvent.Prompt,
+		Block:        block,
 	}
+}
 
-	slog.Info("transaction broadcast successful", "tx_hash", txHash)
+func (a *Agent) getJournalEntry(agentAddress *felt.Felt, promptID uint64) (journal.Entry, bool) {
+	if a.journal == nil {
+		return journal.Entry{}, false
+	}
 
-	return txHash, nil
+	return a.journal.Get(agentAddress, promptID)
+}
+
+// recordPromptStage advances the prompt to stage and persists it if the
+// journal is enabled. Failing to persist is logged but not fatal, the prompt
+// is then redone from an earlier stage after a restart.
+func (a *Agent) recordPromptStage(entry *journal.Entry, stage journal.Stage) {
+	entry.Stage = stage
+
+	if a.journal == nil {
+		return
+	}
+
+	if err := a.journal.Record(*entry); err != nil {
+		slog.Error("failed to record prompt stage", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "stage", stage, "error", err)
+	}
+}
+
+func (a *Agent) checkpointJournal(block uint64) {
+	if a.journal == nil {
+		return
+	}
+
+	if err := a.journal.Checkpoint(block); err != nil {
+		slog.Error("failed to checkpoint prompt journal", "block", block, "error", err)
+	}
+}
+
+// resumeJournaledPrompts schedules every prompt left unfinished by a previous run.
+func (a *Agent) resumeJournaledPrompts(ctx context.Context) {
+	if a.journal == nil {
+		return
+	}
+
+	for _, entry := range a.journal.Pending() {
+		slog.Info("resuming journaled prompt", "agent_address", entry.AgentAddress, "prompt_id", entry.PromptID, "stage", entry.Stage)
+
+		a.submitPromptTask(a.newPromptTask(ctx, entry))
+	}
 }
 
 type QuoteData struct {
@@ -726,7 +1454,7 @@ func (a *Agent) isPromptConsumed(ctx context.Context, agentAddress *felt.Felt, p
 
 func (a *Agent) checkAccountBalance(ctx context.Context) (*big.Int, error) {
 	fnCall := rpc.FunctionCall{
-		ContractAddress:    ethAddress,
+		ContractAddress:    a.network.FeeTokenAddress,
 		EntryPointSelector: balanceOfSelector,
 		Calldata:           []*felt.Felt{a.account.Address()},
 	}
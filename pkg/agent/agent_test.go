@@ -355,6 +355,8 @@ func (m *MockNetwork) GetLogs(eventsInput rpc.EventsInput) ([]rpc.EmittedEvent,
 
 		for _, log := range blockLogs {
 			if filterLog(log) {
+				log.BlockNumber = blockNumber
+				log.BlockHash = mockBlockHash(blockNumber)
 				logs = append(logs, log)
 			}
 		}
@@ -369,6 +371,10 @@ func (m *MockNetwork) SetLogs(blockNumber uint64, logs []rpc.EmittedEvent) {
 	m.logs[blockNumber] = logs
 }
 
+func mockBlockHash(blockNumber uint64) *felt.Felt {
+	return new(felt.Felt).SetUint64(blockNumber + 1)
+}
+
 func hashCall(call rpc.FunctionCall) [32]byte {
 	var byt []byte
 	byt = append(byt, call.ContractAddress.Marshal()...)
@@ -435,6 +441,17 @@ func NewMockProviderFromNetwork(network *MockNetwork) *MockProvider {
 			BlockNumber: func(_ context.Context) (uint64, error) {
 				return network.BlockNumber()
 			},
+			BlockWithTxHashes: func(_ context.Context, blockID rpc.BlockID) (interface{}, error) {
+				if blockID.Number == nil {
+					return nil, fmt.Errorf("invalid block id")
+				}
+				return &rpc.BlockTxHashes{
+					BlockHeader: rpc.BlockHeader{
+						BlockHash:   mockBlockHash(*blockID.Number),
+						BlockNumber: *blockID.Number,
+					},
+				}, nil
+			},
 			Events: func(_ context.Context, input rpc.EventsInput) (*rpc.EventChunk, error) {
 				logs, err := network.GetLogs(input)
 				if err != nil {
@@ -661,6 +678,15 @@ func TestProcessPromptPaidEvent(t *testing.T) {
 					ReturnResult: systemPromptFelts,
 				})
 
+				network.SetCallOutput(rpc.FunctionCall{
+					ContractAddress:    agentAddress,
+					EntryPointSelector: starknetgoutils.GetSelectorFromNameFelt("get_is_drained"),
+					Calldata:           []*felt.Felt{},
+				}, MockNetworkCallOutput{
+					BlockNumber:  1,
+					ReturnResult: []*felt.Felt{new(felt.Felt).SetUint64(0)},
+				})
+
 				network.SetCallOutput(rpc.FunctionCall{
 					ContractAddress:    agentRegistryAddress,
 					EntryPointSelector: starknetgoutils.GetSelectorFromNameFelt("consume_prompt"),
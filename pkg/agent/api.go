@@ -6,10 +6,17 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
+	"github.com/NethermindEth/juno/core/felt"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// AdminServerAddr is the address of the server exposing admin routes, only
+// reachable from the host running the agent.
+const AdminServerAddr = "127.0.0.1:8081"
+
 func (a *Agent) StartServer(ctx context.Context) error {
 	gin.SetMode(gin.ReleaseMode)
 
@@ -66,9 +73,75 @@ func (a *Agent) StartServer(ctx context.Context) error {
 		c.JSON(http.StatusOK, resp)
 	})
 
+	router.GET("/outbox", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"pending": a.outbox.Pending(),
+			"failed":  a.outbox.Failed(),
+		})
+	})
+
+	router.GET("/scheduler", func(c *gin.Context) {
+		c.JSON(http.StatusOK, a.scheduler.Stats())
+	})
+
+	router.GET("/subscribers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, a.eventWatcher.SubscriberStats())
+	})
+
+	router.GET("/receipts/:agent_address/:prompt_id", func(c *gin.Context) {
+		agentAddress, err := new(felt.Felt).SetString(c.Param("agent_address"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid agent address")
+			return
+		}
+
+		promptID, err := strconv.ParseUint(c.Param("prompt_id"), 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid prompt id")
+			return
+		}
+
+		r, ok := a.receipts.Get(agentAddress, promptID)
+		if !ok {
+			c.String(http.StatusNotFound, "receipt not found")
+			return
+		}
+
+		c.JSON(http.StatusOK, r)
+	})
+
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Admin routes change the agent's behavior and are only served on the
+	// loopback interface.
+	adminRouter := gin.Default()
+
+	adminRouter.POST("/outbox/:id/replay", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		if err := a.outbox.Replay(id); err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	serve(ctx, ":8080", router)
+	serve(ctx, AdminServerAddr, adminRouter)
+
+	return nil
+}
+
+// serve serves handler on addr until ctx is done.
+func serve(ctx context.Context, addr string, handler http.Handler) {
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:    addr,
+		Handler: handler,
 	}
 
 	go func() {
@@ -83,6 +156,4 @@ func (a *Agent) StartServer(ctx context.Context) error {
 			slog.Error("server shutdown error", "error", err)
 		}
 	}()
-
-	return nil
 }
@@ -0,0 +1,253 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicDefaultBaseURL   = "https://api.anthropic.com/v1"
+	anthropicDefaultVersion   = "2023-06-01"
+	anthropicDefaultMaxTokens = 1024
+)
+
+// AnthropicChatCompletionConfig is the configuration for the AnthropicChatCompletion
+type AnthropicChatCompletionConfig struct {
+	HttpClient *http.Client
+	BaseURL    string
+	APIKey     string
+	Version    string
+	Model      string
+	MaxTokens  int
+}
+
+// AnthropicChatCompletion is an implementation of the ChatCompletion interface
+// for APIs following the Anthropic messages format
+type AnthropicChatCompletion struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	version    string
+	model      string
+	maxTokens  int
+}
+
+var _ ChatCompletion = (*AnthropicChatCompletion)(nil)
+
+// NewAnthropicChatCompletion creates a new AnthropicChatCompletion
+func NewAnthropicChatCompletion(config AnthropicChatCompletionConfig) *AnthropicChatCompletion {
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = anthropicDefaultBaseURL
+	}
+	if config.Version == "" {
+		config.Version = anthropicDefaultVersion
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = anthropicDefaultMaxTokens
+	}
+
+	return &AnthropicChatCompletion{
+		httpClient: config.HttpClient,
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		version:    config.Version,
+		model:      config.Model,
+		maxTokens:  config.MaxTokens,
+	}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature *float32           `json:"temperature,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// Prompt sends a prompt to the messages API and returns the response
+func (c *AnthropicChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*ChatCompletionResponse, error) {
+	resp, err := c.createMessage(ctx, &anthropicRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    metadata + "\n\n" + systemPrompt,
+		Messages: []anthropicMessage{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Tools: []anthropicTool{
+			{
+				Name:        drainToolName,
+				Description: drainToolDescription,
+				InputSchema: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"address": map[string]any{
+							"type":        "string",
+							"description": drainToolAddressDescription,
+						},
+					},
+					"required": []string{"address"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chat completion failed: %v", err)
+	}
+
+	result := &ChatCompletionResponse{}
+
+	var texts []string
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			if block.Name != drainToolName || result.Drain != nil {
+				continue
+			}
+
+			type drainArgs struct {
+				Address string `json:"address"`
+			}
+
+			var args drainArgs
+			if err := json.Unmarshal(block.Input, &args); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal drain arguments: %v", err)
+			}
+
+			result.Drain = &ChatCompletionDrainCall{
+				Address: args.Address,
+			}
+		}
+	}
+
+	result.Response = strings.Join(texts, "\n")
+
+	return result, nil
+}
+
+// ValidateName checks if a name is appropriate for social media posting
+func (c *AnthropicChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	temperature := float32(0.0)
+
+	resp, err := c.createMessage(ctx, &anthropicRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    validateNameSystemPrompt,
+		Messages: []anthropicMessage{
+			{
+				Role:    "user",
+				Content: fmt.Sprintf("Is this name appropriate: \"%s\"", name),
+			},
+		},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return false, fmt.Errorf("name validation failed: %v", err)
+	}
+
+	var content string
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content += block.Text
+		}
+	}
+
+	type ValidationResponse struct {
+		Appropriate bool `json:"appropriate"`
+	}
+
+	var validationResp ValidationResponse
+	if err := json.Unmarshal([]byte(extractJSONObject(content)), &validationResp); err != nil {
+		// Make a conservative decision if the response can't be parsed
+		return false, nil
+	}
+
+	return validationResp.Appropriate, nil
+}
+
+func (c *AnthropicChatCompletion) createMessage(ctx context.Context, req *anthropicRequest) (*anthropicResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", c.version)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", httpResp.StatusCode, string(respBody))
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(resp.Content) == 0 {
+		return nil, fmt.Errorf("no response received")
+	}
+
+	return &resp, nil
+}
+
+// extractJSONObject returns the outermost JSON object in s, as models often
+// wrap JSON answers in prose or code fences.
+func extractJSONObject(s string) string {
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start == -1 || end < start {
+		return s
+	}
+	return s[start : end+1]
+}
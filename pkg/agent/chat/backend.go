@@ -0,0 +1,86 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	BackendOpenAI           = "openai"
+	BackendOpenAICompatible = "openai-compatible"
+	BackendAnthropic        = "anthropic"
+	BackendHttp             = "http"
+	BackendEnsemble         = "ensemble"
+)
+
+// BackendConfig describes an LLM backend and the model it serves
+type BackendConfig struct {
+	Backend string            `json:"backend"`
+	Model   string            `json:"model"`
+	BaseURL string            `json:"base_url,omitempty"`
+	APIKey  string            `json:"api_key,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Members, Samples and Rule configure the ensemble backend.
+	Members []BackendConfig `json:"members,omitempty"`
+	Samples int             `json:"samples,omitempty"`
+	Rule    string          `json:"rule,omitempty"`
+}
+
+// NewChatCompletionFromBackendConfig creates the ChatCompletion described by config
+func NewChatCompletionFromBackendConfig(config BackendConfig) (ChatCompletion, error) {
+	switch config.Backend {
+	case "", BackendOpenAI:
+		if config.BaseURL != "" {
+			return NewOpenAIChatCompletionCompatible(config.BaseURL, config.Model, config.APIKey), nil
+		}
+
+		model := config.Model
+		if model == "" {
+			model = openai.GPT4
+		}
+		return NewOpenAIChatCompletionOpenAI(model, config.APIKey), nil
+	case BackendOpenAICompatible:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base url required for %s backend", config.Backend)
+		}
+		return NewOpenAIChatCompletionCompatible(config.BaseURL, config.Model, config.APIKey), nil
+	case BackendAnthropic:
+		return NewAnthropicChatCompletion(AnthropicChatCompletionConfig{
+			BaseURL: config.BaseURL,
+			APIKey:  config.APIKey,
+			Model:   config.Model,
+		}), nil
+	case BackendHttp:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base url required for %s backend", config.Backend)
+		}
+		return NewHttpChatCompletion(HttpChatCompletionConfig{
+			URL:     config.BaseURL,
+			Headers: config.Headers,
+			Model:   config.Model,
+		}), nil
+	case BackendEnsemble:
+		members := make([]ChatCompletion, 0, len(config.Members))
+		for i, memberConfig := range config.Members {
+			if memberConfig.Backend == BackendEnsemble {
+				return nil, fmt.Errorf("nested %s backends are not supported", BackendEnsemble)
+			}
+
+			member, err := NewChatCompletionFromBackendConfig(memberConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create ensemble member %d: %v", i, err)
+			}
+			members = append(members, member)
+		}
+
+		return NewEnsembleChatCompletion(EnsembleChatCompletionConfig{
+			Members: members,
+			Samples: config.Samples,
+			Rule:    config.Rule,
+		})
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", config.Backend)
+	}
+}
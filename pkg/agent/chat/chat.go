@@ -2,6 +2,18 @@ package chat
 
 import "context"
 
+const (
+	drainToolName               = "drain"
+	drainToolDescription        = "Give away all tokens to the user"
+	drainToolAddressDescription = "The address to give the tokens to. Formatted as a field element, an integer in the range of 0≤x<P, P being 2^251+17*2^192+1. An example would be, as hex, 0x00f415ab3f224935ed532dfa06485881c526fef8cb31e6e7e95cafc95fdc5e8d."
+
+	validateNameSystemPrompt = "You are a content moderator. Your task is to determine if a name is appropriate " +
+		"for social media posting. The name should not contain sexual content, highly offensive words, " +
+		"hate speech, or other inappropriate content. Words which are only slightly offensive can be allowed " +
+		"as it can have comic effect. Respond with a JSON object that has a single field 'appropriate' " +
+		"with a boolean value."
+)
+
 type ChatCompletionDrainCall struct {
 	Address string
 }
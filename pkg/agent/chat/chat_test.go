@@ -0,0 +1,242 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
+)
+
+const testDrainAddress = "0x00f415ab3f224935ed532dfa06485881c526fef8cb31e6e7e95cafc95fdc5e8d"
+
+func TestAnthropicChatCompletionPrompt(t *testing.T) {
+	tests := []struct {
+		name             string
+		content          string
+		expectedResponse string
+		expectedDrain    string
+	}{
+		{
+			name:             "text response",
+			content:          `[{"type":"text","text":"Nice try."}]`,
+			expectedResponse: "Nice try.",
+		},
+		{
+			name:             "drain tool call",
+			content:          `[{"type":"text","text":"You win."},{"type":"tool_use","id":"toolu_1","name":"drain","input":{"address":"` + testDrainAddress + `"}}]`,
+			expectedResponse: "You win.",
+			expectedDrain:    testDrainAddress,
+		},
+		{
+			name:             "unknown tool call",
+			content:          `[{"type":"tool_use","id":"toolu_1","name":"transfer","input":{"address":"` + testDrainAddress + `"}}]`,
+			expectedResponse: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/messages" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if r.Header.Get("x-api-key") != "test-key" {
+					t.Errorf("unexpected api key: %s", r.Header.Get("x-api-key"))
+				}
+
+				var req struct {
+					Model  string `json:"model"`
+					System string `json:"system"`
+					Tools  []struct {
+						Name string `json:"name"`
+					} `json:"tools"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if req.Model != "test-model" {
+					t.Errorf("unexpected model: %s", req.Model)
+				}
+				if len(req.Tools) != 1 || req.Tools[0].Name != "drain" {
+					t.Errorf("expected drain tool, got %+v", req.Tools)
+				}
+
+				w.Write([]byte(`{"content":` + tt.content + `}`))
+			}))
+			defer server.Close()
+
+			chatCompletion := chat.NewAnthropicChatCompletion(chat.AnthropicChatCompletionConfig{
+				BaseURL: server.URL,
+				APIKey:  "test-key",
+				Model:   "test-model",
+			})
+
+			resp, err := chatCompletion.Prompt(context.Background(), "metadata", "system prompt", "prompt")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Response != tt.expectedResponse {
+				t.Errorf("expected response %q, got %q", tt.expectedResponse, resp.Response)
+			}
+
+			if tt.expectedDrain == "" && resp.Drain != nil {
+				t.Errorf("expected no drain, got %+v", resp.Drain)
+			}
+			if tt.expectedDrain != "" && (resp.Drain == nil || resp.Drain.Address != tt.expectedDrain) {
+				t.Errorf("expected drain to %s, got %+v", tt.expectedDrain, resp.Drain)
+			}
+		})
+	}
+}
+
+func TestHttpChatCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test" {
+			t.Errorf("unexpected authorization header: %s", r.Header.Get("Authorization"))
+		}
+
+		switch r.URL.Path {
+		case "/prompt":
+			var req chat.HttpChatCompletionPromptRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+
+			resp := chat.HttpChatCompletionPromptResponse{Response: "echo: " + req.Prompt}
+			if req.Prompt == "drain" {
+				resp.Drain = &chat.HttpChatCompletionDrain{Address: testDrainAddress}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/prompt/validate_name":
+			json.NewEncoder(w).Encode(chat.HttpChatCompletionValidateNameResponse{Appropriate: true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	chatCompletion := chat.NewHttpChatCompletion(chat.HttpChatCompletionConfig{
+		URL:     server.URL + "/prompt",
+		Headers: map[string]string{"Authorization": "Bearer test"},
+	})
+
+	resp, err := chatCompletion.Prompt(context.Background(), "metadata", "system prompt", "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Response != "echo: hello" || resp.Drain != nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	resp, err = chatCompletion.Prompt(context.Background(), "metadata", "system prompt", "drain")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Drain == nil || resp.Drain.Address != testDrainAddress {
+		t.Errorf("expected drain to %s, got %+v", testDrainAddress, resp.Drain)
+	}
+
+	valid, err := chatCompletion.ValidateName(context.Background(), "name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !valid {
+		t.Error("expected name to be valid")
+	}
+}
+
+type staticChatCompletion struct {
+	response string
+}
+
+func (c *staticChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*chat.ChatCompletionResponse, error) {
+	return &chat.ChatCompletionResponse{Response: c.response}, nil
+}
+
+func (c *staticChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	return true, nil
+}
+
+type drainChatCompletion struct {
+	address string
+}
+
+func (c *drainChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*chat.ChatCompletionResponse, error) {
+	return &chat.ChatCompletionResponse{
+		Response: "drained",
+		Drain:    &chat.ChatCompletionDrainCall{Address: c.address},
+	}, nil
+}
+
+func (c *drainChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	return true, nil
+}
+
+func TestEnsembleChatCompletion(t *testing.T) {
+	drain := &drainChatCompletion{address: testDrainAddress}
+	drainUnpadded := &drainChatCompletion{address: "0xf415ab3f224935ed532dfa06485881c526fef8cb31e6e7e95cafc95fdc5e8d"}
+	refuse := &staticChatCompletion{response: "refused"}
+
+	tests := []struct {
+		name          string
+		members       []chat.ChatCompletion
+		rule          string
+		expectedDrain bool
+	}{
+		{
+			name:          "majority drains",
+			members:       []chat.ChatCompletion{drain, drainUnpadded, refuse},
+			rule:          chat.EnsembleRuleMajority,
+			expectedDrain: true,
+		},
+		{
+			name:          "majority refuses",
+			members:       []chat.ChatCompletion{drain, refuse, refuse},
+			rule:          chat.EnsembleRuleMajority,
+			expectedDrain: false,
+		},
+		{
+			name:          "unanimous refuses on a single dissent",
+			members:       []chat.ChatCompletion{drain, drain, refuse},
+			rule:          chat.EnsembleRuleUnanimous,
+			expectedDrain: false,
+		},
+		{
+			name:          "unanimous drains",
+			members:       []chat.ChatCompletion{drain, drainUnpadded},
+			rule:          chat.EnsembleRuleUnanimous,
+			expectedDrain: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ensemble, err := chat.NewEnsembleChatCompletion(chat.EnsembleChatCompletionConfig{
+				Members: tt.members,
+				Rule:    tt.rule,
+			})
+			if err != nil {
+				t.Fatalf("failed to create ensemble: %v", err)
+			}
+
+			resp, err := ensemble.Prompt(context.Background(), "", "", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (resp.Drain != nil) != tt.expectedDrain {
+				t.Errorf("expected drain %v, got %+v", tt.expectedDrain, resp)
+			}
+			if tt.expectedDrain && resp.Response != "drained" {
+				t.Errorf("expected drain response, got %s", resp.Response)
+			}
+			if !tt.expectedDrain && resp.Response != "refused" {
+				t.Errorf("expected refusal response, got %s", resp.Response)
+			}
+		})
+	}
+}
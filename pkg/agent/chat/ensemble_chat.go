@@ -0,0 +1,174 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+const (
+	// EnsembleRuleMajority drains when more than half of the samples call
+	// drain with the same address.
+	EnsembleRuleMajority = "majority"
+	// EnsembleRuleUnanimous drains only when every sample calls drain with the
+	// same address.
+	EnsembleRuleUnanimous = "unanimous"
+)
+
+// EnsembleChatCompletionConfig is the configuration for the EnsembleChatCompletion
+type EnsembleChatCompletionConfig struct {
+	// Members are the models sampled for every prompt.
+	Members []ChatCompletion
+	// Samples is the number of samples taken from each member. Defaults to 1.
+	Samples int
+	// Rule decides the drain outcome from the votes. Defaults to majority.
+	Rule string
+}
+
+// EnsembleChatCompletion samples several models, or one model several times,
+// and only returns a drain call when enough samples agree on it
+type EnsembleChatCompletion struct {
+	members []ChatCompletion
+	samples int
+	rule    string
+}
+
+var _ ChatCompletion = (*EnsembleChatCompletion)(nil)
+
+// NewEnsembleChatCompletion creates a new EnsembleChatCompletion
+func NewEnsembleChatCompletion(config EnsembleChatCompletionConfig) (*EnsembleChatCompletion, error) {
+	if len(config.Members) == 0 {
+		return nil, fmt.Errorf("no ensemble members configured")
+	}
+
+	if config.Samples <= 0 {
+		config.Samples = 1
+	}
+
+	switch config.Rule {
+	case "":
+		config.Rule = EnsembleRuleMajority
+	case EnsembleRuleMajority, EnsembleRuleUnanimous:
+	default:
+		return nil, fmt.Errorf("unknown ensemble rule: %s", config.Rule)
+	}
+
+	return &EnsembleChatCompletion{
+		members: config.Members,
+		samples: config.Samples,
+		rule:    config.Rule,
+	}, nil
+}
+
+type ensembleSample struct {
+	member   int
+	sample   int
+	response *ChatCompletionResponse
+	err      error
+}
+
+// Prompt samples every member concurrently and applies the ensemble rule to
+// the drain calls. Failed samples count as votes against draining.
+func (c *EnsembleChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*ChatCompletionResponse, error) {
+	samples := make([]*ensembleSample, 0, len(c.members)*c.samples)
+	for member := range c.members {
+		for sample := 0; sample < c.samples; sample++ {
+			samples = append(samples, &ensembleSample{member: member, sample: sample})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range samples {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.response, s.err = c.members[s.member].Prompt(ctx, metadata, systemPrompt, prompt)
+		}()
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	var firstErr error
+	succeeded := 0
+	for _, s := range samples {
+		if s.err != nil {
+			slog.Warn("ensemble sample failed", "member", s.member, "sample", s.sample, "error", s.err)
+			if firstErr == nil {
+				firstErr = s.err
+			}
+			continue
+		}
+		succeeded++
+
+		drainTo := ""
+		if s.response.Drain != nil {
+			drainTo = normalizeDrainAddress(s.response.Drain.Address)
+			votes[drainTo]++
+		}
+
+		slog.Info("ensemble vote", "member", s.member, "sample", s.sample, "drain", s.response.Drain != nil, "drain_to", drainTo)
+	}
+
+	if succeeded == 0 {
+		return nil, fmt.Errorf("all ensemble samples failed: %w", firstErr)
+	}
+
+	winner, winnerVotes := "", 0
+	for address, count := range votes {
+		if count > winnerVotes {
+			winner, winnerVotes = address, count
+		}
+	}
+
+	drain := false
+	switch c.rule {
+	case EnsembleRuleMajority:
+		drain = winnerVotes*2 > len(samples)
+	case EnsembleRuleUnanimous:
+		drain = winnerVotes == len(samples)
+	}
+
+	slog.Info("ensemble decision", "rule", c.rule, "samples", len(samples), "failed", len(samples)-succeeded, "drain_votes", winnerVotes, "drain", drain)
+
+	for _, s := range samples {
+		if s.err != nil {
+			continue
+		}
+
+		if drain && s.response.Drain != nil && normalizeDrainAddress(s.response.Drain.Address) == winner {
+			return s.response, nil
+		}
+		if !drain && s.response.Drain == nil {
+			return s.response, nil
+		}
+	}
+
+	// Every sample drained but no address won, reply with the first sample's
+	// text without the drain call.
+	for _, s := range samples {
+		if s.err == nil {
+			return &ChatCompletionResponse{Response: s.response.Response}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no ensemble response")
+}
+
+// ValidateName delegates name validation to the first member
+func (c *EnsembleChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	return c.members[0].ValidateName(ctx, name)
+}
+
+// normalizeDrainAddress makes equal addresses compare equal regardless of
+// leading zeros or case.
+func normalizeDrainAddress(address string) string {
+	addressFelt, err := new(felt.Felt).SetString(address)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(address))
+	}
+	return addressFelt.String()
+}
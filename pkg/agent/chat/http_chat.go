@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HttpChatCompletionConfig is the configuration for the HttpChatCompletion
+type HttpChatCompletionConfig struct {
+	HttpClient *http.Client
+	// URL receives prompts as HttpChatCompletionPromptRequest and answers with
+	// an HttpChatCompletionPromptResponse.
+	URL string
+	// ValidateNameURL receives names as HttpChatCompletionValidateNameRequest and
+	// answers with an HttpChatCompletionValidateNameResponse. Defaults to
+	// URL + "/validate_name".
+	ValidateNameURL string
+	// Headers are added to every request, e.g. for authorization.
+	Headers map[string]string
+	// Model is forwarded to the server in every request.
+	Model string
+}
+
+// HttpChatCompletionPromptRequest is the body sent to the prompt endpoint
+type HttpChatCompletionPromptRequest struct {
+	Model        string `json:"model,omitempty"`
+	Metadata     string `json:"metadata"`
+	SystemPrompt string `json:"system_prompt"`
+	Prompt       string `json:"prompt"`
+}
+
+// HttpChatCompletionDrain is the drain tool call returned by the prompt endpoint
+type HttpChatCompletionDrain struct {
+	Address string `json:"address"`
+}
+
+// HttpChatCompletionPromptResponse is the body returned by the prompt endpoint
+type HttpChatCompletionPromptResponse struct {
+	Response string                   `json:"response"`
+	Drain    *HttpChatCompletionDrain `json:"drain"`
+}
+
+// HttpChatCompletionValidateNameRequest is the body sent to the name validation endpoint
+type HttpChatCompletionValidateNameRequest struct {
+	Model string `json:"model,omitempty"`
+	Name  string `json:"name"`
+}
+
+// HttpChatCompletionValidateNameResponse is the body returned by the name validation endpoint
+type HttpChatCompletionValidateNameResponse struct {
+	Appropriate bool `json:"appropriate"`
+}
+
+// HttpChatCompletion is an implementation of the ChatCompletion interface for
+// arbitrary services speaking a minimal JSON protocol
+type HttpChatCompletion struct {
+	httpClient      *http.Client
+	url             string
+	validateNameURL string
+	headers         map[string]string
+	model           string
+}
+
+var _ ChatCompletion = (*HttpChatCompletion)(nil)
+
+// NewHttpChatCompletion creates a new HttpChatCompletion
+func NewHttpChatCompletion(config HttpChatCompletionConfig) *HttpChatCompletion {
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+	if config.ValidateNameURL == "" {
+		config.ValidateNameURL = strings.TrimSuffix(config.URL, "/") + "/validate_name"
+	}
+
+	return &HttpChatCompletion{
+		httpClient:      config.HttpClient,
+		url:             config.URL,
+		validateNameURL: config.ValidateNameURL,
+		headers:         config.Headers,
+		model:           config.Model,
+	}
+}
+
+// Prompt sends a prompt to the configured endpoint and returns the response
+func (c *HttpChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*ChatCompletionResponse, error) {
+	var resp HttpChatCompletionPromptResponse
+	if err := c.post(ctx, c.url, &HttpChatCompletionPromptRequest{
+		Model:        c.model,
+		Metadata:     metadata,
+		SystemPrompt: systemPrompt,
+		Prompt:       prompt,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("chat completion failed: %v", err)
+	}
+
+	result := &ChatCompletionResponse{
+		Response: resp.Response,
+	}
+
+	if resp.Drain != nil {
+		result.Drain = &ChatCompletionDrainCall{
+			Address: resp.Drain.Address,
+		}
+	}
+
+	return result, nil
+}
+
+// ValidateName checks if a name is appropriate for social media posting
+func (c *HttpChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	var resp HttpChatCompletionValidateNameResponse
+	if err := c.post(ctx, c.validateNameURL, &HttpChatCompletionValidateNameRequest{
+		Model: c.model,
+		Name:  name,
+	}, &resp); err != nil {
+		return false, fmt.Errorf("name validation failed: %v", err)
+	}
+
+	return resp.Appropriate, nil
+}
+
+func (c *HttpChatCompletion) post(ctx context.Context, url string, reqBody, respBody any) error {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(data))
+	}
+
+	if err := json.Unmarshal(data, respBody); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedModel is returned when a prompt is routed to a model that has
+// no configured backend and no fallback is set.
+var ErrUnsupportedModel = errors.New("unsupported model")
+
+type modelContextKey struct{}
+
+// WithModel returns a context that carries the on-chain model name an agent
+// registered with, to be picked up by a ModelRouterChatCompletion.
+func WithModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, modelContextKey{}, model)
+}
+
+// ModelFromContext returns the model name set by WithModel, if any.
+func ModelFromContext(ctx context.Context) (string, bool) {
+	model, ok := ctx.Value(modelContextKey{}).(string)
+	return model, ok
+}
+
+// ModelRouterChatCompletionConfig is the configuration for the ModelRouterChatCompletion
+type ModelRouterChatCompletionConfig struct {
+	// Routes maps on-chain model names to the backend serving them.
+	Routes map[string]ChatCompletion
+	// Fallback serves models without a route. If nil, unknown models are rejected.
+	Fallback ChatCompletion
+	// NameValidator serves ValidateName calls, which are not tied to an agent.
+	NameValidator ChatCompletion
+}
+
+// ModelRouterChatCompletion routes prompts to a backend based on the model
+// carried in the context
+type ModelRouterChatCompletion struct {
+	routes        map[string]ChatCompletion
+	fallback      ChatCompletion
+	nameValidator ChatCompletion
+}
+
+var _ ChatCompletion = (*ModelRouterChatCompletion)(nil)
+
+// NewModelRouterChatCompletion creates a new ModelRouterChatCompletion
+func NewModelRouterChatCompletion(config ModelRouterChatCompletionConfig) (*ModelRouterChatCompletion, error) {
+	if len(config.Routes) == 0 && config.Fallback == nil {
+		return nil, fmt.Errorf("no routes or fallback configured")
+	}
+
+	if config.NameValidator == nil {
+		config.NameValidator = config.Fallback
+	}
+	if config.NameValidator == nil {
+		return nil, fmt.Errorf("no name validator configured")
+	}
+
+	routes := make(map[string]ChatCompletion, len(config.Routes))
+	for model, chatCompletion := range config.Routes {
+		routes[model] = chatCompletion
+	}
+
+	return &ModelRouterChatCompletion{
+		routes:        routes,
+		fallback:      config.Fallback,
+		nameValidator: config.NameValidator,
+	}, nil
+}
+
+// Route returns the backend for the given model
+func (c *ModelRouterChatCompletion) Route(model string) (ChatCompletion, error) {
+	if chatCompletion, ok := c.routes[model]; ok {
+		return chatCompletion, nil
+	}
+
+	if c.fallback != nil {
+		return c.fallback, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedModel, model)
+}
+
+// SupportsModel reports whether the given model has a dedicated route
+func (c *ModelRouterChatCompletion) SupportsModel(model string) bool {
+	_, ok := c.routes[model]
+	return ok
+}
+
+// Prompt sends the prompt to the backend of the model carried in the context
+func (c *ModelRouterChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*ChatCompletionResponse, error) {
+	model, _ := ModelFromContext(ctx)
+
+	chatCompletion, err := c.Route(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return chatCompletion.Prompt(ctx, metadata, systemPrompt, prompt)
+}
+
+// ValidateName delegates name validation to the configured name validator
+func (c *ModelRouterChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
+	return c.nameValidator.ValidateName(ctx, name)
+}
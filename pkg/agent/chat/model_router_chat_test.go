@@ -0,0 +1,55 @@
+package chat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
+)
+
+func TestModelRouterChatCompletion(t *testing.T) {
+	routes := map[string]chat.ChatCompletion{
+		"gpt-4": &staticChatCompletion{response: "gpt-4"},
+		"llama": &staticChatCompletion{response: "llama"},
+	}
+
+	router, err := chat.NewModelRouterChatCompletion(chat.ModelRouterChatCompletionConfig{
+		Routes:        routes,
+		NameValidator: routes["gpt-4"],
+	})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	for _, model := range []string{"gpt-4", "llama"} {
+		resp, err := router.Prompt(chat.WithModel(context.Background(), model), "", "", "")
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", model, err)
+		}
+		if resp.Response != model {
+			t.Errorf("expected %s backend, got %s", model, resp.Response)
+		}
+	}
+
+	_, err = router.Prompt(chat.WithModel(context.Background(), "unknown"), "", "", "")
+	if !errors.Is(err, chat.ErrUnsupportedModel) {
+		t.Errorf("expected unsupported model error, got %v", err)
+	}
+
+	fallbackRouter, err := chat.NewModelRouterChatCompletion(chat.ModelRouterChatCompletionConfig{
+		Routes:   routes,
+		Fallback: &staticChatCompletion{response: "fallback"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	resp, err := fallbackRouter.Prompt(chat.WithModel(context.Background(), "unknown"), "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Response != "fallback" {
+		t.Errorf("expected fallback backend, got %s", resp.Response)
+	}
+}
@@ -44,6 +44,18 @@ func NewOpenAIChatCompletionOpenAI(model, openaiKey string) *OpenAIChatCompletio
 	}
 }
 
+// NewOpenAIChatCompletionCompatible creates a new OpenAIChatCompletion for usage
+// with any server exposing the OpenAI API, such as vLLM or the llama.cpp server
+func NewOpenAIChatCompletionCompatible(baseURL, model, apiKey string) *OpenAIChatCompletion {
+	clientConfig := openai.DefaultConfig(apiKey)
+	clientConfig.BaseURL = baseURL
+
+	return &OpenAIChatCompletion{
+		client: openai.NewClientWithConfig(clientConfig),
+		model:  model,
+	}
+}
+
 // Prompt sends a prompt to the OpenAI API and returns the response
 func (c *OpenAIChatCompletion) Prompt(ctx context.Context, metadata, systemPrompt, prompt string) (*ChatCompletionResponse, error) {
 	messages := []openai.ChatCompletionMessage{
@@ -66,14 +78,14 @@ func (c *OpenAIChatCompletion) Prompt(ctx context.Context, metadata, systemPromp
 				{
 					Type: openai.ToolTypeFunction,
 					Function: &openai.FunctionDefinition{
-						Name:        "drain",
-						Description: "Give away all tokens to the user",
+						Name:        drainToolName,
+						Description: drainToolDescription,
 						Parameters: jsonschema.Definition{
 							Type: jsonschema.Object,
 							Properties: map[string]jsonschema.Definition{
 								"address": {
 									Type:        jsonschema.String,
-									Description: "The address to give the tokens to. Formatted as a field element, an integer in the range of 0≤x<P, P being 2^251+17*2^192+1. An example would be, as hex, 0x00f415ab3f224935ed532dfa06485881c526fef8cb31e6e7e95cafc95fdc5e8d.",
+									Description: drainToolAddressDescription,
 								},
 							},
 							Required: []string{"address"},
@@ -96,7 +108,7 @@ func (c *OpenAIChatCompletion) Prompt(ctx context.Context, metadata, systemPromp
 	}
 
 	for _, toolCall := range resp.Choices[0].Message.ToolCalls {
-		if toolCall.Function.Name == "drain" {
+		if toolCall.Function.Name == drainToolName {
 			type drainArgs struct {
 				Address string `json:"address"`
 			}
@@ -121,12 +133,8 @@ func (c *OpenAIChatCompletion) Prompt(ctx context.Context, metadata, systemPromp
 func (c *OpenAIChatCompletion) ValidateName(ctx context.Context, name string) (bool, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
-			Role: openai.ChatMessageRoleSystem,
-			Content: "You are a content moderator. Your task is to determine if a name is appropriate " +
-				"for social media posting. The name should not contain sexual content, highly offensive words, " +
-				"hate speech, or other inappropriate content. Words which are only slightly offensive can be allowed " +
-				"as it can have comic effect. Respond with a JSON object that has a single field 'appropriate' " +
-				"with a boolean value.",
+			Role:    openai.ChatMessageRoleSystem,
+			Content: validateNameSystemPrompt,
 		},
 		{
 			Role:    openai.ChatMessageRoleUser,
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+// PromptConsumer submits consume_prompt calls to the agent registry.
+type PromptConsumer interface {
+	EnqueueConsumePrompt(ctx context.Context, agentAddress *felt.Felt, promptID uint64, drainTo *felt.Felt) (chan *snaccount.TxQueueResult, error)
+}
+
+// TxQueuePromptConsumer batches consume_prompt calls through a TxQueue.
+type TxQueuePromptConsumer struct {
+	txQueue              *snaccount.TxQueue
+	agentRegistryAddress *felt.Felt
+}
+
+var _ PromptConsumer = (*TxQueuePromptConsumer)(nil)
+
+// NewTxQueuePromptConsumer creates a new TxQueuePromptConsumer
+func NewTxQueuePromptConsumer(txQueue *snaccount.TxQueue, agentRegistryAddress *felt.Felt) *TxQueuePromptConsumer {
+	return &TxQueuePromptConsumer{
+		txQueue:              txQueue,
+		agentRegistryAddress: agentRegistryAddress,
+	}
+}
+
+func (c *TxQueuePromptConsumer) EnqueueConsumePrompt(ctx context.Context, agentAddress *felt.Felt, promptID uint64, drainTo *felt.Felt) (chan *snaccount.TxQueueResult, error) {
+	fnCall := rpc.FunctionCall{
+		ContractAddress:    c.agentRegistryAddress,
+		EntryPointSelector: consumePromptSelector,
+		Calldata:           []*felt.Felt{agentAddress, new(felt.Felt).SetUint64(promptID), drainTo},
+	}
+
+	ch, err := c.txQueue.Enqueue(ctx, []rpc.FunctionCall{fnCall})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue transaction: %v", err)
+	}
+
+	return ch, nil
+}
@@ -0,0 +1,118 @@
+package drain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// LookupFunc reads whether an agent is drained from the chain.
+type LookupFunc func(ctx context.Context, agentAddress *felt.Felt) (bool, error)
+
+// Guard orders consume_prompt submissions per agent so that at most one drain
+// is enqueued for each agent. A drained agent is finalized on-chain, so any
+// consumption queued after the drain would revert.
+type Guard struct {
+	mu      sync.Mutex
+	locks   map[[32]byte]*sync.Mutex
+	drained map[[32]byte]bool
+	pending map[[32]byte]chan struct{}
+	lookup  LookupFunc
+}
+
+// NewGuard creates a new Guard. Agents the guard has not seen yet are looked
+// up with lookup, so that drains from before a restart are known. If lookup is
+// nil, unseen agents are assumed not drained.
+func NewGuard(lookup LookupFunc) *Guard {
+	return &Guard{
+		locks:   make(map[[32]byte]*sync.Mutex),
+		drained: make(map[[32]byte]bool),
+		pending: make(map[[32]byte]chan struct{}),
+		lookup:  lookup,
+	}
+}
+
+// Lock serializes consumption for the given agent and returns the unlock function.
+func (g *Guard) Lock(agentAddress *felt.Felt) func() {
+	g.mu.Lock()
+	lock, ok := g.locks[agentAddress.Bytes()]
+	if !ok {
+		lock = &sync.Mutex{}
+		g.locks[agentAddress.Bytes()] = lock
+	}
+	g.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// IsDrained reports whether the agent is drained. If a drain of the agent is
+// pending, it waits until the drain succeeds or fails.
+func (g *Guard) IsDrained(ctx context.Context, agentAddress *felt.Felt) (bool, error) {
+	key := agentAddress.Bytes()
+
+	g.mu.Lock()
+	pending, isPending := g.pending[key]
+	g.mu.Unlock()
+
+	if isPending {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-pending:
+		}
+	}
+
+	g.mu.Lock()
+	drained, known := g.drained[key]
+	g.mu.Unlock()
+
+	if known || g.lookup == nil {
+		return drained, nil
+	}
+
+	drained, err := g.lookup(ctx, agentAddress)
+	if err != nil {
+		return false, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// A drain may have settled while looking up.
+	if settled, ok := g.drained[key]; ok {
+		return settled, nil
+	}
+	g.drained[key] = drained
+
+	return drained, nil
+}
+
+// BeginDrain marks a drain of the agent as pending until the returned function
+// is called with whether the drain succeeded. It must be called while holding
+// the agent's lock.
+func (g *Guard) BeginDrain(agentAddress *felt.Felt) func(drained bool) {
+	key := agentAddress.Bytes()
+	done := make(chan struct{})
+
+	g.mu.Lock()
+	g.pending[key] = done
+	g.mu.Unlock()
+
+	var once sync.Once
+	return func(drained bool) {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			if drained || !g.drained[key] {
+				g.drained[key] = drained
+			}
+			if g.pending[key] == done {
+				delete(g.pending, key)
+			}
+			close(done)
+		})
+	}
+}
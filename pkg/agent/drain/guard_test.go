@@ -0,0 +1,105 @@
+package drain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/drain"
+)
+
+var agentAddress = new(felt.Felt).SetUint64(1)
+
+func TestGuardWaitsForPendingDrain(t *testing.T) {
+	for _, drained := range []bool{true, false} {
+		guard := drain.NewGuard(nil)
+
+		unlock := guard.Lock(agentAddress)
+		finish := guard.BeginDrain(agentAddress)
+		unlock()
+
+		result := make(chan bool, 1)
+		go func() {
+			unlock := guard.Lock(agentAddress)
+			defer unlock()
+
+			isDrained, err := guard.IsDrained(context.Background(), agentAddress)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			result <- isDrained
+		}()
+
+		select {
+		case <-result:
+			t.Fatal("expected the later prompt to wait for the pending drain")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		finish(drained)
+
+		if got := <-result; got != drained {
+			t.Errorf("expected drained %t after the drain settled, got %t", drained, got)
+		}
+	}
+}
+
+func TestGuardCancelWhilePending(t *testing.T) {
+	guard := drain.NewGuard(nil)
+	guard.BeginDrain(agentAddress)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := guard.IsDrained(ctx, agentAddress); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context error, got %v", err)
+	}
+}
+
+func TestGuardSeedsFromLookup(t *testing.T) {
+	lookups := 0
+	guard := drain.NewGuard(func(ctx context.Context, agentAddress *felt.Felt) (bool, error) {
+		lookups++
+		if lookups == 1 {
+			return false, errors.New("unavailable")
+		}
+		return true, nil
+	})
+
+	if _, err := guard.IsDrained(context.Background(), agentAddress); err == nil {
+		t.Fatal("expected lookup error")
+	}
+
+	for range 2 {
+		isDrained, err := guard.IsDrained(context.Background(), agentAddress)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !isDrained {
+			t.Error("expected agent drained before the restart to be drained")
+		}
+	}
+
+	if lookups != 2 {
+		t.Errorf("expected the lookup result to be cached, got %d lookups", lookups)
+	}
+}
+
+func TestGuardFailedDrainKeepsAgentLive(t *testing.T) {
+	guard := drain.NewGuard(func(ctx context.Context, agentAddress *felt.Felt) (bool, error) {
+		return false, nil
+	})
+
+	guard.BeginDrain(agentAddress)(false)
+
+	isDrained, err := guard.IsDrained(context.Background(), agentAddress)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if isDrained {
+		t.Error("expected failed drain to leave the agent live")
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+
+	"github.com/NethermindEth/teeception/pkg/network"
 )
 
 const (
 	XClientModeKey            = "X_CLIENT_MODE"
 	AgentTwitterClientPortKey = "AGENT_TWITTER_CLIENT_PORT"
+	AgentJournalFileKey       = "AGENT_JOURNAL_FILE"
+	AgentReceiptsFileKey      = "AGENT_RECEIPTS_FILE"
+	NetworkKey                = "NETWORK"
+	NetworkProfileFileKey     = "NETWORK_PROFILE_FILE"
+	StarknetWsUrlKey          = "STARKNET_WS_URL"
 )
 
 func envGetAgentTwitterClientMode() string {
@@ -26,3 +33,36 @@ func envLookupAgentTwitterClientPort() (string, error) {
 	}
 	return port, nil
 }
+
+func envGetAgentJournalFile() string {
+	journalFile, ok := os.LookupEnv(AgentJournalFileKey)
+	if !ok {
+		slog.Warn(AgentJournalFileKey + " environment variable not set, prompt journal disabled")
+	}
+	return journalFile
+}
+
+func envGetAgentReceiptsFile() string {
+	receiptsFile, ok := os.LookupEnv(AgentReceiptsFileKey)
+	if !ok {
+		slog.Warn(AgentReceiptsFileKey + " environment variable not set, receipts kept in memory only")
+	}
+	return receiptsFile
+}
+
+func envGetNetwork() string {
+	name, ok := os.LookupEnv(NetworkKey)
+	if !ok || name == "" {
+		slog.Warn(NetworkKey + " environment variable not set, defaulting to " + network.NameSepolia)
+		return network.NameSepolia
+	}
+	return name
+}
+
+func envGetNetworkProfileFile() string {
+	return os.Getenv(NetworkProfileFileKey)
+}
+
+func envGetStarknetWsUrl() string {
+	return os.Getenv(StarknetWsUrlKey)
+}
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/agent/expiry"
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+var (
+	withdrawAgentSelector = starknetgoutils.GetSelectorFromNameFelt("withdraw_agent")
+	getIsDrainedSelector  = starknetgoutils.GetSelectorFromNameFelt("get_is_drained")
+)
+
+const expiryTickRate = 30 * time.Second
+
+func (a *Agent) trackAgentExpiry(agentInfo *indexer.AgentInfo) {
+	a.expiryScheduler.Track(&expiry.Agent{
+		Address: agentInfo.Address,
+		Creator: agentInfo.Creator,
+		Name:    agentInfo.Name,
+		EndTime: agentInfo.EndTime,
+	})
+}
+
+// seedExpiryScheduler tracks the agents registered before the journal
+// checkpoint the agent resumed from, whose AgentRegistered events are not
+// delivered again. It retries until it succeeds or the context is done.
+func (a *Agent) seedExpiryScheduler(ctx context.Context) error {
+	for {
+		addresses, err := expiry.ListRegisteredAgents(ctx, a.starknetClient, a.agentRegistryAddress, expiry.DefaultPageSize)
+		if err == nil {
+			for _, addr := range addresses {
+				agentInfo, err := a.agentIndexer.GetOrFetchAgentInfo(ctx, addr, a.startupBlockNumber)
+				if err != nil {
+					slog.Warn("failed to fetch agent info for expiry tracking", "agent_address", addr, "error", err)
+					continue
+				}
+				a.trackAgentExpiry(&agentInfo)
+			}
+
+			slog.Info("seeded expiry scheduler", "agents", len(addresses))
+			return nil
+		}
+
+		slog.Warn("failed to list registered agents, retrying later", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(expiryTickRate):
+		}
+	}
+}
+
+// runExpiryScheduler handles agents as they expire and blocks until the context
+// is done.
+func (a *Agent) runExpiryScheduler(ctx context.Context) error {
+	slog.Info("starting expiry scheduler")
+
+	ticker := time.NewTicker(expiryTickRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		for _, agent := range a.expiryScheduler.Due(time.Now()) {
+			if err := a.onAgentExpired(ctx, agent); err != nil {
+				slog.Warn("failed to handle expired agent, retrying later", "agent_address", agent.Address, "error", err)
+				continue
+			}
+			a.expiryScheduler.Done(agent.Address)
+		}
+	}
+}
+
+// onAgentExpired returns the prize pool of an agent that survived until its end
+// time to its creator through the registry and announces it.
+func (a *Agent) onAgentExpired(ctx context.Context, agent *expiry.Agent) error {
+	unlock := a.drainGuard.Lock(agent.Address)
+	defer unlock()
+
+	isDrained, err := a.drainGuard.IsDrained(ctx, agent.Address)
+	if err != nil {
+		return err
+	}
+	if isDrained {
+		slog.Info("expired agent was drained", "agent_address", agent.Address)
+		return nil
+	}
+
+	// The guard may have cached the agent as live since startup, so the chain
+	// has the final say before withdrawing.
+	isDrained, err = a.isAgentDrained(ctx, agent.Address)
+	if err != nil {
+		return err
+	}
+	if isDrained {
+		slog.Info("expired agent was drained", "agent_address", agent.Address)
+		return nil
+	}
+
+	slog.Info("agent survived until its end time", "agent_address", agent.Address, "end_time", agent.EndTime)
+
+	tweetAgentIdentifier := a.agentTweetIdentifier(ctx, agent.Address, agent.Name)
+
+	canWithdraw, err := a.registryCanWithdraw(ctx)
+	if err != nil {
+		return err
+	}
+	if !canWithdraw {
+		// Registries deployed before withdraw_agent leave the withdrawal to the
+		// creator. Agents that expired while the agent was down were announced
+		// by an earlier run.
+		if !a.expiryScheduler.ExpiredBeforeStart(agent) {
+			tweet := fmt.Sprintf(":%s: survived! Nobody broke it before it expired, and its creator can now withdraw its prize pool", tweetAgentIdentifier)
+			a.outbox.EnqueueAnnouncement(agent.Address, tweet)
+		}
+		return nil
+	}
+
+	finishDrain := a.drainGuard.BeginDrain(agent.Address)
+
+	resultCh, err := a.txQueue.Enqueue(ctx, []rpc.FunctionCall{
+		{
+			ContractAddress:    a.agentRegistryAddress,
+			EntryPointSelector: withdrawAgentSelector,
+			Calldata:           []*felt.Felt{agent.Address},
+		},
+	})
+	if err != nil {
+		finishDrain(false)
+		return fmt.Errorf("failed to enqueue withdraw_agent: %v", err)
+	}
+
+	txHash, err := snaccount.WaitForResult(ctx, resultCh)
+	finishDrain(err == nil)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw: %v", err)
+	}
+
+	slog.Info("returned prize pool of expired agent to its creator", "agent_address", agent.Address, "creator", agent.Creator, "tx_hash", txHash)
+
+	tweet := fmt.Sprintf(":%s: survived! Nobody broke it before it expired, and its prize pool was returned to its creator: %s", tweetAgentIdentifier, a.network.TxURL(txHash))
+
+	a.outbox.EnqueueAnnouncement(agent.Address, tweet)
+
+	return nil
+}
+
+// registryCanWithdraw reports whether the registry has the withdraw_agent
+// entrypoint, so that older registries are not sent transactions that revert.
+// It is only called from the expiry scheduler and caches the answer.
+func (a *Agent) registryCanWithdraw(ctx context.Context) (bool, error) {
+	if a.canWithdraw != nil {
+		return *a.canWithdraw, nil
+	}
+
+	canWithdraw, err := expiry.HasEntrypoint(ctx, a.starknetClient, a.agentRegistryAddress, withdrawAgentSelector)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up withdraw_agent: %w", err)
+	}
+	if !canWithdraw {
+		slog.Warn("registry has no withdraw_agent entrypoint, only announcing expired agents", "registry_address", a.agentRegistryAddress)
+	}
+
+	a.canWithdraw = &canWithdraw
+	return canWithdraw, nil
+}
+
+// lookupDrained seeds the drain guard with the on-chain state of agents it has
+// not seen since startup. Without a Starknet client, as when replaying prompts,
+// agents are assumed not drained.
+func (a *Agent) lookupDrained(ctx context.Context, agentAddress *felt.Felt) (bool, error) {
+	if a.starknetClient == nil {
+		return false, nil
+	}
+	return a.isAgentDrained(ctx, agentAddress)
+}
+
+func (a *Agent) isAgentDrained(ctx context.Context, agentAddress *felt.Felt) (bool, error) {
+	fnCall := rpc.FunctionCall{
+		ContractAddress:    agentAddress,
+		EntryPointSelector: getIsDrainedSelector,
+		Calldata:           []*felt.Felt{},
+	}
+
+	var resp []*felt.Felt
+	var err error
+
+	if err := a.starknetClient.Do(func(provider rpc.RpcProvider) error {
+		resp, err = provider.Call(ctx, fnCall, rpc.WithBlockTag("pending"))
+		return err
+	}); err != nil {
+		return false, fmt.Errorf("failed to call get_is_drained: %w", snaccount.FormatRpcError(err))
+	}
+
+	if len(resp) < 1 {
+		return false, fmt.Errorf("invalid response length: got %d, want at least 1", len(resp))
+	}
+
+	return !resp[0].IsZero(), nil
+}
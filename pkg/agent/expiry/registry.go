@@ -0,0 +1,99 @@
+package expiry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+var (
+	getAgentsCountSelector = starknetgoutils.GetSelectorFromNameFelt("get_agents_count")
+	getAgentsSelector      = starknetgoutils.GetSelectorFromNameFelt("get_agents")
+)
+
+// DefaultPageSize is the number of agents ListRegisteredAgents reads per call.
+const DefaultPageSize = 100
+
+// ListRegisteredAgents reads the addresses of all agents registered in the
+// registry, pageSize at a time.
+func ListRegisteredAgents(ctx context.Context, client starknet.ProviderWrapper, registryAddress *felt.Felt, pageSize uint64) ([]*felt.Felt, error) {
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+
+	call := func(selector *felt.Felt, calldata []*felt.Felt) ([]*felt.Felt, error) {
+		var resp []*felt.Felt
+		var err error
+		if err := client.Do(func(provider rpc.RpcProvider) error {
+			resp, err = provider.Call(ctx, rpc.FunctionCall{
+				ContractAddress:    registryAddress,
+				EntryPointSelector: selector,
+				Calldata:           calldata,
+			}, rpc.WithBlockTag("pending"))
+			return err
+		}); err != nil {
+			return nil, starknet.FormatRpcError(err)
+		}
+		return resp, nil
+	}
+
+	countResp, err := call(getAgentsCountSelector, []*felt.Felt{})
+	if err != nil {
+		return nil, fmt.Errorf("get_agents_count call failed: %w", err)
+	}
+	if len(countResp) < 1 {
+		return nil, fmt.Errorf("invalid get_agents_count response length: got %d, want at least 1", len(countResp))
+	}
+	count := countResp[0].Uint64()
+
+	addresses := make([]*felt.Felt, 0, count)
+	for start := uint64(0); start < count; start += pageSize {
+		end := min(start+pageSize, count)
+
+		resp, err := call(getAgentsSelector, []*felt.Felt{
+			new(felt.Felt).SetUint64(start),
+			new(felt.Felt).SetUint64(end),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get_agents call failed: %w", err)
+		}
+		if len(resp) < 1 || resp[0].Uint64() != uint64(len(resp)-1) {
+			return nil, fmt.Errorf("invalid get_agents response for range [%d, %d)", start, end)
+		}
+
+		addresses = append(addresses, resp[1:]...)
+	}
+
+	return addresses, nil
+}
+
+// HasEntrypoint reports whether the class of the contract at address has an
+// external entrypoint with selector.
+func HasEntrypoint(ctx context.Context, client starknet.ProviderWrapper, address, selector *felt.Felt) (bool, error) {
+	var class rpc.ClassOutput
+	var err error
+	if err := client.Do(func(provider rpc.RpcProvider) error {
+		class, err = provider.ClassAt(ctx, rpc.WithBlockTag("pending"), address)
+		return err
+	}); err != nil {
+		return false, fmt.Errorf("failed to get class: %w", starknet.FormatRpcError(err))
+	}
+
+	sierraClass, ok := class.(*rpc.ContractClass)
+	if !ok {
+		return false, fmt.Errorf("unexpected class type: %T", class)
+	}
+
+	for _, entrypoint := range sierraClass.EntryPointsByType.External {
+		if entrypoint.Selector != nil && entrypoint.Selector.Equal(selector) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,81 @@
+package expiry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/agent/expiry"
+)
+
+type stubRegistry struct {
+	rpc.RpcProvider
+	agents []*felt.Felt
+	pages  int
+}
+
+func (r *stubRegistry) Do(f func(provider rpc.RpcProvider) error) error {
+	return f(r)
+}
+
+func (r *stubRegistry) Call(ctx context.Context, call rpc.FunctionCall, block rpc.BlockID) ([]*felt.Felt, error) {
+	switch call.EntryPointSelector.String() {
+	case starknetgoutils.GetSelectorFromNameFelt("get_agents_count").String():
+		return []*felt.Felt{new(felt.Felt).SetUint64(uint64(len(r.agents)))}, nil
+	case starknetgoutils.GetSelectorFromNameFelt("get_agents").String():
+		r.pages++
+		start, end := call.Calldata[0].Uint64(), call.Calldata[1].Uint64()
+		resp := []*felt.Felt{new(felt.Felt).SetUint64(end - start)}
+		return append(resp, r.agents[start:end]...), nil
+	}
+	return nil, nil
+}
+
+func (r *stubRegistry) ClassAt(ctx context.Context, block rpc.BlockID, address *felt.Felt) (rpc.ClassOutput, error) {
+	class := &rpc.ContractClass{}
+	for _, name := range []string{"get_agents_count", "get_agents"} {
+		class.EntryPointsByType.External = append(class.EntryPointsByType.External, rpc.SierraEntryPoint{
+			Selector: starknetgoutils.GetSelectorFromNameFelt(name),
+		})
+	}
+	return class, nil
+}
+
+func TestHasEntrypoint(t *testing.T) {
+	for name, expected := range map[string]bool{"get_agents": true, "withdraw_agent": false} {
+		ok, err := expiry.HasEntrypoint(context.Background(), &stubRegistry{}, new(felt.Felt).SetUint64(1), starknetgoutils.GetSelectorFromNameFelt(name))
+		if err != nil {
+			t.Fatalf("failed to look up %s: %v", name, err)
+		}
+		if ok != expected {
+			t.Errorf("expected %s to exist: %v, got %v", name, expected, ok)
+		}
+	}
+}
+
+func TestListRegisteredAgents(t *testing.T) {
+	registry := &stubRegistry{}
+	for i := range 5 {
+		registry.agents = append(registry.agents, new(felt.Felt).SetUint64(uint64(100+i)))
+	}
+
+	addresses, err := expiry.ListRegisteredAgents(context.Background(), registry, new(felt.Felt).SetUint64(1), 2)
+	if err != nil {
+		t.Fatalf("failed to list agents: %v", err)
+	}
+
+	if registry.pages != 3 {
+		t.Errorf("expected 3 pages, got %d", registry.pages)
+	}
+	if len(addresses) != len(registry.agents) {
+		t.Fatalf("expected %d agents, got %d", len(registry.agents), len(addresses))
+	}
+	for i, addr := range addresses {
+		if !addr.Equal(registry.agents[i]) {
+			t.Errorf("agent %d: expected %s, got %s", i, registry.agents[i], addr)
+		}
+	}
+}
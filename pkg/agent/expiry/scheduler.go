@@ -0,0 +1,109 @@
+package expiry
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// DefaultGracePeriod is waited past an agent's end time so that the pending
+// block timestamp has also passed it and the agent is finalized on-chain.
+const DefaultGracePeriod = 1 * time.Minute
+
+// Agent is an agent whose expiry is tracked.
+type Agent struct {
+	Address *felt.Felt
+	Creator *felt.Felt
+	Name    string
+	EndTime uint64
+}
+
+// SchedulerConfig is the configuration for a Scheduler.
+type SchedulerConfig struct {
+	// GracePeriod defaults to DefaultGracePeriod.
+	GracePeriod time.Duration
+	// StartedAt defaults to the current time.
+	StartedAt time.Time
+}
+
+// Scheduler tracks the end times of agents. Agents that had already expired
+// when it started, such as while the agent was down, are due immediately; the
+// caller skips the ones that were already withdrawn or drained.
+type Scheduler struct {
+	mu          sync.Mutex
+	agents      map[[32]byte]*Agent
+	gracePeriod time.Duration
+	startedAt   uint64
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(config *SchedulerConfig) *Scheduler {
+	gracePeriod := config.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+
+	startedAt := config.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	return &Scheduler{
+		agents:      make(map[[32]byte]*Agent),
+		gracePeriod: gracePeriod,
+		startedAt:   uint64(startedAt.Unix()),
+	}
+}
+
+// Track schedules the expiry of an agent. Agents that are already tracked are
+// ignored.
+func (s *Scheduler) Track(agent *Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.agents[agent.Address.Bytes()]; ok {
+		return
+	}
+
+	slog.Debug("tracking agent expiry", "agent_address", agent.Address, "end_time", agent.EndTime)
+	s.agents[agent.Address.Bytes()] = agent
+}
+
+// Due returns the tracked agents whose end time is at least the grace period
+// before now, oldest first.
+func (s *Scheduler) Due(now time.Time) []*Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := uint64(now.Add(-s.gracePeriod).Unix())
+
+	due := make([]*Agent, 0)
+	for _, agent := range s.agents {
+		if agent.EndTime <= deadline {
+			due = append(due, agent)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].EndTime < due[j].EndTime
+	})
+
+	return due
+}
+
+// ExpiredBeforeStart reports whether an agent had already expired when the
+// scheduler started.
+func (s *Scheduler) ExpiredBeforeStart(agent *Agent) bool {
+	return agent.EndTime <= s.startedAt
+}
+
+// Done stops tracking an agent.
+func (s *Scheduler) Done(agentAddress *felt.Felt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.agents, agentAddress.Bytes())
+}
@@ -0,0 +1,50 @@
+package expiry_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/expiry"
+)
+
+func TestSchedulerDue(t *testing.T) {
+	startedAt := time.Unix(1000, 0)
+	s := expiry.NewScheduler(&expiry.SchedulerConfig{
+		GracePeriod: time.Minute,
+		StartedAt:   startedAt,
+	})
+
+	expired := &expiry.Agent{Address: new(felt.Felt).SetUint64(1), EndTime: 900}
+	later := &expiry.Agent{Address: new(felt.Felt).SetUint64(2), EndTime: 1200}
+	sooner := &expiry.Agent{Address: new(felt.Felt).SetUint64(3), EndTime: 1100}
+
+	s.Track(expired)
+	s.Track(later)
+	s.Track(sooner)
+	s.Track(&expiry.Agent{Address: new(felt.Felt).SetUint64(3), EndTime: 5000})
+
+	// The agent that expired before the start is due right away
+	due := s.Due(time.Unix(1100, 0))
+	if len(due) != 1 || due[0] != expired {
+		t.Fatalf("expected only the agent expired before the start due, got %+v", due)
+	}
+	if !s.ExpiredBeforeStart(expired) || s.ExpiredBeforeStart(sooner) {
+		t.Error("expected only the first agent to have expired before the start")
+	}
+
+	s.Done(expired.Address)
+
+	due = s.Due(time.Unix(1260, 0))
+	if len(due) != 2 || due[0] != sooner || due[1] != later {
+		t.Fatalf("unexpected due agents: %+v", due)
+	}
+
+	s.Done(sooner.Address)
+
+	due = s.Due(time.Unix(1260, 0))
+	if len(due) != 1 || due[0] != later {
+		t.Fatalf("unexpected due agents after done: %+v", due)
+	}
+}
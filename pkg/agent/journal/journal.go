@@ -0,0 +1,378 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/sealedlog"
+)
+
+// Stage is the last completed step in the lifecycle of a prompt.
+type Stage int
+
+const (
+	StagePaid Stage = iota + 1
+	StageAnswered
+	StageConsumeSubmitted
+	StageConsumeConfirmed
+	StageReplied
+	// StageSkipped marks prompts that were dropped without a reply, e.g.
+	// because the agent expired or the prompt was consumed elsewhere.
+	StageSkipped
+)
+
+// IsFinal reports whether no further work is needed for the prompt.
+func (s Stage) IsFinal() bool {
+	return s >= StageReplied
+}
+
+func (s Stage) String() string {
+	switch s {
+	case StagePaid:
+		return "paid"
+	case StageAnswered:
+		return "answered"
+	case StageConsumeSubmitted:
+		return "consume_submitted"
+	case StageConsumeConfirmed:
+		return "consume_confirmed"
+	case StageReplied:
+		return "replied"
+	case StageSkipped:
+		return "skipped"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Entry is the journaled state of a single prompt.
+type Entry struct {
+	AgentAddress *felt.Felt `json:"agent_address"`
+	PromptID     uint64     `json:"prompt_id"`
+	TweetID      uint64     `json:"tweet_id"`
+	User         *felt.Felt `json:"user"`
+	Prompt       string     `json:"prompt"`
+	Block        uint64     `json:"block"`
+
+	Stage      Stage      `json:"stage"`
+	Response   string     `json:"response,omitempty"`
+	DrainTo    *felt.Felt `json:"drain_to,omitempty"`
+	ErrorReply string     `json:"error_reply,omitempty"`
+	// SkipConsume is set when the prompt can no longer be consumed, e.g.
+	// because the agent was drained by another prompt first.
+	SkipConsume bool       `json:"skip_consume,omitempty"`
+	TxHash      *felt.Felt `json:"tx_hash,omitempty"`
+	// DecidedDrainTo is the drain address the agent answered with. It is kept
+	// when DrainTo is cleared because the drain can no longer be executed.
+	DecidedDrainTo *felt.Felt `json:"decided_drain_to,omitempty"`
+	// ReceiptIssued is set once the receipt of the prompt is stored.
+	ReceiptIssued bool `json:"receipt_issued,omitempty"`
+	// Outgoing are the tweets and replies of the prompt. The prompt is only
+	// replied once all of them are delivered.
+	Outgoing  []Outgoing `json:"outgoing,omitempty"`
+	UpdatedAt int64      `json:"updated_at"`
+}
+
+// Outgoing is a tweet or reply queued on behalf of a prompt.
+type Outgoing struct {
+	Kind      string `json:"kind"`
+	TweetID   uint64 `json:"tweet_id,omitempty"`
+	Text      string `json:"text"`
+	Delivered bool   `json:"delivered,omitempty"`
+}
+
+// IsDelivered reports whether every outgoing tweet of the prompt was delivered.
+func (e *Entry) IsDelivered() bool {
+	for _, outgoing := range e.Outgoing {
+		if !outgoing.Delivered {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Entry) clone() *Entry {
+	e.Outgoing = slices.Clone(e.Outgoing)
+	return &e
+}
+
+// Key identifies a prompt across agents.
+type Key struct {
+	AgentAddress [32]byte
+	PromptID     uint64
+}
+
+// NewKey creates the key of a prompt.
+func NewKey(agentAddress *felt.Felt, promptID uint64) Key {
+	return Key{
+		AgentAddress: agentAddress.Bytes(),
+		PromptID:     promptID,
+	}
+}
+
+const (
+	recordTypeEntry      = "entry"
+	recordTypeCheckpoint = "checkpoint"
+)
+
+type record struct {
+	Type  string `json:"type"`
+	Entry *Entry `json:"entry,omitempty"`
+	Block uint64 `json:"block,omitempty"`
+}
+
+const (
+	// DefaultCompactAfter is the default number of records appended between
+	// compactions.
+	DefaultCompactAfter = 1024
+	// DefaultFinishedRetention is the default number of blocks finished
+	// prompts are remembered for past the checkpoint.
+	DefaultFinishedRetention = 64
+)
+
+// Config is the configuration for a Journal.
+type Config struct {
+	Path   string
+	Sealer sealedlog.Sealer
+	// CompactAfter is the number of records appended before the journal is
+	// compacted again. Defaults to DefaultCompactAfter.
+	CompactAfter int
+	// FinishedRetention is the number of blocks a finished prompt is kept for
+	// once its block is checkpointed, so that prompts delivered again by a
+	// chain reorganization are still recognized. Defaults to
+	// DefaultFinishedRetention.
+	FinishedRetention uint64
+}
+
+// Journal is an append-only, sealed on-disk log of prompt lifecycle stages.
+// Finished prompts are dropped from memory once the checkpoint is past their
+// retention, and from disk when the journal is compacted, which happens on
+// open and every CompactAfter appended records.
+type Journal struct {
+	mu                sync.Mutex
+	path              string
+	log               *sealedlog.Log
+	entries           map[Key]*Entry
+	lastBlock         uint64
+	compactAfter      int
+	finishedRetention uint64
+}
+
+// Open loads the journal at config.Path, compacts it and prepares it for
+// appending.
+func Open(config *Config) (*Journal, error) {
+	compactAfter := config.CompactAfter
+	if compactAfter <= 0 {
+		compactAfter = DefaultCompactAfter
+	}
+
+	finishedRetention := config.FinishedRetention
+	if finishedRetention == 0 {
+		finishedRetention = DefaultFinishedRetention
+	}
+
+	j := &Journal{
+		path:              config.Path,
+		log:               sealedlog.New(config.Path, config.Sealer),
+		entries:           make(map[Key]*Entry),
+		compactAfter:      compactAfter,
+		finishedRetention: finishedRetention,
+	}
+
+	if err := j.load(); err != nil {
+		return nil, fmt.Errorf("failed to load journal: %w", err)
+	}
+
+	if err := j.compact(); err != nil {
+		return nil, fmt.Errorf("failed to compact journal: %w", err)
+	}
+
+	slog.Info("opened prompt journal", "path", j.path, "prompts", len(j.entries), "last_block", j.lastBlock)
+
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	return j.log.Load(func(plaintext []byte) error {
+		var rec record
+		if err := json.Unmarshal(plaintext, &rec); err != nil {
+			return fmt.Errorf("failed to unmarshal journal record: %w", err)
+		}
+
+		j.apply(&rec)
+		return nil
+	})
+}
+
+func (j *Journal) apply(rec *record) {
+	switch rec.Type {
+	case recordTypeEntry:
+		if rec.Entry == nil || rec.Entry.AgentAddress == nil {
+			return
+		}
+		j.entries[NewKey(rec.Entry.AgentAddress, rec.Entry.PromptID)] = rec.Entry
+	case recordTypeCheckpoint:
+		if rec.Block > j.lastBlock {
+			j.lastBlock = rec.Block
+		}
+	}
+}
+
+// prune drops the finished prompts whose retention the checkpoint is past.
+func (j *Journal) prune() {
+	for key, entry := range j.entries {
+		if entry.Stage.IsFinal() && entry.Block+j.finishedRetention <= j.lastBlock {
+			delete(j.entries, key)
+		}
+	}
+}
+
+// compact rewrites the journal with only the retained prompts and the last
+// checkpoint, then reopens it for appending.
+func (j *Journal) compact() error {
+	j.prune()
+
+	return j.log.Compact(func(write func(record any) error) error {
+		if j.lastBlock > 0 {
+			if err := write(&record{Type: recordTypeCheckpoint, Block: j.lastBlock}); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range j.entries {
+			if err := write(&record{Type: recordTypeEntry, Entry: entry}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// maybeCompact compacts the journal if enough records were appended since the
+// last compaction. The record that triggered it is already durable, so
+// failures are only logged and retried on the next append.
+func (j *Journal) maybeCompact() {
+	if j.log.Appended() < j.compactAfter {
+		return
+	}
+
+	if err := j.compact(); err != nil {
+		slog.Error("failed to compact prompt journal", "path", j.path, "error", err)
+	}
+}
+
+// Record durably stores the given state of a prompt.
+func (j *Journal) Record(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry.UpdatedAt = time.Now().Unix()
+
+	stored := entry.clone()
+	if err := j.log.Append(&record{Type: recordTypeEntry, Entry: stored}); err != nil {
+		return err
+	}
+
+	j.entries[NewKey(entry.AgentAddress, entry.PromptID)] = stored
+	j.maybeCompact()
+
+	return nil
+}
+
+// Get returns the last recorded state of a prompt.
+func (j *Journal) Get(agentAddress *felt.Felt, promptID uint64) (Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.entries[NewKey(agentAddress, promptID)]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry.clone(), true
+}
+
+// Pending returns all prompts that still need work.
+func (j *Journal) Pending() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	pending := make([]Entry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		if !entry.Stage.IsFinal() {
+			pending = append(pending, *entry.clone())
+		}
+	}
+	return pending
+}
+
+// Checkpoint records that every prompt paid up to block has been journaled.
+func (j *Journal) Checkpoint(block uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if block <= j.lastBlock {
+		return nil
+	}
+
+	if err := j.log.Append(&record{Type: recordTypeCheckpoint, Block: block}); err != nil {
+		return err
+	}
+
+	j.lastBlock = block
+	j.prune()
+	j.maybeCompact()
+
+	return nil
+}
+
+// Rewind moves the checkpoint back to block after a chain reorganization and
+// drops the prompts paid after it that were not answered yet, returning them.
+// Prompts already answered are kept, as their outcome cannot be taken back.
+func (j *Journal) Rewind(block uint64) ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	dropped := make([]Entry, 0)
+	for key, entry := range j.entries {
+		if entry.Block > block && entry.Stage == StagePaid {
+			dropped = append(dropped, *entry.clone())
+			delete(j.entries, key)
+		}
+	}
+
+	if block >= j.lastBlock && len(dropped) == 0 {
+		return dropped, nil
+	}
+	j.lastBlock = min(j.lastBlock, block)
+
+	// Checkpoints only move forward when appended, so the journal is
+	// rewritten instead.
+	if err := j.compact(); err != nil {
+		return dropped, fmt.Errorf("failed to compact journal: %w", err)
+	}
+
+	return dropped, nil
+}
+
+// LastBlock returns the last checkpointed block.
+func (j *Journal) LastBlock() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.lastBlock
+}
+
+// Close closes the underlying file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.log.Close()
+}
@@ -0,0 +1,186 @@
+package journal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/journal"
+)
+
+type plainSealer struct{}
+
+func (plainSealer) Seal(plaintext []byte) ([]byte, error)  { return plaintext, nil }
+func (plainSealer) Open(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+
+func TestJournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.bin")
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	config := &journal.Config{Path: path, Sealer: plainSealer{}, FinishedRetention: 10}
+
+	j, err := journal.Open(config)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+
+	answered := journal.Entry{AgentAddress: agentAddress, PromptID: 1, Stage: journal.StagePaid}
+	replied := journal.Entry{AgentAddress: agentAddress, PromptID: 2, Stage: journal.StagePaid}
+	for _, entry := range []journal.Entry{answered, replied} {
+		if err := j.Record(entry); err != nil {
+			t.Fatalf("failed to record entry: %v", err)
+		}
+	}
+
+	answered.Stage = journal.StageAnswered
+	answered.Response = "nope"
+	replied.Stage = journal.StageReplied
+	for _, entry := range []journal.Entry{answered, replied} {
+		if err := j.Record(entry); err != nil {
+			t.Fatalf("failed to record entry: %v", err)
+		}
+	}
+
+	if err := j.Checkpoint(42); err != nil {
+		t.Fatalf("failed to checkpoint: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	// Simulate a crash in the middle of writing a record.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("failed to open journal file: %v", err)
+	}
+	file.Write([]byte{0, 0, 1})
+	file.Close()
+
+	j, err = journal.Open(config)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	if j.LastBlock() != 42 {
+		t.Errorf("expected last block 42, got %d", j.LastBlock())
+	}
+
+	pending := j.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending entry, got %d", len(pending))
+	}
+	if pending[0].PromptID != 1 || pending[0].Stage != journal.StageAnswered || pending[0].Response != "nope" {
+		t.Errorf("unexpected pending entry: %+v", pending[0])
+	}
+
+	if _, ok := j.Get(agentAddress, 2); ok {
+		t.Error("expected replied entry to be compacted away")
+	}
+}
+
+func TestJournalCompactsAndPrunes(t *testing.T) {
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	// run records 100 prompts that finish within the same block and returns
+	// the journal with the size of its file.
+	run := func(compactAfter int) (*journal.Journal, int64) {
+		path := filepath.Join(t.TempDir(), "journal.bin")
+
+		j, err := journal.Open(&journal.Config{Path: path, Sealer: plainSealer{}, CompactAfter: compactAfter, FinishedRetention: 10})
+		if err != nil {
+			t.Fatalf("failed to open journal: %v", err)
+		}
+		t.Cleanup(func() { j.Close() })
+
+		for promptID := uint64(1); promptID <= 100; promptID++ {
+			entry := journal.Entry{AgentAddress: agentAddress, PromptID: promptID, Block: promptID, Stage: journal.StagePaid}
+			if err := j.Record(entry); err != nil {
+				t.Fatalf("failed to record entry: %v", err)
+			}
+			entry.Stage = journal.StageReplied
+			if err := j.Record(entry); err != nil {
+				t.Fatalf("failed to record entry: %v", err)
+			}
+			if err := j.Checkpoint(promptID); err != nil {
+				t.Fatalf("failed to checkpoint: %v", err)
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat journal: %v", err)
+		}
+		return j, info.Size()
+	}
+
+	j, compactedSize := run(8)
+	_, size := run(1 << 20)
+
+	if compactedSize*4 >= size {
+		t.Errorf("expected journal to be compacted, got %d bytes against %d uncompacted", compactedSize, size)
+	}
+
+	// Prompts within the retention are still known to dedupe redelivered events.
+	if _, ok := j.Get(agentAddress, 91); !ok {
+		t.Error("expected prompt within retention to be kept")
+	}
+	if _, ok := j.Get(agentAddress, 90); ok {
+		t.Error("expected prompt past retention to be pruned")
+	}
+}
+
+func TestJournalRewind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.bin")
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	config := &journal.Config{Path: path, Sealer: plainSealer{}}
+
+	j, err := journal.Open(config)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+
+	entries := []journal.Entry{
+		{AgentAddress: agentAddress, PromptID: 1, Block: 10, Stage: journal.StagePaid},
+		{AgentAddress: agentAddress, PromptID: 2, Block: 12, Stage: journal.StagePaid},
+		{AgentAddress: agentAddress, PromptID: 3, Block: 12, Stage: journal.StageAnswered},
+	}
+	for _, entry := range entries {
+		if err := j.Record(entry); err != nil {
+			t.Fatalf("failed to record entry: %v", err)
+		}
+	}
+	if err := j.Checkpoint(12); err != nil {
+		t.Fatalf("failed to checkpoint: %v", err)
+	}
+
+	dropped, err := j.Rewind(11)
+	if err != nil {
+		t.Fatalf("failed to rewind: %v", err)
+	}
+	if len(dropped) != 1 || dropped[0].PromptID != 2 {
+		t.Fatalf("expected only the unanswered prompt of the reverted block dropped, got %+v", dropped)
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	j, err = journal.Open(config)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	if j.LastBlock() != 11 {
+		t.Errorf("expected last block 11, got %d", j.LastBlock())
+	}
+	for promptID, expected := range map[uint64]bool{1: true, 2: false, 3: true} {
+		if _, ok := j.Get(agentAddress, promptID); ok != expected {
+			t.Errorf("expected prompt %d journaled: %v, got %v", promptID, expected, ok)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/metrics"
+	"github.com/NethermindEth/teeception/pkg/twitter"
+)
+
+type ItemKind string
+
+const (
+	ItemKindTweet ItemKind = "tweet"
+	ItemKindReply ItemKind = "reply"
+	// ItemKindAnnouncement is a tweet about an agent that is not on behalf of
+	// a prompt, so its PromptID and Index are unset.
+	ItemKindAnnouncement ItemKind = "announcement"
+)
+
+type ItemStatus string
+
+const (
+	ItemStatusPending ItemStatus = "pending"
+	ItemStatusFailed  ItemStatus = "failed"
+)
+
+// Item is an outgoing tweet or reply waiting to be delivered. Index is the
+// position of the item among the outgoing tweets of its prompt.
+type Item struct {
+	ID            uint64     `json:"id"`
+	Kind          ItemKind   `json:"kind"`
+	AgentAddress  *felt.Felt `json:"agent_address"`
+	PromptID      uint64     `json:"prompt_id"`
+	Index         int        `json:"index"`
+	TweetID       uint64     `json:"tweet_id,omitempty"`
+	Text          string     `json:"text"`
+	Status        ItemStatus `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+}
+
+// OutboxConfig is the configuration for an Outbox.
+type OutboxConfig struct {
+	TwitterClient  twitter.TwitterClient
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	TickRate       time.Duration
+}
+
+// Outbox delivers tweets and replies, retrying failed deliveries with
+// exponential backoff. Items that exhaust their attempts are kept as failed
+// until they are replayed.
+type Outbox struct {
+	mu     sync.Mutex
+	items  map[uint64]*Item
+	nextID uint64
+
+	twitterClient  twitter.TwitterClient
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	tickRate       time.Duration
+	onDelivered    func(item Item)
+
+	wakeCh chan struct{}
+}
+
+// NewOutbox creates a new Outbox.
+func NewOutbox(cfg *OutboxConfig) *Outbox {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+	if cfg.TickRate <= 0 {
+		cfg.TickRate = 1 * time.Second
+	}
+
+	return &Outbox{
+		items:          make(map[uint64]*Item),
+		twitterClient:  cfg.TwitterClient,
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		tickRate:       cfg.TickRate,
+		wakeCh:         make(chan struct{}, 1),
+	}
+}
+
+// EnqueueTweet queues a new tweet on behalf of a prompt.
+func (o *Outbox) EnqueueTweet(agentAddress *felt.Felt, promptID uint64, text string) uint64 {
+	return o.enqueue(&Item{
+		Kind:         ItemKindTweet,
+		AgentAddress: agentAddress,
+		PromptID:     promptID,
+		Text:         text,
+	})
+}
+
+// EnqueueReply queues a reply to tweetID on behalf of a prompt.
+func (o *Outbox) EnqueueReply(agentAddress *felt.Felt, promptID uint64, tweetID uint64, text string) uint64 {
+	return o.enqueue(&Item{
+		Kind:         ItemKindReply,
+		AgentAddress: agentAddress,
+		PromptID:     promptID,
+		TweetID:      tweetID,
+		Text:         text,
+	})
+}
+
+// EnqueueAnnouncement queues a tweet about an agent that is not on behalf of a
+// prompt.
+func (o *Outbox) EnqueueAnnouncement(agentAddress *felt.Felt, text string) uint64 {
+	return o.enqueue(&Item{
+		Kind:         ItemKindAnnouncement,
+		AgentAddress: agentAddress,
+		Text:         text,
+	})
+}
+
+// Enqueue queues a tweet or reply. Its ID, status and timestamps are set by
+// the outbox.
+func (o *Outbox) Enqueue(item Item) uint64 {
+	return o.enqueue(&item)
+}
+
+// SetOnDelivered sets the function called with every item once it is
+// delivered.
+func (o *Outbox) SetOnDelivered(onDelivered func(item Item)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.onDelivered = onDelivered
+}
+
+func (o *Outbox) enqueue(item *Item) uint64 {
+	o.mu.Lock()
+	o.nextID++
+	item.ID = o.nextID
+	item.Status = ItemStatusPending
+	item.CreatedAt = time.Now()
+	item.NextAttemptAt = item.CreatedAt
+	o.items[item.ID] = item
+	o.mu.Unlock()
+
+	slog.Info("queued outgoing tweet", "id", item.ID, "kind", item.Kind, "agent_address", item.AgentAddress, "prompt_id", item.PromptID, "tweet_id", item.TweetID)
+
+	o.wake()
+
+	return item.ID
+}
+
+func (o *Outbox) wake() {
+	select {
+	case o.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts the delivery loop and blocks until the context is done.
+func (o *Outbox) Run(ctx context.Context) error {
+	slog.Info("starting outbox")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-o.wakeCh:
+		case <-time.After(o.tickRate):
+		}
+
+		o.deliverDue(ctx)
+	}
+}
+
+// deliverDue delivers due items one at a time, in the order they were queued,
+// so that a drain announcement precedes the replies of the same prompt.
+func (o *Outbox) deliverDue(ctx context.Context) {
+	for _, item := range o.dueItems() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := o.deliver(item)
+
+		o.mu.Lock()
+		current, ok := o.items[item.ID]
+		if !ok || current.Status != ItemStatusPending {
+			o.mu.Unlock()
+			continue
+		}
+
+		current.Attempts++
+		if err == nil {
+			delete(o.items, item.ID)
+			onDelivered := o.onDelivered
+			o.mu.Unlock()
+
+			slog.Info("delivered outgoing tweet", "id", item.ID, "kind", item.Kind, "prompt_id", item.PromptID, "attempts", current.Attempts)
+
+			if onDelivered != nil {
+				onDelivered(item)
+			}
+			continue
+		}
+
+		metrics.TwitterPostFailures.WithLabelValues(string(item.Kind)).Inc()
+
+		current.LastError = err.Error()
+		if current.Attempts >= o.maxAttempts {
+			current.Status = ItemStatusFailed
+			slog.Error("giving up on outgoing tweet", "id", item.ID, "kind", item.Kind, "prompt_id", item.PromptID, "attempts", current.Attempts, "error", err)
+		} else {
+			backoff := o.backoff(current.Attempts)
+			current.NextAttemptAt = time.Now().Add(backoff)
+			slog.Warn("failed to deliver outgoing tweet, retrying", "id", item.ID, "kind", item.Kind, "prompt_id", item.PromptID, "attempts", current.Attempts, "backoff", backoff, "error", err)
+		}
+		o.mu.Unlock()
+	}
+}
+
+func (o *Outbox) dueItems() []Item {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+
+	due := make([]Item, 0)
+	for _, item := range o.items {
+		if item.Status == ItemStatusPending && !item.NextAttemptAt.After(now) {
+			due = append(due, *item)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].ID < due[j].ID
+	})
+
+	return due
+}
+
+func (o *Outbox) deliver(item Item) error {
+	switch item.Kind {
+	case ItemKindTweet, ItemKindAnnouncement:
+		return o.twitterClient.SendTweet(item.Text)
+	case ItemKindReply:
+		return o.twitterClient.ReplyToTweet(item.TweetID, item.Text)
+	default:
+		return fmt.Errorf("unknown item kind: %s", item.Kind)
+	}
+}
+
+func (o *Outbox) backoff(attempts int) time.Duration {
+	backoff := o.initialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= o.maxBackoff {
+			return o.maxBackoff
+		}
+	}
+	return backoff
+}
+
+// Pending returns the items that are still being delivered.
+func (o *Outbox) Pending() []Item {
+	return o.list(ItemStatusPending)
+}
+
+// Failed returns the items that exhausted their delivery attempts.
+func (o *Outbox) Failed() []Item {
+	return o.list(ItemStatusFailed)
+}
+
+func (o *Outbox) list(status ItemStatus) []Item {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	items := make([]Item, 0)
+	for _, item := range o.items {
+		if item.Status == status {
+			items = append(items, *item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+
+	return items
+}
+
+// Replay schedules an item for immediate delivery, resetting its attempts.
+func (o *Outbox) Replay(id uint64) error {
+	o.mu.Lock()
+	item, ok := o.items[id]
+	if !ok {
+		o.mu.Unlock()
+		return fmt.Errorf("item %d not found", id)
+	}
+
+	item.Status = ItemStatusPending
+	item.Attempts = 0
+	item.NextAttemptAt = time.Now()
+	o.mu.Unlock()
+
+	slog.Info("replaying outgoing tweet", "id", id, "kind", item.Kind, "prompt_id", item.PromptID)
+
+	o.wake()
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/outbox"
+	"github.com/NethermindEth/teeception/pkg/twitter"
+)
+
+type flakyTwitterClient struct {
+	mu       sync.Mutex
+	failures int
+	replies  []string
+}
+
+func (c *flakyTwitterClient) Initialize(config *twitter.TwitterClientConfig) error { return nil }
+func (c *flakyTwitterClient) GetTweetText(tweetID uint64) (string, error)          { return "", nil }
+func (c *flakyTwitterClient) SendTweet(tweet string) error                         { return nil }
+
+func (c *flakyTwitterClient) ReplyToTweet(tweetID uint64, reply string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("rate limited")
+	}
+
+	c.replies = append(c.replies, reply)
+	return nil
+}
+
+func TestOutboxRetriesAndReplays(t *testing.T) {
+	client := &flakyTwitterClient{failures: 3}
+	ob := outbox.NewOutbox(&outbox.OutboxConfig{
+		TwitterClient:  client,
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		TickRate:       time.Millisecond,
+	})
+
+	var deliveredMu sync.Mutex
+	var delivered []outbox.Item
+	ob.SetOnDelivered(func(item outbox.Item) {
+		deliveredMu.Lock()
+		defer deliveredMu.Unlock()
+		delivered = append(delivered, item)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ob.Run(ctx)
+
+	id := ob.Enqueue(outbox.Item{Kind: outbox.ItemKindReply, AgentAddress: new(felt.Felt).SetUint64(1), PromptID: 7, Index: 2, TweetID: 42, Text: "hello"})
+
+	waitFor(t, func() bool { return len(ob.Failed()) == 1 })
+	if failed := ob.Failed()[0]; failed.ID != id || failed.Attempts != 2 || failed.LastError == "" {
+		t.Fatalf("unexpected failed item: %+v", failed)
+	}
+
+	if err := ob.Replay(id); err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	waitFor(t, func() bool { return len(ob.Pending()) == 0 && len(ob.Failed()) == 0 })
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.replies) != 1 || client.replies[0] != "hello" {
+		t.Errorf("unexpected replies: %v", client.replies)
+	}
+
+	waitFor(t, func() bool {
+		deliveredMu.Lock()
+		defer deliveredMu.Unlock()
+		return len(delivered) == 1
+	})
+
+	deliveredMu.Lock()
+	defer deliveredMu.Unlock()
+	if len(delivered) != 1 || delivered[0].ID != id || delivered[0].PromptID != 7 || delivered[0].Index != 2 {
+		t.Errorf("unexpected delivered items: %+v", delivered)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,420 @@
+package policy
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Pool runs tasks, e.g. a pond.Pool.
+type Pool interface {
+	Go(task func()) error
+}
+
+// Task is a prompt waiting to be processed.
+type Task struct {
+	AgentAddress *felt.Felt
+	User         *felt.Felt
+	PromptID     uint64
+	Prompt       string
+	Run          func()
+}
+
+// SchedulerConfig is the configuration for a Scheduler.
+type SchedulerConfig struct {
+	Pool Pool
+	// MaxConcurrent caps the tasks handed to the pool at once, so that
+	// ordering is decided here rather than by the pool's FIFO queue.
+	MaxConcurrent int
+	MaxPerUser    int
+	MaxPerAgent   int
+	// Prompts from the same user to the same agent within DuplicateWindow
+	// whose similarity is at least DuplicateThreshold are deferred by
+	// DuplicateDelay for every earlier near-duplicate.
+	DuplicateWindow    time.Duration
+	DuplicateThreshold float64
+	DuplicateDelay     time.Duration
+	MaxDuplicateDelay  time.Duration
+	TickRate           time.Duration
+}
+
+type scheduledTask struct {
+	*Task
+	notBefore time.Time
+}
+
+type recentPrompt struct {
+	words map[string]struct{}
+	at    time.Time
+}
+
+type userAgentKey struct {
+	agentAddress [32]byte
+	user         [32]byte
+}
+
+// Scheduler hands prompts to the pool fairly: agents are served round-robin,
+// and per-user and per-agent concurrency is capped. Deferred prompts stay
+// queued until they are eligible and are never dropped.
+type Scheduler struct {
+	mu sync.Mutex
+
+	queues     map[[32]byte][]*scheduledTask
+	agentOrder [][32]byte
+	nextAgent  int
+
+	running         int
+	runningPerUser  map[[32]byte]int
+	runningPerAgent map[[32]byte]int
+	recent          map[userAgentKey][]recentPrompt
+	lastSweep       time.Time
+
+	pool               Pool
+	maxConcurrent      int
+	maxPerUser         int
+	maxPerAgent        int
+	duplicateWindow    time.Duration
+	duplicateThreshold float64
+	duplicateDelay     time.Duration
+	maxDuplicateDelay  time.Duration
+	tickRate           time.Duration
+
+	wakeCh chan struct{}
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(cfg *SchedulerConfig) *Scheduler {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 10
+	}
+	if cfg.MaxPerUser <= 0 {
+		cfg.MaxPerUser = 1
+	}
+	if cfg.MaxPerAgent <= 0 {
+		cfg.MaxPerAgent = cfg.MaxConcurrent
+	}
+	if cfg.DuplicateWindow <= 0 {
+		cfg.DuplicateWindow = 10 * time.Minute
+	}
+	if cfg.DuplicateThreshold <= 0 {
+		cfg.DuplicateThreshold = 0.9
+	}
+	if cfg.DuplicateDelay <= 0 {
+		cfg.DuplicateDelay = 30 * time.Second
+	}
+	if cfg.MaxDuplicateDelay <= 0 {
+		cfg.MaxDuplicateDelay = 10 * time.Minute
+	}
+	if cfg.TickRate <= 0 {
+		cfg.TickRate = 1 * time.Second
+	}
+
+	return &Scheduler{
+		queues:             make(map[[32]byte][]*scheduledTask),
+		runningPerUser:     make(map[[32]byte]int),
+		runningPerAgent:    make(map[[32]byte]int),
+		recent:             make(map[userAgentKey][]recentPrompt),
+		pool:               cfg.Pool,
+		maxConcurrent:      cfg.MaxConcurrent,
+		maxPerUser:         cfg.MaxPerUser,
+		maxPerAgent:        cfg.MaxPerAgent,
+		duplicateWindow:    cfg.DuplicateWindow,
+		duplicateThreshold: cfg.DuplicateThreshold,
+		duplicateDelay:     cfg.DuplicateDelay,
+		maxDuplicateDelay:  cfg.MaxDuplicateDelay,
+		tickRate:           cfg.TickRate,
+		wakeCh:             make(chan struct{}, 1),
+	}
+}
+
+// Submit queues a task. It returns how long the task was deferred as a
+// near-duplicate of the user's recent prompts.
+func (s *Scheduler) Submit(task *Task) time.Duration {
+	now := time.Now()
+
+	s.mu.Lock()
+	delay := s.duplicateDelayFor(task, now)
+
+	agentKey := task.AgentAddress.Bytes()
+	if _, ok := s.queues[agentKey]; !ok {
+		s.agentOrder = append(s.agentOrder, agentKey)
+	}
+	s.queues[agentKey] = append(s.queues[agentKey], &scheduledTask{
+		Task:      task,
+		notBefore: now.Add(delay),
+	})
+	s.mu.Unlock()
+
+	if delay > 0 {
+		slog.Info("deferring near-duplicate prompt", "agent_address", task.AgentAddress, "prompt_id", task.PromptID, "user", task.User, "delay", delay)
+	}
+
+	s.wake()
+
+	return delay
+}
+
+// duplicateDelayFor records the prompt and returns its deferral.
+func (s *Scheduler) duplicateDelayFor(task *Task, now time.Time) time.Duration {
+	key := userAgentKey{agentAddress: task.AgentAddress.Bytes(), user: task.User.Bytes()}
+	words := promptWords(task.Prompt)
+
+	kept := make([]recentPrompt, 0, len(s.recent[key])+1)
+	duplicates := 0
+	for _, prev := range s.recent[key] {
+		if now.Sub(prev.at) > s.duplicateWindow {
+			continue
+		}
+		kept = append(kept, prev)
+
+		if similarity(words, prev.words) >= s.duplicateThreshold {
+			duplicates++
+		}
+	}
+	s.recent[key] = append(kept, recentPrompt{words: words, at: now})
+
+	delay := time.Duration(duplicates) * s.duplicateDelay
+	if delay > s.maxDuplicateDelay {
+		delay = s.maxDuplicateDelay
+	}
+	return delay
+}
+
+func (s *Scheduler) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches tasks as they become eligible and blocks until the context
+// is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		s.dispatch()
+		s.sweepRecent()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wakeCh:
+		case <-time.After(s.tickRate):
+		}
+	}
+}
+
+// sweepRecent forgets the prompts older than the duplicate window, at most once
+// per window, so that users who stop prompting are not remembered forever.
+func (s *Scheduler) sweepRecent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) < s.duplicateWindow {
+		return
+	}
+	s.lastSweep = now
+
+	for key, prompts := range s.recent {
+		kept := prompts[:0]
+		for _, prev := range prompts {
+			if now.Sub(prev.at) <= s.duplicateWindow {
+				kept = append(kept, prev)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(s.recent, key)
+		} else {
+			s.recent[key] = kept
+		}
+	}
+}
+
+// dispatch hands eligible tasks to the pool, taking at most one task from each
+// agent per round.
+func (s *Scheduler) dispatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	for s.running < s.maxConcurrent && len(s.agentOrder) > 0 {
+		dispatched := false
+
+		for i := 0; i < len(s.agentOrder) && s.running < s.maxConcurrent; i++ {
+			idx := (s.nextAgent + i) % len(s.agentOrder)
+			agentKey := s.agentOrder[idx]
+
+			task, ok := s.takeEligible(agentKey, now)
+			if !ok {
+				continue
+			}
+
+			s.start(task)
+			dispatched = true
+		}
+
+		s.removeEmptyQueues()
+		if len(s.agentOrder) > 0 {
+			s.nextAgent = (s.nextAgent + 1) % len(s.agentOrder)
+		}
+
+		if !dispatched {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) takeEligible(agentKey [32]byte, now time.Time) (*scheduledTask, bool) {
+	if s.runningPerAgent[agentKey] >= s.maxPerAgent {
+		return nil, false
+	}
+
+	queue := s.queues[agentKey]
+	for i, task := range queue {
+		if task.notBefore.After(now) || s.runningPerUser[task.User.Bytes()] >= s.maxPerUser {
+			continue
+		}
+
+		s.queues[agentKey] = append(queue[:i:i], queue[i+1:]...)
+		return task, true
+	}
+
+	return nil, false
+}
+
+func (s *Scheduler) removeEmptyQueues() {
+	order := s.agentOrder[:0]
+	for _, agentKey := range s.agentOrder {
+		if len(s.queues[agentKey]) == 0 && s.runningPerAgent[agentKey] == 0 {
+			delete(s.queues, agentKey)
+			continue
+		}
+		order = append(order, agentKey)
+	}
+	s.agentOrder = order
+	if s.nextAgent >= len(s.agentOrder) {
+		s.nextAgent = 0
+	}
+}
+
+func (s *Scheduler) start(task *scheduledTask) {
+	agentKey := task.AgentAddress.Bytes()
+	userKey := task.User.Bytes()
+
+	s.running++
+	s.runningPerAgent[agentKey]++
+	s.runningPerUser[userKey]++
+
+	err := s.pool.Go(func() {
+		defer s.finish(agentKey, userKey)
+		task.Run()
+	})
+	if err != nil {
+		slog.Error("failed to pool prompt task, requeueing", "agent_address", task.AgentAddress, "prompt_id", task.PromptID, "error", err)
+		s.running--
+		s.runningPerAgent[agentKey]--
+		s.runningPerUser[userKey]--
+		s.queues[agentKey] = append([]*scheduledTask{task}, s.queues[agentKey]...)
+	}
+}
+
+func (s *Scheduler) finish(agentKey, userKey [32]byte) {
+	s.mu.Lock()
+	s.running--
+	s.runningPerAgent[agentKey]--
+	if s.runningPerAgent[agentKey] == 0 {
+		delete(s.runningPerAgent, agentKey)
+	}
+	s.runningPerUser[userKey]--
+	if s.runningPerUser[userKey] == 0 {
+		delete(s.runningPerUser, userKey)
+	}
+	s.mu.Unlock()
+
+	s.wake()
+}
+
+// Stats is a snapshot of the scheduler's queues.
+type Stats struct {
+	Queued   int            `json:"queued"`
+	Deferred int            `json:"deferred"`
+	Running  int            `json:"running"`
+	PerAgent map[string]int `json:"per_agent"`
+	// Recent is the number of user and agent pairs with prompts within the
+	// duplicate window.
+	Recent int `json:"recent"`
+}
+
+// Stats returns a snapshot of the queued and running tasks.
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stats := Stats{
+		Running:  s.running,
+		PerAgent: make(map[string]int),
+		Recent:   len(s.recent),
+	}
+
+	for _, queue := range s.queues {
+		for _, task := range queue {
+			stats.Queued++
+			if task.notBefore.After(now) {
+				stats.Deferred++
+			}
+			stats.PerAgent[task.AgentAddress.String()]++
+		}
+	}
+
+	return stats
+}
+
+// promptWords returns the set of normalized words of a prompt.
+func promptWords(prompt string) map[string]struct{} {
+	words := make(map[string]struct{})
+
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words[string(word)] = struct{}{}
+			word = word[:0]
+		}
+	}
+
+	for _, r := range prompt {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r > 127:
+			word = append(word, r)
+		case r >= 'A' && r <= 'Z':
+			word = append(word, r+('a'-'A'))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return words
+}
+
+// similarity is the Jaccard index of two word sets.
+func similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	intersection := 0
+	for word := range a {
+		if _, ok := b[word]; ok {
+			intersection++
+		}
+	}
+
+	union := len(a) + len(b) - intersection
+	return float64(intersection) / float64(union)
+}
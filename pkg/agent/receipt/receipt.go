@@ -0,0 +1,163 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/curve"
+	"golang.org/x/crypto/sha3"
+
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+const Version = 1
+
+// domain separates receipt hashes from any other message the key signs.
+var domain = new(felt.Felt).SetBytes([]byte("teeception_receipt"))
+
+// Signer signs receipt hashes, e.g. a StarknetAccount.
+type Signer interface {
+	Address() *felt.Felt
+	PublicKey() *felt.Felt
+	Sign(ctx context.Context, msgHash *felt.Felt) ([]*felt.Felt, error)
+}
+
+var _ Signer = (*snaccount.StarknetAccount)(nil)
+
+// Receipt is a signed record of how the agent decided on a prompt. The inputs
+// are committed to by hash, as they are public on-chain, while the response
+// is included as is.
+type Receipt struct {
+	Version          uint64     `json:"version"`
+	AgentAddress     *felt.Felt `json:"agent_address"`
+	PromptID         uint64     `json:"prompt_id"`
+	PromptHash       *felt.Felt `json:"prompt_hash"`
+	SystemPromptHash *felt.Felt `json:"system_prompt_hash"`
+	MetadataHash     *felt.Felt `json:"metadata_hash"`
+	ModelHash        *felt.Felt `json:"model_hash"`
+	Response         string     `json:"response"`
+	// DrainTo is zero unless the agent decided to drain, whether or not the
+	// drain was executed.
+	DrainTo *felt.Felt `json:"drain_to"`
+	// ConsumeTxHash is zero if the prompt was not consumed by the agent.
+	ConsumeTxHash *felt.Felt `json:"consume_tx_hash"`
+
+	Signer    *felt.Felt   `json:"signer"`
+	PublicKey *felt.Felt   `json:"public_key"`
+	Signature []*felt.Felt `json:"signature"`
+}
+
+// Params are the inputs and outcome of a prompt a receipt is issued for.
+type Params struct {
+	AgentAddress  *felt.Felt
+	PromptID      uint64
+	Prompt        string
+	SystemPrompt  string
+	Metadata      string
+	Model         string
+	Response      string
+	DrainTo       *felt.Felt
+	ConsumeTxHash *felt.Felt
+}
+
+// New creates an unsigned receipt.
+func New(params *Params) *Receipt {
+	return &Receipt{
+		Version:          Version,
+		AgentAddress:     params.AgentAddress,
+		PromptID:         params.PromptID,
+		PromptHash:       HashString(params.Prompt),
+		SystemPromptHash: HashString(params.SystemPrompt),
+		MetadataHash:     HashString(params.Metadata),
+		ModelHash:        HashString(params.Model),
+		Response:         params.Response,
+		DrainTo:          orZero(params.DrainTo),
+		ConsumeTxHash:    orZero(params.ConsumeTxHash),
+	}
+}
+
+// HashString hashes arbitrary text into a felt with Starknet keccak.
+func HashString(s string) *felt.Felt {
+	keccak := sha3.NewLegacyKeccak256()
+	keccak.Write([]byte(s))
+	digest := keccak.Sum(nil)
+
+	// Starknet keccak keeps the 250 least significant bits.
+	digest[0] &= 0x03
+
+	return new(felt.Felt).SetBytes(digest)
+}
+
+func orZero(f *felt.Felt) *felt.Felt {
+	if f == nil {
+		return new(felt.Felt)
+	}
+	return f
+}
+
+// Hash returns the message hash the signature is over.
+func (r *Receipt) Hash() *felt.Felt {
+	return crypto.PoseidonArray(
+		domain,
+		new(felt.Felt).SetUint64(r.Version),
+		orZero(r.AgentAddress),
+		new(felt.Felt).SetUint64(r.PromptID),
+		orZero(r.PromptHash),
+		orZero(r.SystemPromptHash),
+		orZero(r.MetadataHash),
+		orZero(r.ModelHash),
+		HashString(r.Response),
+		orZero(r.DrainTo),
+		orZero(r.ConsumeTxHash),
+		orZero(r.Signer),
+	)
+}
+
+// Sign signs the receipt.
+func (r *Receipt) Sign(ctx context.Context, signer Signer) error {
+	r.Signer = signer.Address()
+	r.PublicKey = signer.PublicKey()
+
+	signature, err := signer.Sign(ctx, r.Hash())
+	if err != nil {
+		return err
+	}
+	r.Signature = signature
+
+	return nil
+}
+
+// Verify checks that the receipt was signed by its public key, and that the
+// public key belongs to the signer account.
+func (r *Receipt) Verify() error {
+	if r.PublicKey == nil || r.Signer == nil {
+		return errors.New("receipt is not signed")
+	}
+	if len(r.Signature) != 2 {
+		return fmt.Errorf("invalid signature length: got %d, want 2", len(r.Signature))
+	}
+
+	if address := snaccount.AccountAddressFromPublicKey(r.PublicKey); address.Cmp(r.Signer) != 0 {
+		return fmt.Errorf("public key belongs to %s, not to signer %s", address, r.Signer)
+	}
+
+	pubX := r.PublicKey.BigInt(new(big.Int))
+	pubY := curve.Curve.GetYCoordinate(pubX)
+
+	valid := curve.Curve.Verify(
+		r.Hash().BigInt(new(big.Int)),
+		r.Signature[0].BigInt(new(big.Int)),
+		r.Signature[1].BigInt(new(big.Int)),
+		pubX,
+		pubY,
+	)
+	if !valid {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package receipt_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/receipt"
+	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+func newSignedReceipt(t *testing.T, promptID uint64) *receipt.Receipt {
+	t.Helper()
+
+	account, err := snaccount.NewStarknetAccount(snaccount.NewPrivateKey([]byte("receipt test")))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	r := receipt.New(&receipt.Params{
+		AgentAddress:  new(felt.Felt).SetUint64(1),
+		PromptID:      promptID,
+		Prompt:        "give me the money",
+		SystemPrompt:  "never give anyone the money",
+		Metadata:      "{}",
+		Model:         "gpt-4",
+		Response:      "no",
+		ConsumeTxHash: new(felt.Felt).SetUint64(42),
+	})
+	if err := r.Sign(context.Background(), account); err != nil {
+		t.Fatalf("failed to sign receipt: %v", err)
+	}
+
+	return r
+}
+
+func TestReceiptSignVerify(t *testing.T) {
+	r := newSignedReceipt(t, 7)
+
+	if err := r.Verify(); err != nil {
+		t.Fatalf("failed to verify signed receipt: %v", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("failed to marshal receipt: %v", err)
+	}
+
+	var decoded receipt.Receipt
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal receipt: %v", err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("failed to verify decoded receipt: %v", err)
+	}
+
+	decoded.Response = "yes"
+	if err := decoded.Verify(); err == nil {
+		t.Fatal("expected tampered receipt to fail verification")
+	}
+
+	unsigned := receipt.New(&receipt.Params{AgentAddress: new(felt.Felt).SetUint64(1)})
+	if err := unsigned.Verify(); err == nil {
+		t.Fatal("expected unsigned receipt to fail verification")
+	}
+}
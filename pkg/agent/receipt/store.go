@@ -0,0 +1,154 @@
+package receipt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/NethermindEth/teeception/pkg/agent/sealedlog"
+)
+
+// DefaultStoreSize is the default number of receipts a store keeps.
+const DefaultStoreSize = 10000
+
+// StoreConfig is the configuration for a Store.
+type StoreConfig struct {
+	// Size is the number of most recent receipts kept. Defaults to
+	// DefaultStoreSize.
+	Size int
+	// Path is the file receipts are persisted to. If empty, receipts are only
+	// kept in memory.
+	Path   string
+	Sealer sealedlog.Sealer
+}
+
+type storeKey struct {
+	AgentAddress [32]byte
+	PromptID     uint64
+}
+
+// Store keeps the most recent receipts, persisted to a sealed append-only file
+// if configured. The file is compacted to the kept receipts on open and every
+// Size appended receipts.
+type Store struct {
+	mu    sync.Mutex
+	cache *lru.Cache[storeKey, *Receipt]
+	size  int
+	path  string
+	log   *sealedlog.Log
+}
+
+// NewStore creates a store, loading the receipts persisted at config.Path.
+func NewStore(config *StoreConfig) (*Store, error) {
+	size := config.Size
+	if size <= 0 {
+		size = DefaultStoreSize
+	}
+
+	cache, err := lru.New[storeKey, *Receipt](size)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		cache: cache,
+		size:  size,
+		path:  config.Path,
+	}
+
+	if s.path == "" {
+		return s, nil
+	}
+
+	if config.Sealer == nil {
+		return nil, errors.New("receipt store sealer is required with a path")
+	}
+	s.log = sealedlog.New(s.path, config.Sealer)
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load receipts: %w", err)
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, fmt.Errorf("failed to compact receipts: %w", err)
+	}
+
+	slog.Info("opened receipt store", "path", s.path, "receipts", s.cache.Len())
+
+	return s, nil
+}
+
+func (s *Store) load() error {
+	return s.log.Load(func(plaintext []byte) error {
+		var r Receipt
+		if err := json.Unmarshal(plaintext, &r); err != nil {
+			return fmt.Errorf("failed to unmarshal receipt record: %w", err)
+		}
+		if r.AgentAddress == nil {
+			return nil
+		}
+
+		s.cache.Add(newStoreKey(r.AgentAddress, r.PromptID), &r)
+		return nil
+	})
+}
+
+// compact rewrites the file with only the kept receipts, oldest first, then
+// reopens it for appending.
+func (s *Store) compact() error {
+	return s.log.Compact(func(write func(record any) error) error {
+		for _, r := range s.cache.Values() {
+			if err := write(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Add stores a receipt, replacing any earlier one for the same prompt.
+func (s *Store) Add(r *Receipt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log != nil {
+		if err := s.log.Append(r); err != nil {
+			return fmt.Errorf("failed to append receipt: %w", err)
+		}
+	}
+
+	s.cache.Add(newStoreKey(r.AgentAddress, r.PromptID), r)
+
+	if s.log != nil && s.log.Appended() >= s.size {
+		if err := s.compact(); err != nil {
+			slog.Error("failed to compact receipts", "path", s.path, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the receipt of a prompt.
+func (s *Store) Get(agentAddress *felt.Felt, promptID uint64) (*Receipt, bool) {
+	return s.cache.Get(newStoreKey(agentAddress, promptID))
+}
+
+// Close closes the file receipts are persisted to.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil
+	}
+	return s.log.Close()
+}
+
+func newStoreKey(agentAddress *felt.Felt, promptID uint64) storeKey {
+	return storeKey{AgentAddress: agentAddress.Bytes(), PromptID: promptID}
+}
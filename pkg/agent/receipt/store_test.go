@@ -0,0 +1,60 @@
+package receipt_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/receipt"
+)
+
+type plainSealer struct{}
+
+func (plainSealer) Seal(plaintext []byte) ([]byte, error)  { return plaintext, nil }
+func (plainSealer) Open(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+
+func TestStorePersistsReceipts(t *testing.T) {
+	config := &receipt.StoreConfig{
+		Size:   2,
+		Path:   filepath.Join(t.TempDir(), "receipts.bin"),
+		Sealer: plainSealer{},
+	}
+
+	store, err := receipt.NewStore(config)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	for promptID := uint64(1); promptID <= 3; promptID++ {
+		if err := store.Add(newSignedReceipt(t, promptID)); err != nil {
+			t.Fatalf("failed to add receipt: %v", err)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	store, err = receipt.NewStore(config)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	agentAddress := new(felt.Felt).SetUint64(1)
+
+	if _, ok := store.Get(agentAddress, 1); ok {
+		t.Error("expected oldest receipt to be evicted")
+	}
+
+	for promptID := uint64(2); promptID <= 3; promptID++ {
+		r, ok := store.Get(agentAddress, promptID)
+		if !ok {
+			t.Fatalf("expected receipt of prompt %d to be persisted", promptID)
+		}
+		if err := r.Verify(); err != nil {
+			t.Errorf("failed to verify persisted receipt of prompt %d: %v", promptID, err)
+		}
+	}
+}
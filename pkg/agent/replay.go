@@ -0,0 +1,15 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+// ReplayPrompt runs a historical prompt through the agent's reaction logic
+// without journaling it. The agent should be configured with recording
+// Twitter client and PromptConsumer implementations.
+func (a *Agent) ReplayPrompt(ctx context.Context, agentInfo *indexer.AgentInfo, promptPaidEvent *indexer.PromptPaidEvent, block uint64) error {
+	entry := newPromptEntry(agentInfo.Address, promptPaidEvent, block)
+	return a.reactToTweet(ctx, agentInfo, &entry)
+}
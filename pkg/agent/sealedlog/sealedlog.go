@@ -0,0 +1,173 @@
+package sealedlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Sealer encrypts and authenticates records at rest.
+type Sealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+}
+
+// Log is an append-only file of sealed, length-prefixed JSON records, shared by
+// the prompt journal and the receipt store. It is not safe for concurrent use.
+type Log struct {
+	path     string
+	sealer   Sealer
+	file     *os.File
+	appended int
+}
+
+// New creates a log persisted at path. The file is only opened for appending
+// once the log is compacted.
+func New(path string, sealer Sealer) *Log {
+	return &Log{
+		path:   path,
+		sealer: sealer,
+	}
+}
+
+// Load calls apply with the plaintext of every record in the file, oldest
+// first. A missing file has no records, and a truncated tail, as left by a
+// crash mid-write, is ignored.
+func (l *Log) Load(apply func(plaintext []byte) error) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		var size uint32
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			slog.Warn("truncated record header, ignoring tail", "path", l.path, "error", err)
+			return nil
+		}
+
+		sealed := make([]byte, size)
+		if _, err := io.ReadFull(reader, sealed); err != nil {
+			slog.Warn("truncated record, ignoring tail", "path", l.path, "error", err)
+			return nil
+		}
+
+		plaintext, err := l.sealer.Open(sealed)
+		if err != nil {
+			return fmt.Errorf("failed to open record: %w", err)
+		}
+
+		if err := apply(plaintext); err != nil {
+			return err
+		}
+	}
+}
+
+// Compact rewrites the file with the records passed to write by records, then
+// reopens it for appending.
+func (l *Log) Compact(records func(write func(record any) error) error) error {
+	tmpPath := l.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := records(func(record any) error {
+		return l.writeRecord(file, record)
+	}); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+
+	file, err = os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = file
+	l.appended = 0
+
+	return nil
+}
+
+// Append durably appends a record.
+func (l *Log) Append(record any) error {
+	if l.file == nil {
+		return errors.New("log is not open for appending")
+	}
+
+	if err := l.writeRecord(l.file, record); err != nil {
+		return err
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync record: %w", err)
+	}
+
+	l.appended++
+
+	return nil
+}
+
+// Appended returns the number of records appended since the last compaction.
+func (l *Log) Appended() int {
+	return l.appended
+}
+
+// Close closes the file.
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+func (l *Log) writeRecord(w io.Writer, record any) error {
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	sealed, err := l.sealer.Seal(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to seal record: %w", err)
+	}
+
+	buf := make([]byte, 4+len(sealed))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(sealed)))
+	copy(buf[4:], sealed)
+
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	return nil
+}
@@ -2,11 +2,14 @@ package setup
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
 )
 
 const (
@@ -24,6 +27,8 @@ const (
 	AgentRegistryAddressKey         = "CONTRACT_ADDRESS"
 	AgentRegistryDeploymentBlockKey = "CONTRACT_DEPLOYMENT_BLOCK"
 	OpenAiKeyKey                    = "OPENAI_API_KEY"
+	ModelRoutesKey                  = "MODEL_ROUTES"
+	FallbackModelKey                = "FALLBACK_MODEL"
 	UnencumberEncryptionKeyKey      = "UNENCUMBER_ENCRYPTION_KEY"
 	DisableEncumberingKey           = "DISABLE_ENCUMBERING"
 )
@@ -144,6 +149,32 @@ func envGetOpenAiKey() string {
 	return key
 }
 
+func envGetModelRoutes() (map[string]chat.BackendConfig, error) {
+	routes, ok := os.LookupEnv(ModelRoutesKey)
+	if !ok || routes == "" {
+		return nil, nil
+	}
+
+	var modelRoutes map[string]chat.BackendConfig
+	if err := json.Unmarshal([]byte(routes), &modelRoutes); err != nil {
+		return nil, fmt.Errorf(ModelRoutesKey+" environment variable is not a valid JSON object: %w", err)
+	}
+	return modelRoutes, nil
+}
+
+func envGetFallbackModel() (*chat.BackendConfig, error) {
+	fallback, ok := os.LookupEnv(FallbackModelKey)
+	if !ok || fallback == "" {
+		return nil, nil
+	}
+
+	var fallbackModel chat.BackendConfig
+	if err := json.Unmarshal([]byte(fallback), &fallbackModel); err != nil {
+		return nil, fmt.Errorf(FallbackModelKey+" environment variable is not a valid JSON object: %w", err)
+	}
+	return &fallbackModel, nil
+}
+
 func envGetUnencumberEncryptionKey() [32]byte {
 	var keyBytes [32]byte
 
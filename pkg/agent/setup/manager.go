@@ -7,6 +7,7 @@ import (
 
 	"github.com/NethermindEth/juno/core/felt"
 	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+	"github.com/NethermindEth/teeception/pkg/agent/chat"
 	"github.com/NethermindEth/teeception/pkg/agent/debug"
 	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
 	"github.com/dghubble/oauth1"
@@ -23,6 +24,8 @@ type SetupManager struct {
 	agentRegistryAddress         string
 	agentRegistryDeploymentBlock uint64
 	openAiKey                    string
+	modelRoutes                  map[string]chat.BackendConfig
+	fallbackModel                *chat.BackendConfig
 	loginServerIp                string
 	loginServerPort              string
 	dstackTappdEndpoint          string
@@ -46,9 +49,24 @@ type SetupOutput struct {
 	OpenAIKey                    string     `json:"openai_key"`
 	DstackTappdEndpoint          string     `json:"dstack_tappd_endpoint"`
 	UnencumberEncryptionKey      [32]byte   `json:"encryption_key"`
+
+	// ModelRoutes and FallbackModel configure the chat backends of the
+	// models agents use.
+	ModelRoutes   map[string]chat.BackendConfig `json:"model_routes"`
+	FallbackModel *chat.BackendConfig           `json:"fallback_model"`
 }
 
 func NewSetupManagerFromEnv() (*SetupManager, error) {
+	modelRoutes, err := envGetModelRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	fallbackModel, err := envGetFallbackModel()
+	if err != nil {
+		return nil, err
+	}
+
 	setupManager := &SetupManager{
 		twitterAccount:               envGetTwitterAccount(),
 		twitterPassword:              envGetTwitterPassword(),
@@ -60,6 +78,8 @@ func NewSetupManagerFromEnv() (*SetupManager, error) {
 		agentRegistryAddress:         envGetAgentRegistryAddress(),
 		agentRegistryDeploymentBlock: envGetAgentRegistryDeploymentBlock(),
 		openAiKey:                    envGetOpenAiKey(),
+		modelRoutes:                  modelRoutes,
+		fallbackModel:                fallbackModel,
 		loginServerIp:                envGetLoginServerIp(),
 		loginServerPort:              envGetLoginServerPort(),
 		dstackTappdEndpoint:          envGetDstackTappdEndpoint(),
@@ -181,6 +201,8 @@ func (m *SetupManager) Setup(ctx context.Context) (*SetupOutput, error) {
 		AgentRegistryAddress:         agentRegistryAddress,
 		AgentRegistryDeploymentBlock: m.agentRegistryDeploymentBlock,
 		OpenAIKey:                    m.openAiKey,
+		ModelRoutes:                  m.modelRoutes,
+		FallbackModel:                m.fallbackModel,
 		DstackTappdEndpoint:          m.dstackTappdEndpoint,
 		UnencumberEncryptionKey:      m.unencumberEncryptionKey,
 	}
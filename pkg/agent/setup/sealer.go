@@ -0,0 +1,62 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dstack-TEE/dstack/sdk/go/tappd"
+
+	"github.com/NethermindEth/teeception/pkg/agent/debug"
+)
+
+const (
+	SetupSealingKeyPath    = "/agent/sealing"
+	JournalSealingKeyPath  = "/agent/journal"
+	ReceiptsSealingKeyPath = "/agent/receipts"
+)
+
+// DeriveSealingKey derives a 32 byte key bound to the TEE for the given path
+func DeriveSealingKey(ctx context.Context, client *tappd.TappdClient, path string) ([]byte, error) {
+	sealingKeyResp, err := client.DeriveKeyWithSubject(ctx, path, "teeception")
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive sealing key: %v", err)
+	}
+
+	sealingKey, err := sealingKeyResp.ToBytes(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sealing key to bytes: %v", err)
+	}
+
+	return sealingKey, nil
+}
+
+// Sealer encrypts data with a sealing key. When the plain setup debug flag is
+// set, data is stored unencrypted.
+type Sealer struct {
+	key []byte
+}
+
+// NewSealer creates a new Sealer
+func NewSealer(key []byte) *Sealer {
+	return &Sealer{
+		key: key,
+	}
+}
+
+// Seal encrypts plaintext
+func (s *Sealer) Seal(plaintext []byte) ([]byte, error) {
+	if debug.IsDebugPlainSetup() {
+		return plaintext, nil
+	}
+
+	return encrypt(plaintext, s.key)
+}
+
+// Open decrypts ciphertext produced by Seal
+func (s *Sealer) Open(ciphertext []byte) ([]byte, error) {
+	if debug.IsDebugPlainSetup() {
+		return ciphertext, nil
+	}
+
+	return decrypt(ciphertext, s.key)
+}
@@ -21,14 +21,9 @@ func Setup(ctx context.Context) (*SetupOutput, error) {
 	dstackTappdEndpoint := envGetDstackTappdEndpoint()
 	dstackTappdClient := tappd.NewTappdClient(tappd.WithEndpoint(dstackTappdEndpoint))
 
-	sealingKeyResp, err := dstackTappdClient.DeriveKeyWithSubject(ctx, "/agent/sealing", "teeception")
+	sealingKey, err := DeriveSealingKey(ctx, dstackTappdClient, SetupSealingKeyPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive sealing key: %v", err)
-	}
-
-	sealingKey, err := sealingKeyResp.ToBytes(32)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert sealing key to bytes: %v", err)
+		return nil, err
 	}
 
 	setupOutput, err := loadSetup(ctx, secureFilePath, sealingKey)
@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/journal"
+)
+
+// Tracker tracks queued and running prompt tasks so that prompts reclaimed
+// on-chain can be cancelled before they are answered or consumed. Reclaims are
+// only remembered until the prompt's task is skipped or finished.
+type Tracker struct {
+	mu        sync.Mutex
+	queued    map[journal.Key]bool
+	cancels   map[journal.Key]context.CancelFunc
+	reclaimed map[journal.Key]bool
+}
+
+// NewTracker creates a new Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		queued:    make(map[journal.Key]bool),
+		cancels:   make(map[journal.Key]context.CancelFunc),
+		reclaimed: make(map[journal.Key]bool),
+	}
+}
+
+// Queue registers a task for the given prompt that will be started later.
+func (t *Tracker) Queue(agentAddress *felt.Felt, promptID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.queued[journal.NewKey(agentAddress, promptID)] = true
+}
+
+// Forget drops a queued task that will not be started.
+func (t *Tracker) Forget(agentAddress *felt.Felt, promptID uint64) {
+	key := journal.NewKey(agentAddress, promptID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.queued, key)
+	if _, ok := t.cancels[key]; !ok {
+		delete(t.reclaimed, key)
+	}
+}
+
+// Start registers a task for the given prompt and returns its context and the
+// function to call once it is done. It returns false if the prompt was
+// reclaimed or is already being processed.
+func (t *Tracker) Start(ctx context.Context, agentAddress *felt.Felt, promptID uint64) (context.Context, func(), bool) {
+	key := journal.NewKey(agentAddress, promptID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.queued, key)
+
+	if _, ok := t.cancels[key]; ok {
+		return nil, nil, false
+	}
+	if t.reclaimed[key] {
+		delete(t.reclaimed, key)
+		return nil, nil, false
+	}
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	t.cancels[key] = cancel
+
+	return taskCtx, func() {
+		t.mu.Lock()
+		delete(t.cancels, key)
+		delete(t.reclaimed, key)
+		t.mu.Unlock()
+
+		cancel()
+	}, true
+}
+
+// Reclaim marks the prompt as reclaimed and cancels its task, or makes its
+// queued task skip once started. It reports whether a task was running, in
+// which case the task is left to record the outcome.
+func (t *Tracker) Reclaim(agentAddress *felt.Felt, promptID uint64) bool {
+	key := journal.NewKey(agentAddress, promptID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cancel, running := t.cancels[key]
+	if !running && !t.queued[key] {
+		return false
+	}
+
+	t.reclaimed[key] = true
+	if running {
+		cancel()
+	}
+
+	return running
+}
+
+// Len returns the number of prompts that are queued, running or reclaimed.
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.queued) + len(t.cancels) + len(t.reclaimed)
+}
+
+// IsReclaimed reports whether the prompt of a queued or running task was
+// reclaimed.
+func (t *Tracker) IsReclaimed(agentAddress *felt.Felt, promptID uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.reclaimed[journal.NewKey(agentAddress, promptID)]
+}
@@ -0,0 +1,75 @@
+package tasks_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/agent/tasks"
+)
+
+func TestTrackerReclaimCancelsRunningTask(t *testing.T) {
+	tracker := tasks.NewTracker()
+	agent := new(felt.Felt).SetUint64(1)
+
+	tracker.Queue(agent, 1)
+	ctx, done, ok := tracker.Start(context.Background(), agent, 1)
+	if !ok {
+		t.Fatal("expected task to start")
+	}
+
+	if _, _, ok := tracker.Start(context.Background(), agent, 1); ok {
+		t.Fatal("expected running task not to start again")
+	}
+
+	if !tracker.Reclaim(agent, 1) {
+		t.Fatal("expected reclaim to report a running task")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected task context to be cancelled")
+	}
+	if !tracker.IsReclaimed(agent, 1) {
+		t.Fatal("expected prompt to be reclaimed while running")
+	}
+
+	done()
+
+	if tracker.IsReclaimed(agent, 1) || tracker.Len() != 0 {
+		t.Fatalf("expected finished task to be forgotten, %d tracked", tracker.Len())
+	}
+}
+
+func TestTrackerReclaimSkipsQueuedTask(t *testing.T) {
+	tracker := tasks.NewTracker()
+	agent := new(felt.Felt).SetUint64(1)
+
+	tracker.Queue(agent, 1)
+	if tracker.Reclaim(agent, 1) {
+		t.Fatal("expected reclaim not to report a queued task as running")
+	}
+
+	if _, _, ok := tracker.Start(context.Background(), agent, 1); ok {
+		t.Fatal("expected reclaimed task to be skipped")
+	}
+	if tracker.Len() != 0 {
+		t.Fatalf("expected skipped task to be forgotten, %d tracked", tracker.Len())
+	}
+}
+
+func TestTrackerIgnoresUnknownAndForgottenTasks(t *testing.T) {
+	tracker := tasks.NewTracker()
+	agent := new(felt.Felt).SetUint64(1)
+
+	if tracker.Reclaim(agent, 1) || tracker.Len() != 0 {
+		t.Fatal("expected reclaim of an unknown prompt not to be remembered")
+	}
+
+	tracker.Queue(agent, 2)
+	tracker.Reclaim(agent, 2)
+	tracker.Forget(agent, 2)
+
+	if tracker.Len() != 0 {
+		t.Fatalf("expected forgotten task to be dropped, %d tracked", tracker.Len())
+	}
+}
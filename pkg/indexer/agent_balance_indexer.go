@@ -25,10 +25,21 @@ type AgentBalance struct {
 	EndTime         uint64
 	IsDrained       bool
 	DrainAmount     *big.Int
+
+	// RegisteredAt and DrainedAt are the timestamps of the registration and
+	// of the drain of the agent.
+	RegisteredAt uint64
+	DrainedAt    uint64
+	// BreakAttempts is the number of prompts the agent survived.
+	BreakAttempts uint64
+	// FeesCollected is the total amount paid for the consumed prompts,
+	// including creator and protocol fees.
+	FeesCollected *big.Int
 }
 
 type AgentBalanceIndexerPriceCache interface {
 	GetTokenRate(token *felt.Felt) (*big.Int, bool)
+	GetTokenUSDValue(token *felt.Felt, amount *big.Int) (*big.Int, bool)
 }
 
 // AgentBalanceIndexer responds to Transfer events for addresses known to be Agents, and updates their balances.
@@ -77,7 +88,10 @@ func NewAgentBalanceIndexer(config *AgentBalanceIndexerConfig) *AgentBalanceInde
 	}
 
 	eventCh := make(chan *EventSubscriptionData, 1000)
-	eventSubID := config.EventWatcher.Subscribe(EventAgentRegistered|EventTransfer|EventDrained|EventWithdrawn|EventPromptPaid|EventPromptConsumed, eventCh)
+	eventSubID := config.EventWatcher.SubscribeWithConfig(&EventSubscriberConfig{
+		Type: EventAgentRegistered | EventTransfer | EventDrained | EventWithdrawn | EventPromptPaid | EventPromptConsumed,
+		Name: "agent_balance_indexer",
+	}, eventCh)
 
 	return &AgentBalanceIndexer{
 		client:          config.Client,
@@ -114,7 +128,16 @@ func (i *AgentBalanceIndexer) run(ctx context.Context) error {
 	for {
 		select {
 		case data := <-i.eventCh:
+			if data.Rollback {
+				slog.Warn("reverting agent balances", "block", data.ToBlock)
+				i.mu.Lock()
+				i.db.RevertToBlock(data.ToBlock)
+				i.db.SortAgents(i.priceCache)
+				i.mu.Unlock()
+				continue
+			}
 			for _, ev := range data.Events {
+				i.db.SetCurrentEvent(ev)
 				switch ev.Type {
 				case EventTransfer:
 					i.onTransferEvent(ctx, ev)
@@ -130,6 +153,9 @@ func (i *AgentBalanceIndexer) run(ctx context.Context) error {
 					i.onPromptConsumedEvent(ctx, ev)
 				}
 			}
+			i.mu.Lock()
+			i.db.SetLastIndexedBlock(data.ToBlock)
+			i.mu.Unlock()
 		case <-ticker.C:
 			i.mu.Lock()
 			i.db.SortAgents(i.priceCache)
@@ -151,16 +177,18 @@ func (i *AgentBalanceIndexer) onTransferEvent(ctx context.Context, ev *Event) {
 
 	// Handle incoming transfers to agents
 	if i.db.GetAgentExists(transferEvent.To.Bytes()) {
-		balance, _ := i.db.GetAgentBalance(transferEvent.To.Bytes())
+		prevBalance, _ := i.db.GetAgentBalance(transferEvent.To.Bytes())
+		balance := *prevBalance
 		balance.Amount = new(big.Int).Add(balance.Amount, transferEvent.Amount)
-		i.db.SetAgentBalance(transferEvent.To.Bytes(), balance)
+		i.db.SetAgentBalance(transferEvent.To.Bytes(), &balance)
 	}
 
 	// Handle outgoing transfers from agents
 	if i.db.GetAgentExists(transferEvent.From.Bytes()) {
-		balance, _ := i.db.GetAgentBalance(transferEvent.From.Bytes())
+		prevBalance, _ := i.db.GetAgentBalance(transferEvent.From.Bytes())
+		balance := *prevBalance
 		balance.Amount = new(big.Int).Sub(balance.Amount, transferEvent.Amount)
-		i.db.SetAgentBalance(transferEvent.From.Bytes(), balance)
+		i.db.SetAgentBalance(transferEvent.From.Bytes(), &balance)
 	}
 }
 
@@ -175,7 +203,7 @@ func (i *AgentBalanceIndexer) onAgentRegisteredEvent(ctx context.Context, ev *Ev
 		return
 	}
 
-	i.pushAgent(agentRegisteredEvent)
+	i.pushAgent(agentRegisteredEvent, ev.Timestamp)
 
 	i.mu.Lock()
 	i.db.SortAgents(i.priceCache)
@@ -192,16 +220,21 @@ func (i *AgentBalanceIndexer) onDrainedEvent(ctx context.Context, ev *Event) {
 	defer i.mu.Unlock()
 
 	addrBytes := ev.Raw.FromAddress.Bytes()
-	agentBalance, ok := i.db.GetAgentBalance(addrBytes)
+	prevBalance, ok := i.db.GetAgentBalance(addrBytes)
 	if !ok {
 		slog.Warn("drained event for non-existent agent", "agent", ev.Raw.FromAddress.String())
 		return
 	}
 
+	timestamp := ev.Timestamp
+
+	agentBalance := *prevBalance
 	agentBalance.IsDrained = true
-	agentBalance.DrainAmount = agentBalance.DrainAmount.Add(agentBalance.DrainAmount, drainedEvent.Amount)
+	agentBalance.DrainedAt = timestamp
+	agentBalance.DrainAmount = new(big.Int).Add(agentBalance.DrainAmount, drainedEvent.Amount)
 
-	i.db.SetAgentBalance(addrBytes, agentBalance)
+	i.db.SetAgentBalance(addrBytes, &agentBalance)
+	i.db.StoreDrainedStats(addrBytes, timestamp)
 	i.db.SortAgents(i.priceCache)
 }
 
@@ -215,15 +248,16 @@ func (i *AgentBalanceIndexer) onWithdrawnEvent(ctx context.Context, ev *Event) {
 	defer i.mu.Unlock()
 
 	addrBytes := withdrawnEvent.To.Bytes()
-	agentBalance, ok := i.db.GetAgentBalance(addrBytes)
+	prevBalance, ok := i.db.GetAgentBalance(addrBytes)
 	if !ok {
 		slog.Warn("withdrawn event for non-existent agent", "agent", withdrawnEvent.To.String())
 		return
 	}
 
-	agentBalance.DrainAmount = agentBalance.DrainAmount.Add(agentBalance.DrainAmount, withdrawnEvent.Amount)
+	agentBalance := *prevBalance
+	agentBalance.DrainAmount = new(big.Int).Add(agentBalance.DrainAmount, withdrawnEvent.Amount)
 
-	i.db.SetAgentBalance(addrBytes, agentBalance)
+	i.db.SetAgentBalance(addrBytes, &agentBalance)
 	i.db.SortAgents(i.priceCache)
 }
 
@@ -237,14 +271,16 @@ func (i *AgentBalanceIndexer) onPromptPaidEvent(ctx context.Context, ev *Event)
 	defer i.mu.Unlock()
 
 	if i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
-		balance, _ := i.db.GetAgentBalance(ev.Raw.FromAddress.Bytes())
+		prevBalance, _ := i.db.GetAgentBalance(ev.Raw.FromAddress.Bytes())
+		balance := *prevBalance
 		balance.PendingAmount = new(big.Int).Add(balance.PendingAmount, balance.PromptPrice)
-		i.db.SetAgentBalance(ev.Raw.FromAddress.Bytes(), balance)
+		i.db.SetAgentBalance(ev.Raw.FromAddress.Bytes(), &balance)
+		i.db.StorePromptPaidStats(ev.Raw.FromAddress.Bytes(), ev.Timestamp)
 	}
 }
 
 func (i *AgentBalanceIndexer) onPromptConsumedEvent(ctx context.Context, ev *Event) {
-	_, ok := ev.ToPromptConsumedEvent()
+	promptConsumedEvent, ok := ev.ToPromptConsumedEvent()
 	if !ok {
 		return
 	}
@@ -253,13 +289,27 @@ func (i *AgentBalanceIndexer) onPromptConsumedEvent(ctx context.Context, ev *Eve
 	defer i.mu.Unlock()
 
 	if i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
-		balance, _ := i.db.GetAgentBalance(ev.Raw.FromAddress.Bytes())
+		prevBalance, _ := i.db.GetAgentBalance(ev.Raw.FromAddress.Bytes())
+		balance := *prevBalance
 		balance.PendingAmount = new(big.Int).Sub(balance.PendingAmount, balance.PromptPrice)
-		i.db.SetAgentBalance(ev.Raw.FromAddress.Bytes(), balance)
+
+		fees := new(big.Int).Add(promptConsumedEvent.Amount, promptConsumedEvent.CreatorFee)
+		fees.Add(fees, promptConsumedEvent.ProtocolFee)
+		if balance.FeesCollected != nil {
+			fees.Add(fees, balance.FeesCollected)
+		}
+		balance.FeesCollected = fees
+
+		// The prompt failed if its amount went back to the agent
+		if promptConsumedEvent.DrainedTo.Equal(ev.Raw.FromAddress) {
+			balance.BreakAttempts++
+		}
+
+		i.db.SetAgentBalance(ev.Raw.FromAddress.Bytes(), &balance)
 	}
 }
 
-func (i *AgentBalanceIndexer) pushAgent(ev *AgentRegisteredEvent) {
+func (i *AgentBalanceIndexer) pushAgent(ev *AgentRegisteredEvent, registeredAt uint64) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
@@ -276,6 +326,8 @@ func (i *AgentBalanceIndexer) pushAgent(ev *AgentRegisteredEvent) {
 		EndTime:         ev.EndTime,
 		IsDrained:       false,
 		DrainAmount:     big.NewInt(0),
+		RegisteredAt:    registeredAt,
+		FeesCollected:   big.NewInt(0),
 	})
 }
 
@@ -309,14 +361,26 @@ func (i *AgentBalanceIndexer) GetAgentLeaderboardCount() uint64 {
 }
 
 type AgentLeaderboardResponse struct {
-	Agents     [][32]byte
+	Agents [][32]byte
+	// Stats holds the stats of each agent over the window of a windowed
+	// leaderboard.
+	Stats      []*WindowedStats
 	AgentCount uint64
 	LastBlock  uint64
 }
 
-// GetAgentLeaderboard returns start:end agents from the agent balance leaderboard provided a callback to get a token's rate.
-func (i *AgentBalanceIndexer) GetAgentLeaderboard(start, end uint64, isActive *bool) (*AgentLeaderboardResponse, error) {
-	return i.db.GetLeaderboard(start, end, isActive, i.priceCache)
+// GetAgentLeaderboard returns start:end agents from the agent balance leaderboard sorted by mode.
+func (i *AgentBalanceIndexer) GetAgentLeaderboard(mode AgentSortMode, start, end uint64, isActive *bool) (*AgentLeaderboardResponse, error) {
+	return i.db.GetLeaderboard(mode, start, end, isActive, i.priceCache)
+}
+
+// GetWindowedAgentLeaderboard returns start:end agents from the agent
+// leaderboard over a window.
+func (i *AgentBalanceIndexer) GetWindowedAgentLeaderboard(window LeaderboardWindow, start, end uint64, isActive *bool) (*AgentLeaderboardResponse, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.db.GetWindowedLeaderboard(window, start, end, isActive, i.priceCache)
 }
 
 // GetLastIndexedBlock returns the last indexed block.
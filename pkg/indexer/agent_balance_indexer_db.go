@@ -2,7 +2,6 @@ package indexer
 
 import (
 	"fmt"
-	"log/slog"
 	"maps"
 	"math/big"
 	"slices"
@@ -14,7 +13,8 @@ import (
 
 // AgentBalanceIndexerDatabaseReader is the reader for an AgentBalanceIndexerDatabase.
 type AgentBalanceIndexerDatabaseReader interface {
-	GetLeaderboard(start, end uint64, isActive *bool, priceCache AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error)
+	GetLeaderboard(mode AgentSortMode, start, end uint64, isActive *bool, priceCache AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error)
+	GetWindowedLeaderboard(window LeaderboardWindow, start, end uint64, isActive *bool, priceCache AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error)
 	GetLeaderboardCount() uint64
 	GetAgentExists(addr [32]byte) bool
 	GetAgentBalance(addr [32]byte) (*AgentBalance, bool)
@@ -28,7 +28,12 @@ type AgentBalanceIndexerDatabaseWriter interface {
 	SortAgents(priceCache AgentBalanceIndexerPriceCache)
 
 	SetAgentBalance(addr [32]byte, balance *AgentBalance)
+	StorePromptPaidStats(addr [32]byte, timestamp uint64)
+	StoreDrainedStats(addr [32]byte, timestamp uint64)
 	SetLastIndexedBlock(block uint64)
+	SetCurrentBlock(block uint64)
+	SetCurrentEvent(ev *Event)
+	RevertToBlock(block uint64)
 }
 
 // AgentBalanceIndexerDatabase is the database for an AgentBalanceIndexer.
@@ -42,24 +47,35 @@ type AgentBalanceIndexerDatabaseInMemory struct {
 	balances   map[[32]byte]*AgentBalance
 	valueCache map[[32]byte]*big.Int
 
-	sortedAgentsMu      sync.RWMutex
-	sortedAgents        *utils.LazySortedList[[32]byte]
+	sortedAgentsMu sync.RWMutex
+	// sortedAgents holds a sorted view of the agents per sort mode.
+	sortedAgents        map[AgentSortMode]*utils.LazySortedList[[32]byte]
 	activeAgentsCount   uint64
 	totalActiveBalances map[[32]byte]*big.Int
 
+	windowedStats *windowedStats
+
 	lastIndexedBlock uint64
+	undoLog          *utils.UndoLog
 }
 
 var _ AgentBalanceIndexerDatabase = (*AgentBalanceIndexerDatabaseInMemory)(nil)
 
 // NewAgentBalanceIndexerDatabaseInMemory creates a new in-memory AgentBalanceIndexerDatabase.
 func NewAgentBalanceIndexerDatabaseInMemory(initialBlock uint64) *AgentBalanceIndexerDatabaseInMemory {
+	sortedAgents := make(map[AgentSortMode]*utils.LazySortedList[[32]byte], len(AgentSortModes))
+	for _, mode := range AgentSortModes {
+		sortedAgents[mode] = utils.NewLazySortedList[[32]byte]()
+	}
+
 	return &AgentBalanceIndexerDatabaseInMemory{
 		balances:            make(map[[32]byte]*AgentBalance),
 		lastIndexedBlock:    initialBlock,
-		sortedAgents:        utils.NewLazySortedList[[32]byte](),
+		sortedAgents:        sortedAgents,
 		activeAgentsCount:   0,
 		totalActiveBalances: make(map[[32]byte]*big.Int),
+		windowedStats:       newWindowedStats(),
+		undoLog:             utils.NewUndoLog(),
 	}
 }
 
@@ -80,15 +96,51 @@ func (db *AgentBalanceIndexerDatabaseInMemory) GetAgentBalance(addr [32]byte) (*
 	return balance, ok
 }
 
-// SetAgentBalance sets the agent balance.
+// SetAgentBalance sets the agent balance. The stored balance must not be
+// modified afterwards, so that the change can be reverted.
 func (db *AgentBalanceIndexerDatabaseInMemory) SetAgentBalance(addr [32]byte, balance *AgentBalance) {
-	if _, ok := db.balances[addr]; !ok {
-		db.sortedAgents.Add(addr)
+	prevBalance, existed := db.balances[addr]
+	db.undoLog.Push(func() {
+		if existed {
+			db.balances[addr] = prevBalance
+		} else {
+			delete(db.balances, addr)
+		}
+	})
+
+	if !existed {
+		db.addSortedAgents(addr)
 	}
 
 	db.balances[addr] = balance
 }
 
+// StorePromptPaidStats counts a prompt paid to an agent at timestamp, at the
+// agent's prompt price.
+func (db *AgentBalanceIndexerDatabaseInMemory) StorePromptPaidStats(addr [32]byte, timestamp uint64) {
+	balance, ok := db.balances[addr]
+	if !ok {
+		return
+	}
+
+	token := balance.Token.Bytes()
+	db.windowedStats.update(db.undoLog, addr, timestamp, func(stats *WindowedStats) {
+		amount, ok := stats.Amounts[token]
+		if !ok {
+			amount = big.NewInt(0)
+		}
+		stats.Amounts[token] = new(big.Int).Add(amount, balance.PromptPrice)
+		stats.PromptCount++
+	})
+}
+
+// StoreDrainedStats counts a drain of an agent at timestamp.
+func (db *AgentBalanceIndexerDatabaseInMemory) StoreDrainedStats(addr [32]byte, timestamp uint64) {
+	db.windowedStats.update(db.undoLog, addr, timestamp, func(stats *WindowedStats) {
+		stats.BreakCount++
+	})
+}
+
 // GetLastIndexedBlock returns the last indexed block.
 func (db *AgentBalanceIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 	return db.lastIndexedBlock
@@ -97,88 +149,72 @@ func (db *AgentBalanceIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 // SetLastIndexedBlock sets the last indexed block.
 func (db *AgentBalanceIndexerDatabaseInMemory) SetLastIndexedBlock(block uint64) {
 	db.lastIndexedBlock = block
+	db.undoLog.Prune(block - min(block, ReorgWindow))
 }
 
-// SortAgents sorts the agents by balance in descending order, using USD value
-func (db *AgentBalanceIndexerDatabaseInMemory) SortAgents(priceCache AgentBalanceIndexerPriceCache) {
-	db.sortedAgentsMu.Lock()
-	defer db.sortedAgentsMu.Unlock()
-
-	if db.sortedAgents.InnerLen() != len(db.balances) {
-		db.sortedAgents.Add(slices.Collect(maps.Keys(db.balances))...)
-	}
-
-	currentTime := uint64(time.Now().Unix())
-
-	db.sortedAgents.Sort(func(a, b [32]byte) int {
-		balA := db.balances[a]
-		balB := db.balances[b]
-
-		aFinalized := balA.EndTime < currentTime || balA.IsDrained
-		bFinalized := balB.EndTime < currentTime || balB.IsDrained
-
-		if aFinalized != bFinalized {
-			if aFinalized {
-				return 1
-			}
-
-			return -1
-		}
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *AgentBalanceIndexerDatabaseInMemory) SetCurrentBlock(block uint64) {
+	db.undoLog.SetBlock(block)
+}
 
-		amountA := balA.Amount
-		if aFinalized {
-			amountA = balA.DrainAmount
-		}
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *AgentBalanceIndexerDatabaseInMemory) SetCurrentEvent(ev *Event) {
+	db.SetCurrentBlock(ev.Block)
+}
 
-		amountB := balB.Amount
-		if bFinalized {
-			amountB = balB.DrainAmount
-		}
+// RevertToBlock reverts every change made after a given block. Agents must be
+// sorted again afterwards.
+func (db *AgentBalanceIndexerDatabaseInMemory) RevertToBlock(block uint64) {
+	db.undoLog.Revert(block)
+	if db.lastIndexedBlock > block {
+		db.lastIndexedBlock = block
+	}
 
-		lessAmount := -amountA.Cmp(amountB)
+	db.sortedAgentsMu.Lock()
+	defer db.sortedAgentsMu.Unlock()
 
-		if balA.Token != balB.Token {
-			rateA, ok := priceCache.GetTokenRate(balA.Token)
-			if !ok {
-				slog.Error("failed to get USD rate for agent", "token", balA.Token)
-				return 0
-			}
+	for _, sortedAgents := range db.sortedAgents {
+		sortedAgents.Clear()
+	}
+	db.addSortedAgents(slices.Collect(maps.Keys(db.balances))...)
+}
 
-			rateB, ok := priceCache.GetTokenRate(balB.Token)
-			if !ok {
-				slog.Error("failed to get USD rate for agent", "token", balB.Token)
-				return 0
-			}
+func (db *AgentBalanceIndexerDatabaseInMemory) addSortedAgents(addrs ...[32]byte) {
+	for _, sortedAgents := range db.sortedAgents {
+		sortedAgents.Add(addrs...)
+	}
+}
 
-			lessAmount = -amountA.Mul(amountA, rateA).Cmp(amountB.Mul(amountB, rateB))
-		}
+// SortAgents sorts the agents of every sort mode in descending order, using
+// USD values.
+func (db *AgentBalanceIndexerDatabaseInMemory) SortAgents(priceCache AgentBalanceIndexerPriceCache) {
+	db.sortedAgentsMu.Lock()
+	defer db.sortedAgentsMu.Unlock()
 
-		if lessAmount != 0 {
-			return lessAmount
-		}
+	currentTime := uint64(time.Now().Unix())
 
-		if balA.EndTime != balB.EndTime {
-			if balA.EndTime < balB.EndTime {
-				return -1
-			}
-			return 1
+	for mode, sortedAgents := range db.sortedAgents {
+		if sortedAgents.InnerLen() != len(db.balances) {
+			sortedAgents.Clear()
+			sortedAgents.Add(slices.Collect(maps.Keys(db.balances))...)
 		}
 
-		if balA.Id < balB.Id {
-			return -1
-		}
-		return 1
-	})
+		sortedAgents.Sort(agentSortCompare(mode, db.balances, currentTime, priceCache))
+	}
 
 	db.totalActiveBalances = make(map[[32]byte]*big.Int)
 
-	for idx, agent := range db.sortedAgents.Items() {
+	// every view holds the active agents first, so any of them can be used
+	// to count them
+	sortedAgents := db.sortedAgents[AgentSortPrizePool]
+	db.activeAgentsCount = uint64(sortedAgents.Len())
+
+	for idx, agent := range sortedAgents.Items() {
 		bal := db.balances[agent]
-		isFinalized := bal.EndTime < currentTime || bal.IsDrained
 
 		// since we know the finalized agents are at the end of the list, we can break early
 		// for both active agents count tracking and total active balances
-		if isFinalized {
+		if bal.isFinalized(currentTime) {
 			db.activeAgentsCount = uint64(idx)
 			break
 		}
@@ -193,8 +229,8 @@ func (db *AgentBalanceIndexerDatabaseInMemory) SortAgents(priceCache AgentBalanc
 	}
 }
 
-// GetLeaderboard returns the leaderboard for the given range.
-func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboard(start, end uint64, isActive *bool, _ AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error) {
+// GetLeaderboard returns the leaderboard sorted by mode for the given range.
+func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboard(mode AgentSortMode, start, end uint64, isActive *bool, _ AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error) {
 	db.sortedAgentsMu.RLock()
 	defer db.sortedAgentsMu.RUnlock()
 
@@ -202,12 +238,17 @@ func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboard(start, end uint64,
 		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
 	}
 
-	effectiveLen := uint64(db.sortedAgents.Len())
+	sortedAgents, ok := db.sortedAgents[mode]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort mode: %q", mode)
+	}
+
+	effectiveLen := uint64(sortedAgents.Len())
 	if isActive != nil {
 		if *isActive {
 			effectiveLen = db.activeAgentsCount
 		} else {
-			effectiveLen = uint64(db.sortedAgents.Len()) - db.activeAgentsCount
+			effectiveLen = uint64(sortedAgents.Len()) - db.activeAgentsCount
 
 			start += db.activeAgentsCount
 			end += db.activeAgentsCount
@@ -226,7 +267,7 @@ func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboard(start, end uint64,
 		end = effectiveLen
 	}
 
-	agents, ok := db.sortedAgents.GetRange(int(start), int(end))
+	agents, ok := sortedAgents.GetRange(int(start), int(end))
 	if !ok {
 		return nil, fmt.Errorf("failed to get range of agents")
 	}
@@ -240,9 +281,39 @@ func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboard(start, end uint64,
 	return leaderboard, nil
 }
 
+// GetWindowedLeaderboard returns the leaderboard over a window for the given
+// range. Agents are ranked by value of the prompts paid to them, break count
+// and prompt count within the window.
+func (db *AgentBalanceIndexerDatabaseInMemory) GetWindowedLeaderboard(window LeaderboardWindow, start, end uint64, isActive *bool, priceCache AgentBalanceIndexerPriceCache) (*AgentLeaderboardResponse, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	totals := db.windowedStats.aggregate(window)
+	if isActive != nil {
+		currentTime := uint64(time.Now().Unix())
+		for addr := range totals {
+			balance, ok := db.balances[addr]
+			if !ok || !balance.isFinalized(currentTime) != *isActive {
+				delete(totals, addr)
+			}
+		}
+	}
+
+	ranked := rankWindowedStats(totals, priceCache)
+	agents, stats := pageWindowedStats(ranked, totals, start, end)
+
+	return &AgentLeaderboardResponse{
+		Agents:     agents,
+		Stats:      stats,
+		AgentCount: uint64(len(ranked)),
+		LastBlock:  db.lastIndexedBlock,
+	}, nil
+}
+
 // GetLeaderboardCount returns the number of agents in the leaderboard.
 func (db *AgentBalanceIndexerDatabaseInMemory) GetLeaderboardCount() uint64 {
-	return uint64(db.sortedAgents.Len())
+	return uint64(db.sortedAgents[AgentSortPrizePool].Len())
 }
 
 // GetTotalAgentBalances returns the total balances of all agents in the database per token.
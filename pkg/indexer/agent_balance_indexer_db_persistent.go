@@ -0,0 +1,161 @@
+package indexer
+
+import (
+	"log/slog"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+const agentBalancesTable = "balances"
+
+// AgentBalanceIndexerDatabasePersistent is an AgentBalanceIndexerDatabase kept
+// in memory and persisted to a store on every indexed block.
+type AgentBalanceIndexerDatabasePersistent struct {
+	*AgentBalanceIndexerDatabaseInMemory
+
+	state persistentState
+	dirty map[[32]byte]struct{}
+}
+
+var _ AgentBalanceIndexerDatabase = (*AgentBalanceIndexerDatabasePersistent)(nil)
+
+// NewAgentBalanceIndexerDatabasePersistent creates an
+// AgentBalanceIndexerDatabase persisted to s, loading the state persisted by a
+// previous run if any.
+func NewAgentBalanceIndexerDatabasePersistent(s *store.Store, initialBlock uint64) (*AgentBalanceIndexerDatabasePersistent, error) {
+	db := &AgentBalanceIndexerDatabasePersistent{
+		AgentBalanceIndexerDatabaseInMemory: NewAgentBalanceIndexerDatabaseInMemory(initialBlock),
+		state:                               newPersistentState(s, "agent_balance_indexer"),
+		dirty:                               make(map[[32]byte]struct{}),
+	}
+	db.windowedStats.dirty = make(map[windowedStatsKey]struct{})
+
+	block, ok, err := db.state.load(func(tx *store.Tx) error {
+		if err := loadWindowedStats(tx, db.windowedStats); err != nil {
+			return err
+		}
+
+		return tx.ForEach(agentBalancesTable, func(key []byte, decode func(value any) error) error {
+			var balance AgentBalance
+			if err := decode(&balance); err != nil {
+				return err
+			}
+
+			db.balances[[32]byte(key)] = &balance
+			db.addSortedAgents([32]byte(key))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		db.lastIndexedBlock = block
+		db.undoLog = utils.NewUndoLog()
+		slog.Info("loaded persisted agent balances", "agents", len(db.balances), "last_indexed_block", block)
+	}
+
+	return db, nil
+}
+
+// SetAgentBalance sets the agent balance.
+func (db *AgentBalanceIndexerDatabasePersistent) SetAgentBalance(addr [32]byte, balance *AgentBalance) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[addr] = struct{}{}
+	db.AgentBalanceIndexerDatabaseInMemory.SetAgentBalance(addr, balance)
+}
+
+// StorePromptPaidStats counts a prompt paid to an agent at timestamp.
+func (db *AgentBalanceIndexerDatabasePersistent) StorePromptPaidStats(addr [32]byte, timestamp uint64) {
+	if db.state.skip() {
+		return
+	}
+
+	db.AgentBalanceIndexerDatabaseInMemory.StorePromptPaidStats(addr, timestamp)
+}
+
+// StoreDrainedStats counts a drain of an agent at timestamp.
+func (db *AgentBalanceIndexerDatabasePersistent) StoreDrainedStats(addr [32]byte, timestamp uint64) {
+	if db.state.skip() {
+		return
+	}
+
+	db.AgentBalanceIndexerDatabaseInMemory.StoreDrainedStats(addr, timestamp)
+}
+
+// SetLastIndexedBlock sets the last indexed block and persists the changes
+// made up to it.
+func (db *AgentBalanceIndexerDatabasePersistent) SetLastIndexedBlock(block uint64) {
+	if db.state.stale(block) {
+		return
+	}
+
+	err := db.state.commit(block, func(tx *store.Tx, rewrite bool) error {
+		if rewrite {
+			if err := tx.Clear(agentBalancesTable); err != nil {
+				return err
+			}
+
+			clear(db.dirty)
+			for addr := range db.balances {
+				db.dirty[addr] = struct{}{}
+			}
+		}
+
+		if err := persistWindowedStats(tx, db.windowedStats, rewrite); err != nil {
+			return err
+		}
+
+		for addr := range db.dirty {
+			balance, ok := db.balances[addr]
+			if !ok {
+				if err := tx.Delete(agentBalancesTable, addr[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(agentBalancesTable, addr[:], balance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// The changes are persisted again with the next block.
+		slog.Error("failed to persist agent balances", "block", block, "error", err)
+	} else {
+		clear(db.dirty)
+		clear(db.windowedStats.dirty)
+	}
+
+	db.AgentBalanceIndexerDatabaseInMemory.SetLastIndexedBlock(block)
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *AgentBalanceIndexerDatabasePersistent) SetCurrentBlock(block uint64) {
+	db.state.setCurrentBlock(block)
+	db.AgentBalanceIndexerDatabaseInMemory.SetCurrentBlock(block)
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *AgentBalanceIndexerDatabasePersistent) SetCurrentEvent(ev *Event) {
+	db.state.setCurrentEvent(ev)
+	db.AgentBalanceIndexerDatabaseInMemory.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. The state is
+// persisted again on the next indexed block.
+func (db *AgentBalanceIndexerDatabasePersistent) RevertToBlock(block uint64) {
+	if db.state.stale(block) {
+		slog.Warn("cannot revert persisted agent balances", "block", block, "persisted_block", db.state.resumeBlock)
+	}
+
+	db.state.revert(block)
+	db.AgentBalanceIndexerDatabaseInMemory.RevertToBlock(block)
+}
@@ -32,6 +32,8 @@ type AgentIndexer struct {
 	registryAddress *felt.Felt
 	client          starknet.ProviderWrapper
 
+	fetchUnknownAgents bool
+
 	eventCh      chan *EventSubscriptionData
 	eventSubID   int64
 	eventWatcher *EventWatcher
@@ -48,6 +50,10 @@ type AgentIndexerConfig struct {
 	Client          starknet.ProviderWrapper
 	InitialState    *AgentIndexerInitialState
 	EventWatcher    *EventWatcher
+	// FetchUnknownAgents makes GetOrFetchAgentInfo fetch agents missing from the
+	// database even if their block was already indexed. Required when indexing
+	// does not start at the registry deployment block.
+	FetchUnknownAgents bool
 }
 
 // NewAgentIndexer instantiates an AgentIndexer.
@@ -59,15 +65,21 @@ func NewAgentIndexer(cfg *AgentIndexerConfig) *AgentIndexer {
 	}
 
 	eventCh := make(chan *EventSubscriptionData, 1000)
-	eventSubID := cfg.EventWatcher.Subscribe(EventAgentRegistered, eventCh)
+	eventSubID := cfg.EventWatcher.SubscribeWithConfig(&EventSubscriberConfig{
+		Type: EventAgentRegistered,
+		Name: "agent_indexer",
+	}, eventCh)
 
 	return &AgentIndexer{
 		db:              cfg.InitialState.Db,
 		registryAddress: cfg.RegistryAddress,
 		client:          cfg.Client,
-		eventCh:         eventCh,
-		eventSubID:      eventSubID,
-		eventWatcher:    cfg.EventWatcher,
+
+		fetchUnknownAgents: cfg.FetchUnknownAgents,
+
+		eventCh:      eventCh,
+		eventSubID:   eventSubID,
+		eventWatcher: cfg.EventWatcher,
 	}
 }
 
@@ -88,7 +100,18 @@ func (i *AgentIndexer) run(ctx context.Context) error {
 		select {
 		case data := <-i.eventCh:
 			i.agentsMu.Lock()
+			if data.Rollback {
+				slog.Warn("reverting agents", "block", data.ToBlock)
+				if err := i.db.RevertToBlock(data.ToBlock); err != nil {
+					slog.Error("failed to revert agents", "error", err)
+				}
+				i.agentsMu.Unlock()
+				continue
+			}
 			for _, ev := range data.Events {
+				if err := i.db.SetCurrentEvent(ev); err != nil {
+					slog.Error("failed to set current event", "error", err)
+				}
 				i.onAgentRegistered(ev)
 			}
 			if err := i.db.SetLastIndexedBlock(data.ToBlock); err != nil {
@@ -195,7 +218,7 @@ func (i *AgentIndexer) GetOrFetchAgentInfo(ctx context.Context, addr *felt.Felt,
 
 	info, ok := i.db.GetAgentInfo(addr.Bytes())
 	if !ok {
-		if i.db.GetLastIndexedBlock() >= block {
+		if !i.fetchUnknownAgents && i.db.GetLastIndexedBlock() >= block {
 			return AgentInfo{}, fmt.Errorf("agent not found")
 		}
 
@@ -311,6 +334,18 @@ func (i *AgentIndexer) fetchAgentInfo(ctx context.Context, addr *felt.Felt) (Age
 		return AgentInfo{}, fmt.Errorf("get_end_time call failed: %w", snaccount.FormatRpcError(err))
 	}
 
+	var getModelResp []*felt.Felt
+	if err := i.client.Do(func(provider rpc.RpcProvider) error {
+		getModelResp, err = provider.Call(ctx, rpc.FunctionCall{
+			ContractAddress:    addr,
+			EntryPointSelector: getModelSelector,
+			Calldata:           []*felt.Felt{},
+		}, rpc.WithBlockTag("pending"))
+		return err
+	}); err != nil {
+		return AgentInfo{}, fmt.Errorf("get_model call failed: %w", snaccount.FormatRpcError(err))
+	}
+
 	promptPrice := snaccount.Uint256ToBigInt([2]*felt.Felt(getPromptPriceResp[0:2]))
 
 	return AgentInfo{
@@ -321,6 +356,7 @@ func (i *AgentIndexer) fetchAgentInfo(ctx context.Context, addr *felt.Felt) (Age
 		PromptPrice:  promptPrice,
 		TokenAddress: getTokenResp[0],
 		EndTime:      getEndTimeResp[0].Uint64(),
+		Model:        getModelResp[0],
 	}, nil
 }
 
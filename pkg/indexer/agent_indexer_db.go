@@ -1,6 +1,10 @@
 package indexer
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
 
 // AgentIndexerDatabaseReader is the database reader for an AgentIndexer.
 type AgentIndexerDatabaseReader interface {
@@ -15,6 +19,9 @@ type AgentIndexerDatabaseReader interface {
 type AgentIndexerDatabaseWriter interface {
 	SetAgentInfo(addr [32]byte, info AgentInfo) error
 	SetLastIndexedBlock(block uint64) error
+	SetCurrentBlock(block uint64) error
+	SetCurrentEvent(ev *Event) error
+	RevertToBlock(block uint64) error
 }
 
 // AgentIndexerDatabase is the database for an AgentIndexer.
@@ -29,6 +36,7 @@ type AgentIndexerDatabaseInMemory struct {
 	addressesByCreator map[[32]byte][][32]byte
 	addresses          [][32]byte
 	lastIndexedBlock   uint64
+	undoLog            *utils.UndoLog
 }
 
 var _ AgentIndexerDatabase = (*AgentIndexerDatabaseInMemory)(nil)
@@ -40,6 +48,7 @@ func NewAgentIndexerDatabaseInMemory(initialBlock uint64) *AgentIndexerDatabaseI
 		addressesByCreator: make(map[[32]byte][][32]byte),
 		addresses:          make([][32]byte, 0),
 		lastIndexedBlock:   initialBlock,
+		undoLog:            utils.NewUndoLog(),
 	}
 }
 
@@ -51,6 +60,20 @@ func (db *AgentIndexerDatabaseInMemory) GetAgentInfo(addr [32]byte) (AgentInfo,
 
 // SetAgentInfo sets an agent's info.
 func (db *AgentIndexerDatabaseInMemory) SetAgentInfo(addr [32]byte, info AgentInfo) error {
+	creator := info.Creator.Bytes()
+	prevInfo, existed := db.agents[addr]
+	addressesLen := len(db.addresses)
+	creatorAddressesLen := len(db.addressesByCreator[creator])
+	db.undoLog.Push(func() {
+		if existed {
+			db.agents[addr] = prevInfo
+		} else {
+			delete(db.agents, addr)
+		}
+		db.addresses = db.addresses[:addressesLen]
+		db.addressesByCreator[creator] = db.addressesByCreator[creator][:creatorAddressesLen]
+	})
+
 	db.agents[addr] = info
 	db.addresses = append(db.addresses, addr)
 	db.addressesByCreator[info.Creator.Bytes()] = append(db.addressesByCreator[info.Creator.Bytes()], addr)
@@ -94,5 +117,26 @@ func (db *AgentIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 // SetLastIndexedBlock sets the last indexed block.
 func (db *AgentIndexerDatabaseInMemory) SetLastIndexedBlock(block uint64) error {
 	db.lastIndexedBlock = block
+	db.undoLog.Prune(block - min(block, ReorgWindow))
+	return nil
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *AgentIndexerDatabaseInMemory) SetCurrentBlock(block uint64) error {
+	db.undoLog.SetBlock(block)
+	return nil
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *AgentIndexerDatabaseInMemory) SetCurrentEvent(ev *Event) error {
+	return db.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block.
+func (db *AgentIndexerDatabaseInMemory) RevertToBlock(block uint64) error {
+	db.undoLog.Revert(block)
+	if db.lastIndexedBlock > block {
+		db.lastIndexedBlock = block
+	}
 	return nil
 }
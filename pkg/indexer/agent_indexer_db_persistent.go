@@ -0,0 +1,144 @@
+package indexer
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+const agentsTable = "agents"
+
+// AgentIndexerDatabasePersistent is an AgentIndexerDatabase kept in memory and
+// persisted to a store on every indexed block.
+type AgentIndexerDatabasePersistent struct {
+	*AgentIndexerDatabaseInMemory
+
+	state persistentState
+	dirty map[[32]byte]uint64
+}
+
+type agentRecord struct {
+	Index uint64
+	Info  AgentInfo
+}
+
+var _ AgentIndexerDatabase = (*AgentIndexerDatabasePersistent)(nil)
+
+// NewAgentIndexerDatabasePersistent creates an AgentIndexerDatabase persisted
+// to s, loading the state persisted by a previous run if any.
+func NewAgentIndexerDatabasePersistent(s *store.Store, initialBlock uint64) (*AgentIndexerDatabasePersistent, error) {
+	db := &AgentIndexerDatabasePersistent{
+		AgentIndexerDatabaseInMemory: NewAgentIndexerDatabaseInMemory(initialBlock),
+		state:                        newPersistentState(s, "agent_indexer"),
+		dirty:                        make(map[[32]byte]uint64),
+	}
+
+	block, ok, err := db.state.load(func(tx *store.Tx) error {
+		records := make([]agentRecord, 0)
+		err := tx.ForEach(agentsTable, func(_ []byte, decode func(value any) error) error {
+			var record agentRecord
+			if err := decode(&record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		slices.SortFunc(records, func(a, b agentRecord) int {
+			return cmp.Compare(a.Index, b.Index)
+		})
+		for _, record := range records {
+			if err := db.AgentIndexerDatabaseInMemory.SetAgentInfo(record.Info.Address.Bytes(), record.Info); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		db.lastIndexedBlock = block
+		db.undoLog = utils.NewUndoLog()
+		slog.Info("loaded persisted agents", "agents", len(db.agents), "last_indexed_block", block)
+	}
+
+	return db, nil
+}
+
+// SetAgentInfo sets an agent's info.
+func (db *AgentIndexerDatabasePersistent) SetAgentInfo(addr [32]byte, info AgentInfo) error {
+	if db.state.skip() {
+		return nil
+	}
+
+	db.dirty[addr] = uint64(len(db.addresses))
+	return db.AgentIndexerDatabaseInMemory.SetAgentInfo(addr, info)
+}
+
+// SetLastIndexedBlock sets the last indexed block and persists the changes
+// made up to it.
+func (db *AgentIndexerDatabasePersistent) SetLastIndexedBlock(block uint64) error {
+	if db.state.stale(block) {
+		return nil
+	}
+
+	err := db.state.commit(block, func(tx *store.Tx, rewrite bool) error {
+		if rewrite {
+			if err := tx.Clear(agentsTable); err != nil {
+				return err
+			}
+
+			clear(db.dirty)
+			for idx, addr := range db.addresses {
+				db.dirty[addr] = uint64(idx)
+			}
+		}
+
+		for addr, idx := range db.dirty {
+			if err := tx.Put(agentsTable, addr[:], &agentRecord{Index: idx, Info: db.agents[addr]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		clear(db.dirty)
+	}
+
+	// The changes that failed to persist are persisted with the next block.
+	if innerErr := db.AgentIndexerDatabaseInMemory.SetLastIndexedBlock(block); innerErr != nil {
+		return innerErr
+	}
+	return err
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *AgentIndexerDatabasePersistent) SetCurrentBlock(block uint64) error {
+	db.state.setCurrentBlock(block)
+	return db.AgentIndexerDatabaseInMemory.SetCurrentBlock(block)
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *AgentIndexerDatabasePersistent) SetCurrentEvent(ev *Event) error {
+	db.state.setCurrentEvent(ev)
+	return db.AgentIndexerDatabaseInMemory.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. The state is
+// persisted again on the next indexed block.
+func (db *AgentIndexerDatabasePersistent) RevertToBlock(block uint64) error {
+	if db.state.stale(block) {
+		slog.Warn("cannot revert persisted agents", "block", block, "persisted_block", db.state.resumeBlock)
+	}
+
+	db.state.revert(block)
+	return db.AgentIndexerDatabaseInMemory.RevertToBlock(block)
+}
@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"cmp"
+	"log/slog"
+	"math/big"
+)
+
+// AgentSortMode is the order of the agent leaderboard.
+type AgentSortMode string
+
+const (
+	// AgentSortPrizePool orders agents by USD value of their prize pool, or of
+	// the amount drained from them once finalized.
+	AgentSortPrizePool AgentSortMode = "prize_pool"
+	// AgentSortUptime orders agents by time standing since registration.
+	AgentSortUptime AgentSortMode = "uptime"
+	// AgentSortAttempts orders agents by break attempts survived.
+	AgentSortAttempts AgentSortMode = "attempts"
+	// AgentSortFees orders agents by USD value of the fees collected.
+	AgentSortFees AgentSortMode = "fees"
+)
+
+// AgentSortModes are the supported agent leaderboard orders.
+var AgentSortModes = []AgentSortMode{AgentSortPrizePool, AgentSortUptime, AgentSortAttempts, AgentSortFees}
+
+// IsValid returns true if m is a supported sort mode.
+func (m AgentSortMode) IsValid() bool {
+	for _, mode := range AgentSortModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// isFinalized returns true if the agent can no longer be prompted.
+func (b *AgentBalance) isFinalized(currentTime uint64) bool {
+	return b.EndTime < currentTime || b.IsDrained
+}
+
+// Uptime returns the time in seconds the agent has been standing since its
+// registration, up to its drain or end time once finalized.
+func (b *AgentBalance) Uptime(currentTime uint64) uint64 {
+	if b.RegisteredAt == 0 {
+		return 0
+	}
+
+	end := currentTime
+	if b.IsDrained && b.DrainedAt != 0 {
+		end = b.DrainedAt
+	} else if b.EndTime < currentTime {
+		end = b.EndTime
+	}
+
+	if end < b.RegisteredAt {
+		return 0
+	}
+	return end - b.RegisteredAt
+}
+
+// agentSortCompare returns the comparison function ordering agents for a sort
+// mode. Active agents always come before finalized ones, so that every view
+// can be paginated by activity the same way.
+func agentSortCompare(mode AgentSortMode, balances map[[32]byte]*AgentBalance, currentTime uint64, priceCache AgentBalanceIndexerPriceCache) func(a, b [32]byte) int {
+	return func(a, b [32]byte) int {
+		balA := balances[a]
+		balB := balances[b]
+
+		aFinalized := balA.isFinalized(currentTime)
+		bFinalized := balB.isFinalized(currentTime)
+
+		if aFinalized != bFinalized {
+			if aFinalized {
+				return 1
+			}
+
+			return -1
+		}
+
+		var res int
+		switch mode {
+		case AgentSortUptime:
+			res = cmp.Compare(balB.Uptime(currentTime), balA.Uptime(currentTime))
+		case AgentSortAttempts:
+			res = cmp.Compare(balB.BreakAttempts, balA.BreakAttempts)
+		case AgentSortFees:
+			res = compareUSDValue(balB, balB.FeesCollected, balA, balA.FeesCollected, priceCache)
+		default:
+			amountA := balA.Amount
+			if aFinalized {
+				amountA = balA.DrainAmount
+			}
+
+			amountB := balB.Amount
+			if bFinalized {
+				amountB = balB.DrainAmount
+			}
+
+			res = compareUSDValue(balB, amountB, balA, amountA, priceCache)
+			if res == 0 {
+				res = cmp.Compare(balA.EndTime, balB.EndTime)
+			}
+		}
+
+		if res != 0 {
+			return res
+		}
+
+		return cmp.Compare(balA.Id, balB.Id)
+	}
+}
+
+// compareUSDValue compares the USD values of amounts held in the tokens of
+// the agents a and b. Nil amounts are treated as zero.
+func compareUSDValue(a *AgentBalance, amountA *big.Int, b *AgentBalance, amountB *big.Int, priceCache AgentBalanceIndexerPriceCache) int {
+	if amountA == nil {
+		amountA = big.NewInt(0)
+	}
+	if amountB == nil {
+		amountB = big.NewInt(0)
+	}
+
+	if a.Token.Equal(b.Token) {
+		return amountA.Cmp(amountB)
+	}
+
+	valueA, ok := priceCache.GetTokenUSDValue(a.Token, amountA)
+	if !ok {
+		slog.Error("failed to get USD value for agent", "token", a.Token)
+		return 0
+	}
+
+	valueB, ok := priceCache.GetTokenUSDValue(b.Token, amountB)
+	if !ok {
+		slog.Error("failed to get USD value for agent", "token", b.Token)
+		return 0
+	}
+
+	return valueA.Cmp(valueB)
+}
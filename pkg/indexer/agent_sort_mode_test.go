@@ -0,0 +1,68 @@
+package indexer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+func TestAgentSortModes(t *testing.T) {
+	token := new(felt.Felt).SetUint64(1)
+	agents := [][32]byte{
+		new(felt.Felt).SetUint64(10).Bytes(),
+		new(felt.Felt).SetUint64(11).Bytes(),
+		new(felt.Felt).SetUint64(12).Bytes(),
+	}
+
+	// Each agent leads a different mode, the last one is drained
+	balances := []*indexer.AgentBalance{
+		{Amount: big.NewInt(300), RegisteredAt: 200, BreakAttempts: 1, FeesCollected: big.NewInt(10)},
+		{Amount: big.NewInt(100), RegisteredAt: 100, BreakAttempts: 2, FeesCollected: big.NewInt(30)},
+		{Amount: big.NewInt(0), RegisteredAt: 50, BreakAttempts: 5, FeesCollected: big.NewInt(50), IsDrained: true, DrainedAt: 60, DrainAmount: big.NewInt(500)},
+	}
+
+	db := indexer.NewAgentBalanceIndexerDatabaseInMemory(0)
+	db.SetCurrentBlock(1)
+	for idx, balance := range balances {
+		balance.Id = idx
+		balance.Token = token
+		balance.EndTime = 1 << 40
+		db.SetAgentBalance(agents[idx], balance)
+	}
+	db.SetLastIndexedBlock(1)
+	db.SortAgents(fixedPriceCache{})
+
+	expected := map[indexer.AgentSortMode][][32]byte{
+		indexer.AgentSortPrizePool: {agents[0], agents[1], agents[2]},
+		indexer.AgentSortUptime:    {agents[1], agents[0], agents[2]},
+		indexer.AgentSortAttempts:  {agents[1], agents[0], agents[2]},
+		indexer.AgentSortFees:      {agents[1], agents[0], agents[2]},
+	}
+	for mode, order := range expected {
+		leaderboard, err := db.GetLeaderboard(mode, 0, 10, nil, fixedPriceCache{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for idx := range order {
+			if leaderboard.Agents[idx] != order[idx] {
+				t.Errorf("unexpected order for %s at %d", mode, idx)
+			}
+		}
+	}
+
+	active := true
+	leaderboard, err := db.GetLeaderboard(indexer.AgentSortAttempts, 0, 10, &active, fixedPriceCache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderboard.AgentCount != 2 {
+		t.Errorf("expected 2 active agents, got %d", leaderboard.AgentCount)
+	}
+
+	if _, err := db.GetLeaderboard("unknown", 0, 10, nil, fixedPriceCache{}); err == nil {
+		t.Error("expected an error for an unknown sort mode")
+	}
+}
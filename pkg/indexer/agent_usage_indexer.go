@@ -3,6 +3,7 @@ package indexer
 import (
 	"context"
 	"log/slog"
+	"math/big"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
@@ -35,9 +36,14 @@ type AgentUsageIndexer struct {
 
 	db              AgentUsageIndexerDatabase
 	registryAddress *felt.Felt
+	priceCache      AgentBalanceIndexerPriceCache
 
 	maxPrompts uint64
 
+	// receipts fetches the replies of consumed prompts, if configured.
+	receipts      *receiptClient
+	replyAttempts map[PromptRef]*fetchAttempt
+
 	eventCh      chan *EventSubscriptionData
 	eventSubID   int64
 	eventWatcher *EventWatcher
@@ -58,6 +64,10 @@ type AgentUsageIndexerConfig struct {
 	MaxPrompts      uint64
 	InitialState    *AgentUsageIndexerInitialState
 	EventWatcher    *EventWatcher
+	PriceCache      AgentBalanceIndexerPriceCache
+	// ReceiptsURL is the base URL of the agent API serving the receipts that
+	// hold its replies. Replies are not indexed if empty.
+	ReceiptsURL string
 }
 
 func NewAgentUsageIndexer(config *AgentUsageIndexerConfig) *AgentUsageIndexer {
@@ -68,13 +78,24 @@ func NewAgentUsageIndexer(config *AgentUsageIndexerConfig) *AgentUsageIndexer {
 	}
 
 	eventCh := make(chan *EventSubscriptionData, 1000)
-	eventSubID := config.EventWatcher.Subscribe(EventAgentRegistered|EventPromptConsumed|EventPromptPaid|EventWithdrawn, eventCh)
+	eventSubID := config.EventWatcher.SubscribeWithConfig(&EventSubscriberConfig{
+		Type: EventAgentRegistered | EventPromptConsumed | EventPromptPaid | EventPromptReclaimed | EventWithdrawn | EventDrained,
+		Name: "agent_usage_indexer",
+	}, eventCh)
+
+	var receipts *receiptClient
+	if config.ReceiptsURL != "" {
+		receipts = newReceiptClient(config.ReceiptsURL)
+	}
 
 	return &AgentUsageIndexer{
 		client:          config.Client,
 		registryAddress: config.RegistryAddress,
 		db:              config.InitialState.Db,
+		priceCache:      config.PriceCache,
 		maxPrompts:      config.MaxPrompts,
+		receipts:        receipts,
+		replyAttempts:   make(map[PromptRef]*fetchAttempt),
 		eventCh:         eventCh,
 		eventSubID:      eventSubID,
 		eventWatcher:    config.EventWatcher,
@@ -86,6 +107,11 @@ func (i *AgentUsageIndexer) Run(ctx context.Context) error {
 	g.Go(func() error {
 		return i.run(ctx)
 	})
+	if i.receipts != nil {
+		g.Go(func() error {
+			return i.runReplyFetcher(ctx)
+		})
+	}
 	return g.Wait()
 }
 
@@ -97,18 +123,37 @@ func (i *AgentUsageIndexer) run(ctx context.Context) error {
 	for {
 		select {
 		case data := <-i.eventCh:
+			var blockTimestamps map[uint64]uint64
+			if !data.Rollback {
+				blockTimestamps = i.fetchPendingTimestamps(ctx, data.ToBlock)
+			}
+
 			i.mu.Lock()
+			if data.Rollback {
+				slog.Warn("reverting agent usage", "block", data.ToBlock)
+				i.db.RevertToBlock(data.ToBlock)
+				i.mu.Unlock()
+				continue
+			}
 			for _, ev := range data.Events {
+				i.db.SetCurrentEvent(ev)
 				if ev.Type == EventAgentRegistered {
 					i.onAgentRegisteredEvent(ev)
 				} else if ev.Type == EventPromptConsumed {
 					i.onPromptConsumedEvent(ev)
 				} else if ev.Type == EventPromptPaid {
 					i.onPromptPaidEvent(ev)
+				} else if ev.Type == EventPromptReclaimed {
+					i.onPromptReclaimedEvent(ev)
 				} else if ev.Type == EventWithdrawn {
 					i.onWithdrawnEvent(ev)
+				} else if ev.Type == EventDrained {
+					i.onDrainedEvent(ev)
 				}
 			}
+			for block, timestamp := range blockTimestamps {
+				i.db.SetBlockTimestamp(block, timestamp)
+			}
 			i.db.SetLastIndexedBlock(data.ToBlock)
 			i.mu.Unlock()
 		case <-ctx.Done():
@@ -117,6 +162,27 @@ func (i *AgentUsageIndexer) run(ctx context.Context) error {
 	}
 }
 
+// fetchPendingTimestamps fetches the timestamps of the blocks up to toBlock
+// holding prompts seen in the pending block, which get the timestamp of their
+// block once it is indexed. Failed blocks are retried with the next batch.
+func (i *AgentUsageIndexer) fetchPendingTimestamps(ctx context.Context, toBlock uint64) map[uint64]uint64 {
+	i.mu.RLock()
+	blocks := i.db.GetPendingTimestampBlocks(toBlock)
+	i.mu.RUnlock()
+
+	timestamps := make(map[uint64]uint64, len(blocks))
+	for _, block := range blocks {
+		timestamp, err := i.eventWatcher.BlockTimestamp(ctx, block)
+		if err != nil {
+			slog.Warn("failed to get block timestamp", "block", block, "error", err)
+			continue
+		}
+		timestamps[block] = timestamp
+	}
+
+	return timestamps
+}
+
 func (i *AgentUsageIndexer) onAgentRegisteredEvent(ev *Event) {
 	if ev.Raw.FromAddress.Cmp(i.registryAddress) != 0 {
 		slog.Warn("agent registered event from non-registry address", "agent", ev.Raw.FromAddress)
@@ -128,7 +194,27 @@ func (i *AgentUsageIndexer) onAgentRegisteredEvent(ev *Event) {
 		return
 	}
 
-	i.db.StoreAgent(agentRegisteredEvent.Agent.Bytes())
+	i.db.StoreAgentRegisteredData(agentRegisteredEvent, i.promptEventInfo(ev))
+}
+
+func (i *AgentUsageIndexer) onDrainedEvent(ev *Event) {
+	drainedEvent, ok := ev.ToDrainedEvent()
+	if !ok {
+		return
+	}
+
+	if !i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
+		return
+	}
+
+	// The value is kept at the rate of the drain, which the hall of fame
+	// reports rather than today's.
+	var amountUSD *big.Int
+	if token, ok := i.db.GetAgentToken(ev.Raw.FromAddress.Bytes()); ok {
+		amountUSD, _ = i.priceCache.GetTokenUSDValue(token, drainedEvent.Amount)
+	}
+
+	i.db.StoreDrainedData(ev.Raw.FromAddress.Bytes(), drainedEvent, amountUSD, i.promptEventInfo(ev))
 }
 
 func (i *AgentUsageIndexer) onPromptConsumedEvent(ev *Event) {
@@ -142,7 +228,7 @@ func (i *AgentUsageIndexer) onPromptConsumedEvent(ev *Event) {
 		return
 	}
 
-	i.db.StorePromptConsumedData(ev.Raw.FromAddress.Bytes(), promptConsumedEvent)
+	i.db.StorePromptConsumedData(ev.Raw.FromAddress.Bytes(), promptConsumedEvent, i.promptEventInfo(ev))
 }
 
 func (i *AgentUsageIndexer) onPromptPaidEvent(ev *Event) {
@@ -156,7 +242,30 @@ func (i *AgentUsageIndexer) onPromptPaidEvent(ev *Event) {
 		return
 	}
 
-	i.db.StorePromptPaidData(ev.Raw.FromAddress.Bytes(), promptPaidEvent)
+	i.db.StorePromptPaidData(ev.Raw.FromAddress.Bytes(), promptPaidEvent, i.promptEventInfo(ev))
+}
+
+func (i *AgentUsageIndexer) onPromptReclaimedEvent(ev *Event) {
+	promptReclaimedEvent, ok := ev.ToPromptReclaimedEvent()
+	if !ok {
+		return
+	}
+
+	if !i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
+		slog.Debug("ignoring prompt reclaimed event for unregistered agent", "agent", ev.Raw.FromAddress)
+		return
+	}
+
+	i.db.StorePromptReclaimedData(ev.Raw.FromAddress.Bytes(), promptReclaimedEvent, i.promptEventInfo(ev))
+}
+
+func (i *AgentUsageIndexer) promptEventInfo(ev *Event) PromptEventInfo {
+	return PromptEventInfo{
+		Block:     ev.Block,
+		Timestamp: ev.Timestamp,
+		TxHash:    ev.Raw.TransactionHash,
+		Pending:   ev.Raw.BlockHash == nil,
+	}
 }
 
 func (i *AgentUsageIndexer) onWithdrawnEvent(ev *Event) {
@@ -194,6 +303,65 @@ func (i *AgentUsageIndexer) GetTotalUsage() *AgentUsageIndexerTotalUsage {
 	return i.db.GetTotalUsage()
 }
 
+// GetPromptHistory returns a page of an agent's prompt history, newest first.
+func (i *AgentUsageIndexer) GetPromptHistory(addr *felt.Felt, filter *PromptHistoryFilter) (*PromptHistoryPage, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	prompts, nextCursor, ok := i.db.GetPromptHistory(addr.Bytes(), filter)
+	if !ok {
+		return nil, false
+	}
+
+	return &PromptHistoryPage{
+		Prompts:    prompts,
+		NextCursor: nextCursor,
+		LastBlock:  i.db.GetLastIndexedBlock(),
+	}, true
+}
+
+// HallOfFamePage is a page of the hall of fame.
+type HallOfFamePage struct {
+	Entries   []*HallOfFameEntry
+	Total     uint64
+	LastBlock uint64
+}
+
+// GetHallOfFame returns start:end drains of the hall of fame in the given
+// order.
+func (i *AgentUsageIndexer) GetHallOfFame(order HallOfFameSort, start, end uint64) *HallOfFamePage {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entries, total := i.db.GetHallOfFame(order, start, end, i.priceCache)
+	return &HallOfFamePage{
+		Entries:   entries,
+		Total:     total,
+		LastBlock: i.db.GetLastIndexedBlock(),
+	}
+}
+
+// SearchPage is a page of search results.
+type SearchPage struct {
+	Results   []*SearchResult
+	Total     uint64
+	LastBlock uint64
+}
+
+// Search returns start:end results of a full-text search over agent names,
+// system prompts and paid prompts, by descending relevance.
+func (i *AgentUsageIndexer) Search(query string, scope SearchScope, start, end uint64) *SearchPage {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	results, total := i.db.Search(query, scope, start, end)
+	return &SearchPage{
+		Results:   results,
+		Total:     total,
+		LastBlock: i.db.GetLastIndexedBlock(),
+	}
+}
+
 func (i *AgentUsageIndexer) GetLastIndexedBlock() uint64 {
 	return i.db.GetLastIndexedBlock()
 }
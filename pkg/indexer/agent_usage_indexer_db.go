@@ -5,26 +5,44 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"log/slog"
+	"math/big"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/hashicorp/golang-lru/v2/expirable"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
 )
 
 type AgentUsageIndexerDatabaseReader interface {
 	GetAgentUsage(addr [32]byte) (*AgentUsage, bool)
 	GetAgentExists(addr [32]byte) bool
+	GetAgentToken(addr [32]byte) (*felt.Felt, bool)
 	GetLastIndexedBlock() uint64
 	GetTotalUsage() *AgentUsageIndexerTotalUsage
+	GetPromptHistory(addr [32]byte, filter *PromptHistoryFilter) ([]*PromptHistoryEntry, uint64, bool)
+	GetPendingTimestampBlocks(toBlock uint64) []uint64
+	GetPromptsMissingReply() map[PromptRef]*PromptHistoryEntry
+	GetHallOfFame(order HallOfFameSort, start, end uint64, priceCache AgentBalanceIndexerPriceCache) ([]*HallOfFameEntry, uint64)
+	Search(query string, scope SearchScope, start, end uint64) ([]*SearchResult, uint64)
 }
 
 type AgentUsageIndexerDatabaseWriter interface {
 	StoreAgent(addr [32]byte)
-	StorePromptPaidData(addr [32]byte, ev *PromptPaidEvent)
-	StorePromptConsumedData(addr [32]byte, ev *PromptConsumedEvent)
+	StoreAgentRegisteredData(ev *AgentRegisteredEvent, info PromptEventInfo)
+	StorePromptPaidData(addr [32]byte, ev *PromptPaidEvent, info PromptEventInfo)
+	StorePromptConsumedData(addr [32]byte, ev *PromptConsumedEvent, info PromptEventInfo)
+	StorePromptReclaimedData(addr [32]byte, ev *PromptReclaimedEvent, info PromptEventInfo)
 	StoreWithdrawnData(addr [32]byte, ev *WithdrawnEvent)
+	StoreDrainedData(addr [32]byte, ev *DrainedEvent, amountUSD *big.Int, info PromptEventInfo)
+	SetBlockTimestamp(block, timestamp uint64)
+	SetPromptReply(ref PromptRef, reply string)
 	SetLastIndexedBlock(block uint64)
+	SetCurrentBlock(block uint64)
+	SetCurrentEvent(ev *Event)
+	RevertToBlock(block uint64)
 }
 
 type AgentUsageIndexerDatabase interface {
@@ -40,8 +58,21 @@ type AgentUsageIndexerDatabaseInMemory struct {
 		AgentUsageIndexerDatabaseInMemoryPromptCacheKey,
 		AgentUsageIndexerDatabaseInMemoryPromptCacheData,
 	]
+	// history holds every prompt paid to an agent, sorted by prompt ID.
+	history map[[32]byte][]*PromptHistoryEntry
+	// pendingTimestamps holds the history entries of each block with a
+	// provisional timestamp, and missingReplies those whose reply was not
+	// fetched yet. Both are checked against the entries when used.
+	pendingTimestamps map[uint64]map[PromptRef]struct{}
+	missingReplies    map[PromptRef]struct{}
+	registrations     map[[32]byte]*agentRegistration
+	// hallOfFame holds every drain, in drain order.
+	hallOfFame []*HallOfFameEntry
+	// search indexes agent names, system prompts and paid prompts.
+	search           *searchIndex
 	totalUsage       *AgentUsageIndexerTotalUsage
 	lastIndexedBlock uint64
+	undoLog          *utils.UndoLog
 }
 
 type AgentUsageIndexerDatabaseInMemoryPromptCacheKey [32]byte
@@ -69,8 +100,14 @@ func NewAgentUsageIndexerDatabaseInMemory(initialBlock, maxPrompts uint64) *Agen
 			nil,
 			agentUsageIndexerPromptCacheTTL,
 		),
-		totalUsage:       &AgentUsageIndexerTotalUsage{},
-		lastIndexedBlock: initialBlock,
+		history:           make(map[[32]byte][]*PromptHistoryEntry),
+		pendingTimestamps: make(map[uint64]map[PromptRef]struct{}),
+		missingReplies:    make(map[PromptRef]struct{}),
+		registrations:     make(map[[32]byte]*agentRegistration),
+		search:            newSearchIndex(),
+		totalUsage:        &AgentUsageIndexerTotalUsage{},
+		lastIndexedBlock:  initialBlock,
+		undoLog:           utils.NewUndoLog(),
 	}
 }
 
@@ -93,40 +130,110 @@ func (db *AgentUsageIndexerDatabaseInMemory) GetAgentExists(addr [32]byte) bool
 	return ok
 }
 
+// GetAgentToken returns the token an agent was registered with.
+func (db *AgentUsageIndexerDatabaseInMemory) GetAgentToken(addr [32]byte) (*felt.Felt, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	registration, ok := db.registrations[addr]
+	if !ok {
+		return nil, false
+	}
+	return registration.Token, true
+}
+
 func (db *AgentUsageIndexerDatabaseInMemory) StoreAgent(addr [32]byte) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUsageUndo(addr)
+
 	db.totalUsage.TotalRegisteredAgents++
 
 	usage := db.getOrCreateAgentUsage(addr)
 	db.usages[addr] = usage
 }
 
-func (db *AgentUsageIndexerDatabaseInMemory) StorePromptPaidData(addr [32]byte, promptPaidEvent *PromptPaidEvent) {
+// StoreAgentRegisteredData stores a registered agent, along with what the
+// hall of fame keeps of its registration.
+func (db *AgentUsageIndexerDatabaseInMemory) StoreAgentRegisteredData(agentRegisteredEvent *AgentRegisteredEvent, info PromptEventInfo) {
+	addr := agentRegisteredEvent.Agent.Bytes()
+	db.StoreAgent(addr)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	prevRegistration, existed := db.registrations[addr]
+	db.undoLog.Push(func() {
+		if existed {
+			db.registrations[addr] = prevRegistration
+		} else {
+			delete(db.registrations, addr)
+		}
+	})
+
+	db.registrations[addr] = &agentRegistration{
+		Name:         agentRegisteredEvent.Name,
+		SystemPrompt: agentRegisteredEvent.SystemPrompt,
+		Token:        agentRegisteredEvent.TokenAddress,
+		Timestamp:    info.Timestamp,
+	}
+
+	db.search.set(db.undoLog, searchDocumentKey{agent: addr, field: SearchFieldName}, agentRegisteredEvent.Name)
+	db.search.set(db.undoLog, searchDocumentKey{agent: addr, field: SearchFieldSystemPrompt}, agentRegisteredEvent.SystemPrompt)
+}
+
+func (db *AgentUsageIndexerDatabaseInMemory) StorePromptPaidData(addr [32]byte, promptPaidEvent *PromptPaidEvent, info PromptEventInfo) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUsageUndo(addr)
+
 	db.totalUsage.TotalAttempts++
 
 	usage := db.getOrCreateAgentUsage(addr)
 	usage.BreakAttempts++
 	db.usages[addr] = usage
 
+	promptCacheKey := db.promptCacheKey(addr, promptPaidEvent.PromptID)
+	db.undoLog.Push(func() {
+		db.promptCache.Remove(promptCacheKey)
+	})
+
 	db.promptCache.Add(
-		db.promptCacheKey(addr, promptPaidEvent.PromptID),
+		promptCacheKey,
 		AgentUsageIndexerDatabaseInMemoryPromptCacheData{
 			TweetID: promptPaidEvent.TweetID,
 			Prompt:  promptPaidEvent.Prompt,
 			User:    promptPaidEvent.User,
 		},
 	)
+
+	db.setPromptHistoryEntry(addr, &PromptHistoryEntry{
+		PromptID:         promptPaidEvent.PromptID,
+		TweetID:          promptPaidEvent.TweetID,
+		Prompt:           promptPaidEvent.Prompt,
+		User:             promptPaidEvent.User,
+		Outcome:          PromptOutcomePending,
+		Block:            info.Block,
+		Timestamp:        info.Timestamp,
+		TxHash:           info.TxHash,
+		PendingTimestamp: info.Pending,
+	})
+
+	db.search.set(db.undoLog, searchDocumentKey{
+		agent:    addr,
+		field:    SearchFieldPrompt,
+		promptID: promptPaidEvent.PromptID,
+	}, promptPaidEvent.Prompt)
 }
 
-func (db *AgentUsageIndexerDatabaseInMemory) StorePromptConsumedData(addr [32]byte, promptConsumedEvent *PromptConsumedEvent) {
+func (db *AgentUsageIndexerDatabaseInMemory) StorePromptConsumedData(addr [32]byte, promptConsumedEvent *PromptConsumedEvent, info PromptEventInfo) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUsageUndo(addr)
+
 	usage := db.getOrCreateAgentUsage(addr)
 
 	var succeeded bool
@@ -147,6 +254,9 @@ func (db *AgentUsageIndexerDatabaseInMemory) StorePromptConsumedData(addr [32]by
 		promptCacheData = AgentUsageIndexerDatabaseInMemoryPromptCacheData{}
 	} else {
 		db.promptCache.Remove(promptCacheKey)
+		db.undoLog.Push(func() {
+			db.promptCache.Add(promptCacheKey, promptCacheData)
+		})
 	}
 
 	prompt := &AgentUsagePrompt{
@@ -171,18 +281,134 @@ func (db *AgentUsageIndexerDatabaseInMemory) StorePromptConsumedData(addr [32]by
 	}
 
 	db.usages[addr] = usage
+
+	outcome := PromptOutcomeFailed
+	if succeeded {
+		outcome = PromptOutcomeDrained
+	}
+	db.finishPromptHistoryEntry(addr, promptConsumedEvent.PromptID, outcome, drainAddress, info)
+}
+
+func (db *AgentUsageIndexerDatabaseInMemory) StorePromptReclaimedData(addr [32]byte, promptReclaimedEvent *PromptReclaimedEvent, info PromptEventInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.finishPromptHistoryEntry(addr, promptReclaimedEvent.PromptID, PromptOutcomeReclaimed, nil, info)
 }
 
 func (db *AgentUsageIndexerDatabaseInMemory) StoreWithdrawnData(addr [32]byte, withdrawnEvent *WithdrawnEvent) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUsageUndo(addr)
+
 	usage := db.getOrCreateAgentUsage(addr)
 
 	usage.IsWithdrawn = true
 	db.usages[addr] = usage
 }
 
+// StoreDrainedData adds a drain to the hall of fame, along with the USD value
+// of the amount drained.
+func (db *AgentUsageIndexerDatabaseInMemory) StoreDrainedData(addr [32]byte, drainedEvent *DrainedEvent, amountUSD *big.Int, info PromptEventInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	entry := &HallOfFameEntry{
+		Agent:     new(felt.Felt).SetBytes(addr[:]),
+		PromptID:  drainedEvent.PromptID,
+		User:      drainedEvent.User,
+		Amount:    drainedEvent.Amount,
+		AmountUSD: amountUSD,
+		Attempts:  drainedEvent.PromptID,
+		DrainedAt: info.Timestamp,
+		Block:     info.Block,
+		TxHash:    info.TxHash,
+	}
+
+	if registration, ok := db.registrations[addr]; ok {
+		entry.AgentName = registration.Name
+		entry.SystemPrompt = registration.SystemPrompt
+		entry.Token = registration.Token
+		entry.RegisteredAt = registration.Timestamp
+	}
+
+	if idx, ok := findPromptHistoryEntry(db.history[addr], drainedEvent.PromptID); ok {
+		promptEntry := db.history[addr][idx]
+		entry.TweetID = promptEntry.TweetID
+		entry.Prompt = promptEntry.Prompt
+	}
+
+	hallOfFameLen := len(db.hallOfFame)
+	db.undoLog.Push(func() {
+		db.hallOfFame = db.hallOfFame[:hallOfFameLen]
+	})
+
+	db.hallOfFame = append(db.hallOfFame, entry)
+}
+
+// GetHallOfFame returns start:end drains of the hall of fame in the given
+// order, and the number of drains.
+func (db *AgentUsageIndexerDatabaseInMemory) GetHallOfFame(order HallOfFameSort, start, end uint64, priceCache AgentBalanceIndexerPriceCache) ([]*HallOfFameEntry, uint64) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	total := uint64(len(db.hallOfFame))
+	end = min(end, total)
+	if start >= end {
+		return []*HallOfFameEntry{}, total
+	}
+
+	return sortHallOfFame(db.hallOfFame, order, priceCache)[start:end], total
+}
+
+// Search returns start:end texts matching every term of query within scope,
+// by descending relevance, and the number of matching texts.
+func (db *AgentUsageIndexerDatabaseInMemory) Search(query string, scope SearchScope, start, end uint64) ([]*SearchResult, uint64) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	keys, scores, terms := db.search.search(query, scope)
+
+	total := uint64(len(keys))
+	end = min(end, total)
+	if start >= end {
+		return []*SearchResult{}, total
+	}
+
+	results := make([]*SearchResult, 0, end-start)
+	for _, key := range keys[start:end] {
+		result := &SearchResult{
+			Agent:    new(felt.Felt).SetBytes(key.agent[:]),
+			Field:    key.field,
+			PromptID: key.promptID,
+			Score:    scores[key],
+			Snippet:  searchSnippet(db.search.documents[key].text, terms),
+		}
+		if registration, ok := db.registrations[key.agent]; ok {
+			result.AgentName = registration.Name
+		}
+
+		results = append(results, result)
+	}
+
+	return results, total
+}
+
+// rebuildSearchIndex indexes the registrations and prompt history again.
+func (db *AgentUsageIndexerDatabaseInMemory) rebuildSearchIndex() {
+	db.search = newSearchIndex()
+	for addr, registration := range db.registrations {
+		db.search.add(searchDocumentKey{agent: addr, field: SearchFieldName}, registration.Name)
+		db.search.add(searchDocumentKey{agent: addr, field: SearchFieldSystemPrompt}, registration.SystemPrompt)
+	}
+	for addr, history := range db.history {
+		for _, entry := range history {
+			db.search.add(searchDocumentKey{agent: addr, field: SearchFieldPrompt, promptID: entry.PromptID}, entry.Prompt)
+		}
+	}
+}
+
 func (db *AgentUsageIndexerDatabaseInMemory) GetTotalUsage() *AgentUsageIndexerTotalUsage {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -190,6 +416,111 @@ func (db *AgentUsageIndexerDatabaseInMemory) GetTotalUsage() *AgentUsageIndexerT
 	return db.totalUsage
 }
 
+// GetPromptHistory returns a page of an agent's prompt history and the cursor
+// of the next page.
+func (db *AgentUsageIndexerDatabaseInMemory) GetPromptHistory(addr [32]byte, filter *PromptHistoryFilter) ([]*PromptHistoryEntry, uint64, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if _, ok := db.usages[addr]; !ok {
+		return nil, 0, false
+	}
+
+	prompts, nextCursor := filterPromptHistory(db.history[addr], filter)
+	return prompts, nextCursor, true
+}
+
+// GetPendingTimestampBlocks returns the blocks up to toBlock with history
+// entries of a provisional timestamp.
+func (db *AgentUsageIndexerDatabaseInMemory) GetPendingTimestampBlocks(toBlock uint64) []uint64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	blocks := make([]uint64, 0)
+	for block := range db.pendingTimestamps {
+		if block <= toBlock {
+			blocks = append(blocks, block)
+		}
+	}
+	slices.Sort(blocks)
+	return blocks
+}
+
+// SetBlockTimestamp replaces the provisional timestamp of the history entries
+// of a block.
+func (db *AgentUsageIndexerDatabaseInMemory) SetBlockTimestamp(block, timestamp uint64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.setBlockTimestamp(block, timestamp)
+}
+
+// setBlockTimestamp replaces the provisional timestamp of the history entries
+// of a block, and returns the entries changed.
+func (db *AgentUsageIndexerDatabaseInMemory) setBlockTimestamp(block, timestamp uint64) []PromptRef {
+	refs := make([]PromptRef, 0, len(db.pendingTimestamps[block]))
+	for ref := range db.pendingTimestamps[block] {
+		idx, ok := findPromptHistoryEntry(db.history[ref.Agent], ref.PromptID)
+		if !ok {
+			continue
+		}
+
+		prevEntry := db.history[ref.Agent][idx]
+		if !prevEntry.PendingTimestamp || prevEntry.Block != block {
+			continue
+		}
+
+		entry := *prevEntry
+		entry.Timestamp = timestamp
+		entry.PendingTimestamp = false
+
+		db.undoLog.Push(func() {
+			db.trackPromptHistoryEntry(ref, prevEntry)
+		})
+		db.setPromptHistoryEntry(ref.Agent, &entry)
+		refs = append(refs, ref)
+	}
+
+	delete(db.pendingTimestamps, block)
+	return refs
+}
+
+// GetPromptsMissingReply returns the history entries whose reply was not
+// fetched yet.
+func (db *AgentUsageIndexerDatabaseInMemory) GetPromptsMissingReply() map[PromptRef]*PromptHistoryEntry {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	entries := make(map[PromptRef]*PromptHistoryEntry, len(db.missingReplies))
+	for ref := range db.missingReplies {
+		idx, ok := findPromptHistoryEntry(db.history[ref.Agent], ref.PromptID)
+		if !ok || !db.history[ref.Agent][idx].needsReply() {
+			delete(db.missingReplies, ref)
+			continue
+		}
+		entries[ref] = db.history[ref.Agent][idx]
+	}
+	return entries
+}
+
+// SetPromptReply stores the reply of a consumed prompt. Replies are not part
+// of the chain, so they are not reverted on their own.
+func (db *AgentUsageIndexerDatabaseInMemory) SetPromptReply(ref PromptRef, reply string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.missingReplies, ref)
+
+	idx, ok := findPromptHistoryEntry(db.history[ref.Agent], ref.PromptID)
+	if !ok || !db.history[ref.Agent][idx].needsReply() {
+		return
+	}
+
+	entry := *db.history[ref.Agent][idx]
+	entry.Reply = reply
+	db.history[ref.Agent][idx] = &entry
+}
+
 func (db *AgentUsageIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 	return db.lastIndexedBlock
 }
@@ -199,6 +530,120 @@ func (db *AgentUsageIndexerDatabaseInMemory) SetLastIndexedBlock(block uint64) {
 	defer db.mu.Unlock()
 
 	db.lastIndexedBlock = block
+	db.undoLog.Prune(block - min(block, ReorgWindow))
+}
+
+func (db *AgentUsageIndexerDatabaseInMemory) SetCurrentBlock(block uint64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.undoLog.SetBlock(block)
+}
+
+func (db *AgentUsageIndexerDatabaseInMemory) SetCurrentEvent(ev *Event) {
+	db.SetCurrentBlock(ev.Block)
+}
+
+func (db *AgentUsageIndexerDatabaseInMemory) RevertToBlock(block uint64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.undoLog.Revert(block)
+	if db.lastIndexedBlock > block {
+		db.lastIndexedBlock = block
+	}
+}
+
+// pushUsageUndo records how to restore an agent's usage and the total usage
+// as they are before a change.
+func (db *AgentUsageIndexerDatabaseInMemory) pushUsageUndo(addr [32]byte) {
+	totalUsage := *db.totalUsage
+
+	usage, existed := db.usages[addr]
+	var prevUsage AgentUsage
+	if existed {
+		prevUsage = *usage
+		prevUsage.LatestPrompts = slices.Clone(usage.LatestPrompts)
+	}
+
+	db.undoLog.Push(func() {
+		*db.totalUsage = totalUsage
+		if existed {
+			*db.usages[addr] = prevUsage
+		} else {
+			delete(db.usages, addr)
+		}
+	})
+}
+
+// setPromptHistoryEntry stores an entry of an agent's prompt history,
+// replacing the entry of the same prompt if any.
+func (db *AgentUsageIndexerDatabaseInMemory) setPromptHistoryEntry(addr [32]byte, entry *PromptHistoryEntry) {
+	db.trackPromptHistoryEntry(PromptRef{Agent: addr, PromptID: entry.PromptID}, entry)
+
+	history := db.history[addr]
+	idx, found := findPromptHistoryEntry(history, entry.PromptID)
+	if found {
+		prevEntry := history[idx]
+		db.undoLog.Push(func() {
+			db.history[addr][idx] = prevEntry
+		})
+
+		history[idx] = entry
+		return
+	}
+
+	// Prompts are paid in order, so entries are almost always appended.
+	if idx == len(history) {
+		db.undoLog.Push(func() {
+			db.history[addr] = db.history[addr][:idx]
+		})
+
+		db.history[addr] = append(history, entry)
+		return
+	}
+
+	db.undoLog.Push(func() {
+		db.history[addr] = history
+	})
+
+	db.history[addr] = slices.Insert(slices.Clone(history), idx, entry)
+}
+
+// trackPromptHistoryEntry records whether an entry waits for the timestamp of
+// its block or for its reply.
+func (db *AgentUsageIndexerDatabaseInMemory) trackPromptHistoryEntry(ref PromptRef, entry *PromptHistoryEntry) {
+	if entry.PendingTimestamp {
+		if db.pendingTimestamps[entry.Block] == nil {
+			db.pendingTimestamps[entry.Block] = make(map[PromptRef]struct{})
+		}
+		db.pendingTimestamps[entry.Block][ref] = struct{}{}
+	}
+	if entry.needsReply() {
+		db.missingReplies[ref] = struct{}{}
+	}
+}
+
+// finishPromptHistoryEntry records the outcome of a prompt in its history
+// entry.
+func (db *AgentUsageIndexerDatabaseInMemory) finishPromptHistoryEntry(addr [32]byte, promptID uint64, outcome PromptOutcome, drainedTo *felt.Felt, info PromptEventInfo) {
+	entry := &PromptHistoryEntry{PromptID: promptID}
+	if idx, found := findPromptHistoryEntry(db.history[addr], promptID); found {
+		prevEntry := *db.history[addr][idx]
+		entry = &prevEntry
+	} else {
+		// The prompt was paid before indexing started
+		entry.Block = info.Block
+		entry.Timestamp = info.Timestamp
+		entry.PendingTimestamp = info.Pending
+	}
+
+	entry.Outcome = outcome
+	entry.DrainedTo = drainedTo
+	entry.ConsumeBlock = info.Block
+	entry.ConsumeTxHash = info.TxHash
+
+	db.setPromptHistoryEntry(addr, entry)
 }
 
 func (db *AgentUsageIndexerDatabaseInMemory) getOrCreateAgentUsage(addr [32]byte) *AgentUsage {
@@ -0,0 +1,399 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"math/big"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+const (
+	agentUsagesTable    = "usages"
+	totalUsageTable     = "total_usage"
+	pendingPromptsTable = "pending_prompts"
+	promptHistoryTable  = "prompt_history"
+	registrationsTable  = "registrations"
+	hallOfFameTable     = "hall_of_fame"
+)
+
+var totalUsageKey = []byte("total")
+
+// AgentUsageIndexerDatabasePersistent is an AgentUsageIndexerDatabase kept in
+// memory and persisted to a store on every indexed block. Prompts paid but not
+// consumed yet are persisted as well, so that they are known once consumed.
+type AgentUsageIndexerDatabasePersistent struct {
+	*AgentUsageIndexerDatabaseInMemory
+
+	state        persistentState
+	dirty        map[[32]byte]struct{}
+	dirtyPrompts map[AgentUsageIndexerDatabaseInMemoryPromptCacheKey]struct{}
+	dirtyHistory map[PromptRef]struct{}
+	// dirtyRegistrations holds changed registrations, and dirtyHallOfFame
+	// changed hall of fame indices.
+	dirtyRegistrations map[[32]byte]struct{}
+	dirtyHallOfFame    map[int]struct{}
+}
+
+func promptRefKey(ref PromptRef) []byte {
+	return binary.BigEndian.AppendUint64(ref.Agent[:], ref.PromptID)
+}
+
+var _ AgentUsageIndexerDatabase = (*AgentUsageIndexerDatabasePersistent)(nil)
+
+// NewAgentUsageIndexerDatabasePersistent creates an AgentUsageIndexerDatabase
+// persisted to s, loading the state persisted by a previous run if any.
+func NewAgentUsageIndexerDatabasePersistent(s *store.Store, initialBlock, maxPrompts uint64) (*AgentUsageIndexerDatabasePersistent, error) {
+	db := &AgentUsageIndexerDatabasePersistent{
+		AgentUsageIndexerDatabaseInMemory: NewAgentUsageIndexerDatabaseInMemory(initialBlock, maxPrompts),
+		state:                             newPersistentState(s, "agent_usage_indexer"),
+		dirty:                             make(map[[32]byte]struct{}),
+		dirtyPrompts:                      make(map[AgentUsageIndexerDatabaseInMemoryPromptCacheKey]struct{}),
+		dirtyHistory:                      make(map[PromptRef]struct{}),
+		dirtyRegistrations:                make(map[[32]byte]struct{}),
+		dirtyHallOfFame:                   make(map[int]struct{}),
+	}
+
+	block, ok, err := db.state.load(func(tx *store.Tx) error {
+		err := tx.ForEach(agentUsagesTable, func(key []byte, decode func(value any) error) error {
+			var usage AgentUsage
+			if err := decode(&usage); err != nil {
+				return err
+			}
+
+			db.usages[[32]byte(key)] = &usage
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.ForEach(pendingPromptsTable, func(key []byte, decode func(value any) error) error {
+			var data AgentUsageIndexerDatabaseInMemoryPromptCacheData
+			if err := decode(&data); err != nil {
+				return err
+			}
+
+			db.promptCache.Add(AgentUsageIndexerDatabaseInMemoryPromptCacheKey(key), data)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Keys are sorted by agent, then prompt ID
+		err = tx.ForEach(promptHistoryTable, func(key []byte, decode func(value any) error) error {
+			var entry PromptHistoryEntry
+			if err := decode(&entry); err != nil {
+				return err
+			}
+
+			agent := [32]byte(key[:32])
+			db.history[agent] = append(db.history[agent], &entry)
+			db.trackPromptHistoryEntry(PromptRef{Agent: agent, PromptID: entry.PromptID}, &entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.ForEach(registrationsTable, func(key []byte, decode func(value any) error) error {
+			var registration agentRegistration
+			if err := decode(&registration); err != nil {
+				return err
+			}
+
+			db.registrations[[32]byte(key)] = &registration
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Keys are sorted by drain order
+		err = tx.ForEach(hallOfFameTable, func(key []byte, decode func(value any) error) error {
+			var entry HallOfFameEntry
+			if err := decode(&entry); err != nil {
+				return err
+			}
+
+			db.hallOfFame = append(db.hallOfFame, &entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Get(totalUsageTable, totalUsageKey, db.totalUsage)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		db.lastIndexedBlock = block
+		db.rebuildSearchIndex()
+		db.undoLog = utils.NewUndoLog()
+		slog.Info("loaded persisted agent usages", "agents", len(db.usages), "last_indexed_block", block)
+	}
+
+	return db, nil
+}
+
+// StoreAgent stores a registered agent.
+func (db *AgentUsageIndexerDatabasePersistent) StoreAgent(addr [32]byte) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[addr] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StoreAgent(addr)
+}
+
+// StoreAgentRegisteredData stores a registered agent.
+func (db *AgentUsageIndexerDatabasePersistent) StoreAgentRegisteredData(ev *AgentRegisteredEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[ev.Agent.Bytes()] = struct{}{}
+	db.dirtyRegistrations[ev.Agent.Bytes()] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StoreAgentRegisteredData(ev, info)
+}
+
+// StorePromptPaidData stores a paid prompt.
+func (db *AgentUsageIndexerDatabasePersistent) StorePromptPaidData(addr [32]byte, ev *PromptPaidEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[addr] = struct{}{}
+	db.dirtyPrompts[db.promptCacheKey(addr, ev.PromptID)] = struct{}{}
+	db.dirtyHistory[PromptRef{Agent: addr, PromptID: ev.PromptID}] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StorePromptPaidData(addr, ev, info)
+}
+
+// StorePromptConsumedData stores a consumed prompt.
+func (db *AgentUsageIndexerDatabasePersistent) StorePromptConsumedData(addr [32]byte, ev *PromptConsumedEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[addr] = struct{}{}
+	db.dirtyPrompts[db.promptCacheKey(addr, ev.PromptID)] = struct{}{}
+	db.dirtyHistory[PromptRef{Agent: addr, PromptID: ev.PromptID}] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StorePromptConsumedData(addr, ev, info)
+}
+
+// SetBlockTimestamp replaces the provisional timestamp of the history entries
+// of a block.
+func (db *AgentUsageIndexerDatabasePersistent) SetBlockTimestamp(block, timestamp uint64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, ref := range db.setBlockTimestamp(block, timestamp) {
+		db.dirtyHistory[ref] = struct{}{}
+	}
+}
+
+// SetPromptReply stores the reply of a consumed prompt.
+func (db *AgentUsageIndexerDatabasePersistent) SetPromptReply(ref PromptRef, reply string) {
+	db.dirtyHistory[ref] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.SetPromptReply(ref, reply)
+}
+
+// StorePromptReclaimedData stores a reclaimed prompt.
+func (db *AgentUsageIndexerDatabasePersistent) StorePromptReclaimedData(addr [32]byte, ev *PromptReclaimedEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirtyHistory[PromptRef{Agent: addr, PromptID: ev.PromptID}] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StorePromptReclaimedData(addr, ev, info)
+}
+
+// StoreWithdrawnData stores a withdrawal.
+func (db *AgentUsageIndexerDatabasePersistent) StoreWithdrawnData(addr [32]byte, ev *WithdrawnEvent) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[addr] = struct{}{}
+	db.AgentUsageIndexerDatabaseInMemory.StoreWithdrawnData(addr, ev)
+}
+
+// StoreDrainedData adds a drain to the hall of fame.
+func (db *AgentUsageIndexerDatabasePersistent) StoreDrainedData(addr [32]byte, ev *DrainedEvent, amountUSD *big.Int, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.AgentUsageIndexerDatabaseInMemory.StoreDrainedData(addr, ev, amountUSD, info)
+
+	db.mu.RLock()
+	db.dirtyHallOfFame[len(db.hallOfFame)-1] = struct{}{}
+	db.mu.RUnlock()
+}
+
+// SetLastIndexedBlock sets the last indexed block and persists the changes
+// made up to it.
+func (db *AgentUsageIndexerDatabasePersistent) SetLastIndexedBlock(block uint64) {
+	if db.state.stale(block) {
+		return
+	}
+
+	db.mu.RLock()
+	err := db.state.commit(block, func(tx *store.Tx, rewrite bool) error {
+		if rewrite {
+			if err := tx.Clear(agentUsagesTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(pendingPromptsTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(promptHistoryTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(registrationsTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(hallOfFameTable); err != nil {
+				return err
+			}
+
+			clear(db.dirty)
+			for addr := range db.usages {
+				db.dirty[addr] = struct{}{}
+			}
+			clear(db.dirtyPrompts)
+			for _, key := range db.promptCache.Keys() {
+				db.dirtyPrompts[key] = struct{}{}
+			}
+			clear(db.dirtyHistory)
+			for addr, history := range db.history {
+				for _, entry := range history {
+					db.dirtyHistory[PromptRef{Agent: addr, PromptID: entry.PromptID}] = struct{}{}
+				}
+			}
+			clear(db.dirtyRegistrations)
+			for addr := range db.registrations {
+				db.dirtyRegistrations[addr] = struct{}{}
+			}
+			clear(db.dirtyHallOfFame)
+			for idx := range db.hallOfFame {
+				db.dirtyHallOfFame[idx] = struct{}{}
+			}
+		}
+
+		for addr := range db.dirty {
+			usage, ok := db.usages[addr]
+			if !ok {
+				if err := tx.Delete(agentUsagesTable, addr[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(agentUsagesTable, addr[:], usage); err != nil {
+				return err
+			}
+		}
+
+		for key := range db.dirtyPrompts {
+			data, ok := db.promptCache.Peek(key)
+			if !ok {
+				if err := tx.Delete(pendingPromptsTable, key[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(pendingPromptsTable, key[:], &data); err != nil {
+				return err
+			}
+		}
+
+		for ref := range db.dirtyHistory {
+			idx, ok := findPromptHistoryEntry(db.history[ref.Agent], ref.PromptID)
+			if !ok {
+				if err := tx.Delete(promptHistoryTable, promptRefKey(ref)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(promptHistoryTable, promptRefKey(ref), db.history[ref.Agent][idx]); err != nil {
+				return err
+			}
+		}
+
+		for addr := range db.dirtyRegistrations {
+			registration, ok := db.registrations[addr]
+			if !ok {
+				if err := tx.Delete(registrationsTable, addr[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(registrationsTable, addr[:], registration); err != nil {
+				return err
+			}
+		}
+
+		for idx := range db.dirtyHallOfFame {
+			key := binary.BigEndian.AppendUint64(nil, uint64(idx))
+			if idx >= len(db.hallOfFame) {
+				if err := tx.Delete(hallOfFameTable, key); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(hallOfFameTable, key, db.hallOfFame[idx]); err != nil {
+				return err
+			}
+		}
+
+		return tx.Put(totalUsageTable, totalUsageKey, db.totalUsage)
+	})
+	db.mu.RUnlock()
+
+	if err != nil {
+		// The changes are persisted again with the next block.
+		slog.Error("failed to persist agent usages", "block", block, "error", err)
+	} else {
+		clear(db.dirty)
+		clear(db.dirtyPrompts)
+		clear(db.dirtyHistory)
+		clear(db.dirtyRegistrations)
+		clear(db.dirtyHallOfFame)
+	}
+
+	db.AgentUsageIndexerDatabaseInMemory.SetLastIndexedBlock(block)
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *AgentUsageIndexerDatabasePersistent) SetCurrentBlock(block uint64) {
+	db.state.setCurrentBlock(block)
+	db.AgentUsageIndexerDatabaseInMemory.SetCurrentBlock(block)
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *AgentUsageIndexerDatabasePersistent) SetCurrentEvent(ev *Event) {
+	db.state.setCurrentEvent(ev)
+	db.AgentUsageIndexerDatabaseInMemory.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. The state is
+// persisted again on the next indexed block.
+func (db *AgentUsageIndexerDatabasePersistent) RevertToBlock(block uint64) {
+	if db.state.stale(block) {
+		slog.Warn("cannot revert persisted agent usages", "block", block, "persisted_block", db.state.resumeBlock)
+	}
+
+	db.state.revert(block)
+	db.AgentUsageIndexerDatabaseInMemory.RevertToBlock(block)
+}
@@ -0,0 +1,75 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+const blockTimestampCacheSize = 1000
+
+// blockTimestampCache caches the timestamps of recent blocks. It is safe for
+// concurrent use.
+type blockTimestampCache struct {
+	client     starknet.ProviderWrapper
+	timestamps *lru.Cache[uint64, uint64]
+}
+
+func newBlockTimestampCache(client starknet.ProviderWrapper) (*blockTimestampCache, error) {
+	timestamps, err := lru.New[uint64, uint64](blockTimestampCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blockTimestampCache{
+		client:     client,
+		timestamps: timestamps,
+	}, nil
+}
+
+// get returns the timestamp of a block, fetching it if it is not cached.
+func (c *blockTimestampCache) get(ctx context.Context, block uint64) (uint64, error) {
+	if timestamp, ok := c.timestamps.Get(block); ok {
+		return timestamp, nil
+	}
+
+	timestamp, err := c.getBlockTimestamp(ctx, block)
+	if err != nil {
+		return 0, err
+	}
+
+	c.timestamps.Add(block, timestamp)
+	return timestamp, nil
+}
+
+// revert drops the timestamps of the blocks after block.
+func (c *blockTimestampCache) revert(block uint64) {
+	for _, cachedBlock := range c.timestamps.Keys() {
+		if cachedBlock > block {
+			c.timestamps.Remove(cachedBlock)
+		}
+	}
+}
+
+func (c *blockTimestampCache) getBlockTimestamp(ctx context.Context, block uint64) (uint64, error) {
+	var resp interface{}
+	var err error
+
+	if err := c.client.Do(func(provider rpc.RpcProvider) error {
+		resp, err = provider.BlockWithTxHashes(ctx, rpc.WithBlockNumber(block))
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("failed to get block %d: %w", block, starknet.FormatRpcError(err))
+	}
+
+	header, ok := resp.(*rpc.BlockTxHashes)
+	if !ok {
+		return 0, fmt.Errorf("unexpected response for block %d: %T", block, resp)
+	}
+
+	return header.Timestamp, nil
+}
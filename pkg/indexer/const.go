@@ -12,6 +12,7 @@ var (
 	tokenAddedSelector      = starknetgoutils.GetSelectorFromNameFelt("TokenAdded")
 	tokenRemovedSelector    = starknetgoutils.GetSelectorFromNameFelt("TokenRemoved")
 	teeUnencumberedSelector = starknetgoutils.GetSelectorFromNameFelt("TeeUnencumbered")
+	promptReclaimedSelector = starknetgoutils.GetSelectorFromNameFelt("PromptReclaimed")
 
 	promptPaidSelectorBytes      = starknetgoutils.GetSelectorFromNameFelt("PromptPaid").Bytes()
 	promptConsumedSelectorBytes  = starknetgoutils.GetSelectorFromNameFelt("PromptConsumed").Bytes()
@@ -22,6 +23,7 @@ var (
 	tokenAddedSelectorBytes      = starknetgoutils.GetSelectorFromNameFelt("TokenAdded").Bytes()
 	tokenRemovedSelectorBytes    = starknetgoutils.GetSelectorFromNameFelt("TokenRemoved").Bytes()
 	teeUnencumberedSelectorBytes = starknetgoutils.GetSelectorFromNameFelt("TeeUnencumbered").Bytes()
+	promptReclaimedSelectorBytes = starknetgoutils.GetSelectorFromNameFelt("PromptReclaimed").Bytes()
 
 	isAgentRegisteredSelector = starknetgoutils.GetSelectorFromNameFelt("is_agent_registered")
 	getSystemPromptSelector   = starknetgoutils.GetSelectorFromNameFelt("get_system_prompt")
@@ -30,6 +32,8 @@ var (
 	getNameSelector           = starknetgoutils.GetSelectorFromNameFelt("get_name")
 	getCreatorSelector        = starknetgoutils.GetSelectorFromNameFelt("get_creator")
 	getEndTimeSelector        = starknetgoutils.GetSelectorFromNameFelt("get_end_time")
+	getModelSelector          = starknetgoutils.GetSelectorFromNameFelt("get_model")
 
 	getPrizePoolSelector = starknetgoutils.GetSelectorFromNameFelt("get_prize_pool")
+	getTeeSelector       = starknetgoutils.GetSelectorFromNameFelt("get_tee")
 )
@@ -0,0 +1,65 @@
+package indexer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+func emittedEvent(t *testing.T, txHash, data string) rpc.EmittedEvent {
+	t.Helper()
+
+	raw := fmt.Sprintf(`{"from_address":"0x1","keys":["0x2"],"data":[%q],"block_number":10,"transaction_hash":%q}`, data, txHash)
+
+	var ev rpc.EmittedEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+	return ev
+}
+
+func TestEventIdentifierIgnoresFiltering(t *testing.T) {
+	// All events of a transaction, as emitted.
+	all := []rpc.EmittedEvent{
+		emittedEvent(t, "0xa", "0x5"),
+		emittedEvent(t, "0xa", "0x6"),
+		emittedEvent(t, "0xa", "0x5"),
+		emittedEvent(t, "0xb", "0x5"),
+	}
+
+	identifier := indexer.NewEventIdentifier()
+	ids := make([][32]byte, len(all))
+	for i, ev := range all {
+		ids[i] = identifier.Identify(ev)
+	}
+
+	seen := make(map[[32]byte]bool)
+	for i, id := range ids {
+		if seen[id] {
+			t.Fatalf("event %d has a duplicate identity", i)
+		}
+		seen[id] = true
+	}
+
+	// A filtered view without the second event, e.g. a stream subscription,
+	// identifies the remaining events the same way.
+	filtered := indexer.NewEventIdentifier()
+	for _, i := range []int{0, 2, 3} {
+		if filtered.Identify(all[i]) != ids[i] {
+			t.Errorf("event %d is identified differently when filtered", i)
+		}
+	}
+
+	// Events received again, e.g. by polling after being streamed, keep their
+	// identity.
+	again := indexer.NewEventIdentifier()
+	for i, ev := range all {
+		if again.Identify(ev) != ids[i] {
+			t.Errorf("event %d is identified differently when received again", i)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+)
+
+const (
+	wsMethodSubscribeNewHeads    = "starknet_subscribeNewHeads"
+	wsMethodSubscribeEvents      = "starknet_subscribeEvents"
+	wsMethodSubscriptionNewHeads = "starknet_subscriptionNewHeads"
+	wsMethodSubscriptionEvents   = "starknet_subscriptionEvents"
+	wsMethodSubscriptionReorg    = "starknet_subscriptionReorg"
+)
+
+// EventStreamMessageType is the kind of a message received from an EventStream.
+type EventStreamMessageType int
+
+const (
+	// EventStreamConnected is sent after every (re)connection, as events may
+	// have been missed while disconnected.
+	EventStreamConnected EventStreamMessageType = iota
+	EventStreamNewHead
+	EventStreamEvent
+	// EventStreamReorg is sent when the node reports a reorg.
+	EventStreamReorg
+)
+
+// EventStreamMessage is a notification received from an EventStream.
+type EventStreamMessage struct {
+	Type        EventStreamMessageType
+	BlockNumber uint64
+	Event       rpc.EmittedEvent
+}
+
+// EventStreamConfig holds the necessary settings for constructing an EventStream.
+type EventStreamConfig struct {
+	URL               string
+	Keys              [][]*felt.Felt
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+// EventStream subscribes to new heads and events over the Starknet JSON-RPC
+// WebSocket API and reconnects when the connection drops.
+type EventStream struct {
+	url               string
+	keys              [][]*felt.Felt
+	reconnectDelay    time.Duration
+	maxReconnectDelay time.Duration
+}
+
+// NewEventStream creates a new EventStream.
+func NewEventStream(cfg *EventStreamConfig) *EventStream {
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = 1 * time.Second
+	}
+	if cfg.MaxReconnectDelay <= 0 {
+		cfg.MaxReconnectDelay = 1 * time.Minute
+	}
+
+	return &EventStream{
+		url:               cfg.URL,
+		keys:              cfg.Keys,
+		reconnectDelay:    cfg.ReconnectDelay,
+		maxReconnectDelay: cfg.MaxReconnectDelay,
+	}
+}
+
+// Run streams notifications to out until the context is done, reconnecting
+// with exponential backoff.
+func (s *EventStream) Run(ctx context.Context, out chan<- *EventStreamMessage) error {
+	delay := s.reconnectDelay
+
+	for {
+		connected, err := s.stream(ctx, out)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if connected {
+			delay = s.reconnectDelay
+		}
+		slog.Warn("event stream disconnected, reconnecting", "url", s.url, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, s.maxReconnectDelay)
+	}
+}
+
+type wsRequest struct {
+	JsonRpc string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type wsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type wsMessage struct {
+	ID     *uint64         `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *wsError        `json:"error"`
+	Params struct {
+		SubscriptionID json.RawMessage `json:"subscription_id"`
+		Result         json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+type wsHead struct {
+	BlockNumber uint64 `json:"block_number"`
+}
+
+type wsEventsParams struct {
+	Keys [][]*felt.Felt `json:"keys,omitempty"`
+}
+
+// stream runs a single connection. It reports whether the subscriptions were
+// established.
+func (s *EventStream) stream(ctx context.Context, out chan<- *EventStreamMessage) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	var closeOnce sync.Once
+	closeConn := func() {
+		closeOnce.Do(func() {
+			conn.Close()
+		})
+	}
+	defer closeConn()
+
+	go func() {
+		<-ctx.Done()
+		closeConn()
+	}()
+
+	subscriptions := []wsRequest{
+		{JsonRpc: "2.0", ID: 1, Method: wsMethodSubscribeNewHeads, Params: struct{}{}},
+		{JsonRpc: "2.0", ID: 2, Method: wsMethodSubscribeEvents, Params: wsEventsParams{Keys: s.keys}},
+	}
+	for _, req := range subscriptions {
+		if err := conn.WriteJSON(req); err != nil {
+			return false, fmt.Errorf("failed to send %s: %w", req.Method, err)
+		}
+	}
+
+	pendingSubscriptions := len(subscriptions)
+	connected := false
+
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return connected, fmt.Errorf("failed to read message: %w", err)
+		}
+
+		if msg.ID != nil {
+			if msg.Error != nil {
+				return connected, fmt.Errorf("subscription %d failed: %d %s", *msg.ID, msg.Error.Code, msg.Error.Message)
+			}
+
+			pendingSubscriptions--
+			if pendingSubscriptions == 0 {
+				connected = true
+				slog.Info("event stream subscribed", "url", s.url)
+				if !send(ctx, out, &EventStreamMessage{Type: EventStreamConnected}) {
+					return connected, ctx.Err()
+				}
+			}
+			continue
+		}
+
+		var streamMsg *EventStreamMessage
+
+		switch msg.Method {
+		case wsMethodSubscriptionNewHeads:
+			var head wsHead
+			if err := json.Unmarshal(msg.Params.Result, &head); err != nil {
+				slog.Warn("failed to unmarshal streamed head", "error", err)
+				continue
+			}
+			streamMsg = &EventStreamMessage{Type: EventStreamNewHead, BlockNumber: head.BlockNumber}
+		case wsMethodSubscriptionEvents:
+			var event rpc.EmittedEvent
+			if err := json.Unmarshal(msg.Params.Result, &event); err != nil {
+				slog.Warn("failed to unmarshal streamed event", "error", err)
+				continue
+			}
+			streamMsg = &EventStreamMessage{Type: EventStreamEvent, BlockNumber: event.BlockNumber, Event: event}
+		case wsMethodSubscriptionReorg:
+			streamMsg = &EventStreamMessage{Type: EventStreamReorg}
+		default:
+			continue
+		}
+
+		if !send(ctx, out, streamMsg) {
+			return connected, ctx.Err()
+		}
+	}
+}
+
+func send(ctx context.Context, out chan<- *EventStreamMessage, msg *EventStreamMessage) bool {
+	select {
+	case out <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
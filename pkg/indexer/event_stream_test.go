@@ -0,0 +1,103 @@
+package indexer_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+// newStreamStub serves the Starknet WebSocket subscription methods and pushes
+// a head and an event after both subscriptions are acknowledged. The first
+// connection is dropped right after, to exercise reconnection.
+func newStreamStub(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	connections := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		connections++
+
+		for i := 0; i < 2; i++ {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				t.Errorf("failed to read request: %v", err)
+				return
+			}
+			if !strings.HasPrefix(req.Method, "starknet_subscribe") {
+				t.Errorf("unexpected method: %s", req.Method)
+			}
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": i + 1})
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"starknet_subscriptionNewHeads","params":{"subscription_id":1,"result":{"block_number":42,"block_hash":"0x1"}}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"starknet_subscriptionEvents","params":{"subscription_id":2,"result":{"from_address":"0x2","keys":["0x3"],"data":["0x4"],"block_number":42,"block_hash":"0x1","transaction_hash":"0x5"}}}`))
+
+		if connections > 1 {
+			// keep the second connection open until the client goes away
+			conn.ReadMessage()
+		}
+	}))
+}
+
+func TestEventStream(t *testing.T) {
+	server := newStreamStub(t)
+	defer server.Close()
+
+	stream := indexer.NewEventStream(&indexer.EventStreamConfig{
+		URL:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msgCh := make(chan *indexer.EventStreamMessage, 100)
+	go stream.Run(ctx, msgCh)
+
+	expected := []indexer.EventStreamMessageType{
+		indexer.EventStreamConnected,
+		indexer.EventStreamNewHead,
+		indexer.EventStreamEvent,
+		// reconnected after the stub dropped the first connection
+		indexer.EventStreamConnected,
+	}
+
+	for i, typ := range expected {
+		select {
+		case msg := <-msgCh:
+			if msg.Type != typ {
+				t.Fatalf("message %d: expected type %d, got %d", i, typ, msg.Type)
+			}
+
+			switch msg.Type {
+			case indexer.EventStreamNewHead:
+				if msg.BlockNumber != 42 {
+					t.Errorf("expected head 42, got %d", msg.BlockNumber)
+				}
+			case indexer.EventStreamEvent:
+				raw, _ := json.Marshal(msg.Event.Keys)
+				if msg.BlockNumber != 42 || string(raw) != `["0x3"]` {
+					t.Errorf("unexpected event %+v", msg.Event)
+				}
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for message %d", i)
+		}
+	}
+}
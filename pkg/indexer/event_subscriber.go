@@ -0,0 +1,323 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/NethermindEth/teeception/pkg/metrics"
+)
+
+// OverflowPolicy decides what happens when a subscriber's queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the watcher wait until the subscriber catches up.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest drops the oldest queued message. It is only safe for
+	// subscribers that do not build state from every event, e.g. ones reacting
+	// to recent events only, as the dropped events are never delivered again.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowResync drops every queued message and rolls the subscriber back
+	// to the last block it received, then delivers the missed events again
+	// once it has caught up.
+	OverflowResync OverflowPolicy = "resync"
+)
+
+const defaultSubscriberBufferSize = 1000
+
+// ParseOverflowPolicy parses an overflow policy name.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(name); policy {
+	case OverflowBlock, OverflowDropOldest, OverflowResync:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %q", name)
+	}
+}
+
+// EventSubscriberConfig holds the necessary settings for constructing an EventSubscriber.
+type EventSubscriberConfig struct {
+	Type EventType
+	// Name identifies the subscriber in stats and metrics.
+	Name           string
+	BufferSize     int
+	OverflowPolicy OverflowPolicy
+}
+
+// EventSubscriber delivers parsed Events to a channel from its own bounded
+// queue, so that a slow reader does not hold back the others.
+type EventSubscriber struct {
+	id     int64
+	name   string
+	typ    EventType
+	ch     chan<- *EventSubscriptionData
+	policy OverflowPolicy
+
+	mu       sync.Mutex
+	queue    []*EventSubscriptionData
+	capacity int
+	inflight *EventSubscriptionData
+	closed   bool
+
+	notify chan struct{}
+	space  chan struct{}
+	done   chan struct{}
+
+	// resyncing is set after an overflow with OverflowResync, until the
+	// events after resyncFrom have been queued again.
+	resyncing  bool
+	resyncFrom uint64
+
+	delivered          uint64
+	dropped            uint64
+	resyncs            uint64
+	lastDeliveredBlock uint64
+}
+
+// EventSubscriberStats holds delivery stats of a subscriber.
+type EventSubscriberStats struct {
+	ID                 int64          `json:"id"`
+	Name               string         `json:"name"`
+	Policy             OverflowPolicy `json:"policy"`
+	Queued             int            `json:"queued"`
+	Capacity           int            `json:"capacity"`
+	Delivered          uint64         `json:"delivered"`
+	Dropped            uint64         `json:"dropped"`
+	Resyncs            uint64         `json:"resyncs"`
+	Resyncing          bool           `json:"resyncing"`
+	LastDeliveredBlock uint64         `json:"last_delivered_block"`
+	LagBlocks          uint64         `json:"lag_blocks"`
+}
+
+// NewEventSubscriber creates a subscriber delivering to ch. Its delivery
+// goroutine is started with Run.
+func NewEventSubscriber(id int64, cfg *EventSubscriberConfig, ch chan<- *EventSubscriptionData, lastIndexedBlock uint64) *EventSubscriber {
+	name := cfg.Name
+	if name == "" {
+		name = strconv.FormatInt(id, 10)
+	}
+
+	capacity := cfg.BufferSize
+	if capacity <= 0 {
+		capacity = defaultSubscriberBufferSize
+	}
+
+	policy := cfg.OverflowPolicy
+	if policy == "" {
+		policy = OverflowBlock
+	}
+
+	return &EventSubscriber{
+		id:                 id,
+		name:               name,
+		typ:                cfg.Type,
+		ch:                 ch,
+		policy:             policy,
+		queue:              make([]*EventSubscriptionData, 0, capacity),
+		capacity:           capacity,
+		notify:             make(chan struct{}, 1),
+		space:              make(chan struct{}, 1),
+		done:               make(chan struct{}),
+		lastDeliveredBlock: lastIndexedBlock,
+	}
+}
+
+// Run delivers queued messages until the subscriber is closed.
+func (s *EventSubscriber) Run() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		data := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.inflight = data
+		queued := len(s.queue)
+		s.mu.Unlock()
+
+		signal(s.space)
+		metrics.EventWatcherSubscriberQueue.WithLabelValues(s.name).Set(float64(queued))
+
+		select {
+		case s.ch <- data:
+		case <-s.done:
+			return
+		}
+
+		s.mu.Lock()
+		s.inflight = nil
+		s.delivered++
+		s.lastDeliveredBlock = data.ToBlock
+		s.mu.Unlock()
+	}
+}
+
+// Enqueue queues a message, applying the overflow policy if the queue is full.
+// With OverflowBlock, it waits for space until the subscriber is closed or ctx
+// is done.
+func (s *EventSubscriber) Enqueue(ctx context.Context, data *EventSubscriptionData) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		// Events are delivered again when resyncing, but reorgs must still
+		// reach the subscriber.
+		if s.resyncing {
+			if data.Rollback {
+				s.resyncFrom = min(s.resyncFrom, data.ToBlock)
+				s.push(data)
+			}
+			s.mu.Unlock()
+			return
+		}
+
+		if len(s.queue) < s.capacity {
+			s.push(data)
+			s.mu.Unlock()
+			return
+		}
+
+		switch s.policy {
+		case OverflowDropOldest:
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.dropped++
+			metrics.EventWatcherSubscriberDropped.WithLabelValues(s.name).Inc()
+			s.push(data)
+			s.mu.Unlock()
+			return
+		case OverflowResync:
+			s.startResync()
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.space:
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// push appends a message to the queue and wakes the delivery goroutine. The
+// caller must hold s.mu.
+func (s *EventSubscriber) push(data *EventSubscriptionData) {
+	s.queue = append(s.queue, data)
+	metrics.EventWatcherSubscriberQueue.WithLabelValues(s.name).Set(float64(len(s.queue)))
+	signal(s.notify)
+}
+
+// startResync drops the queued messages and rolls the subscriber back to the
+// last block it fully received. The caller must hold s.mu.
+func (s *EventSubscriber) startResync() {
+	resyncFrom := s.lastDeliveredBlock
+	if s.inflight != nil && s.inflight.Rollback {
+		resyncFrom = min(resyncFrom, s.inflight.ToBlock)
+	}
+	for _, data := range s.queue {
+		if data.Rollback {
+			resyncFrom = min(resyncFrom, data.ToBlock)
+		}
+	}
+
+	slog.Warn("subscriber overflowed, resyncing", "subscriber", s.name, "dropped", len(s.queue), "resync_from", resyncFrom)
+
+	s.dropped += uint64(len(s.queue))
+	metrics.EventWatcherSubscriberDropped.WithLabelValues(s.name).Add(float64(len(s.queue)))
+	metrics.EventWatcherSubscriberResyncs.WithLabelValues(s.name).Inc()
+
+	clear(s.queue)
+	s.queue = s.queue[:0]
+	s.resyncs++
+	s.resyncing = true
+	s.resyncFrom = resyncFrom
+
+	s.push(&EventSubscriptionData{
+		Events:    []*Event{},
+		FromBlock: resyncFrom,
+		ToBlock:   resyncFrom,
+		Rollback:  true,
+	})
+}
+
+// ReadyToResync returns the block to resync from once the subscriber has
+// processed its rollback.
+func (s *EventSubscriber) ReadyToResync() (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.resyncing || len(s.queue) > 0 || s.inflight != nil {
+		return 0, false
+	}
+
+	return s.resyncFrom, true
+}
+
+// FinishResync queues the missed messages and resumes live delivery.
+func (s *EventSubscriber) FinishResync(messages []*EventSubscriptionData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, data := range messages {
+		s.push(data)
+	}
+	s.resyncing = false
+}
+
+// Close stops delivery and unblocks Enqueue.
+func (s *EventSubscriber) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+		metrics.EventWatcherSubscriberQueue.DeleteLabelValues(s.name)
+	}
+}
+
+// Stats returns the delivery stats of the subscriber.
+func (s *EventSubscriber) Stats(lastIndexedBlock uint64) EventSubscriberStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return EventSubscriberStats{
+		ID:                 s.id,
+		Name:               s.name,
+		Policy:             s.policy,
+		Queued:             len(s.queue),
+		Capacity:           s.capacity,
+		Delivered:          s.delivered,
+		Dropped:            s.dropped,
+		Resyncs:            s.resyncs,
+		Resyncing:          s.resyncing,
+		LastDeliveredBlock: s.lastDeliveredBlock,
+		LagBlocks:          lastIndexedBlock - min(s.lastDeliveredBlock, lastIndexedBlock),
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
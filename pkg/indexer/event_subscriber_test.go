@@ -0,0 +1,207 @@
+package indexer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+func newTestSubscriber(policy indexer.OverflowPolicy, ch chan *indexer.EventSubscriptionData) *indexer.EventSubscriber {
+	return indexer.NewEventSubscriber(1, &indexer.EventSubscriberConfig{
+		Type:           indexer.EventPromptPaid,
+		Name:           "test-" + string(policy),
+		BufferSize:     2,
+		OverflowPolicy: policy,
+	}, ch, 10)
+}
+
+func blockMessage(block uint64) *indexer.EventSubscriptionData {
+	return &indexer.EventSubscriptionData{Events: []*indexer.Event{}, FromBlock: block, ToBlock: block}
+}
+
+func rollbackMessage(block uint64) *indexer.EventSubscriptionData {
+	return &indexer.EventSubscriptionData{Events: []*indexer.Event{}, FromBlock: block, ToBlock: block, Rollback: true}
+}
+
+func receiveMessage(t *testing.T, ch chan *indexer.EventSubscriptionData) *indexer.EventSubscriptionData {
+	t.Helper()
+
+	select {
+	case data := <-ch:
+		return data
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+func waitForCondition(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventSubscriberBlocksWhenFull(t *testing.T) {
+	ch := make(chan *indexer.EventSubscriptionData)
+	sub := newTestSubscriber(indexer.OverflowBlock, ch)
+	defer sub.Close()
+
+	ctx := context.Background()
+	sub.Enqueue(ctx, blockMessage(11))
+	sub.Enqueue(ctx, blockMessage(12))
+
+	enqueued := make(chan struct{})
+	go func() {
+		sub.Enqueue(ctx, blockMessage(13))
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("expected enqueue to block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	go sub.Run()
+
+	for block := uint64(11); block <= 13; block++ {
+		if data := receiveMessage(t, ch); data.ToBlock != block {
+			t.Fatalf("expected block %d, got %d", block, data.ToBlock)
+		}
+	}
+	<-enqueued
+
+	if stats := sub.Stats(13); stats.Dropped != 0 {
+		t.Errorf("expected no dropped messages, got %d", stats.Dropped)
+	}
+}
+
+func TestEventSubscriberCloseUnblocksEnqueue(t *testing.T) {
+	ch := make(chan *indexer.EventSubscriptionData)
+	sub := newTestSubscriber(indexer.OverflowBlock, ch)
+
+	ctx := context.Background()
+	sub.Enqueue(ctx, blockMessage(11))
+	sub.Enqueue(ctx, blockMessage(12))
+
+	enqueued := make(chan struct{})
+	go func() {
+		sub.Enqueue(ctx, blockMessage(13))
+		close(enqueued)
+	}()
+
+	sub.Close()
+
+	select {
+	case <-enqueued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected close to unblock enqueue")
+	}
+
+	// Enqueueing after close is a no-op.
+	sub.Enqueue(ctx, blockMessage(14))
+	if stats := sub.Stats(14); stats.Queued != 2 {
+		t.Errorf("expected 2 queued messages, got %d", stats.Queued)
+	}
+}
+
+func TestEventSubscriberDropsOldest(t *testing.T) {
+	ch := make(chan *indexer.EventSubscriptionData)
+	sub := newTestSubscriber(indexer.OverflowDropOldest, ch)
+	defer sub.Close()
+
+	ctx := context.Background()
+	for block := uint64(11); block <= 14; block++ {
+		sub.Enqueue(ctx, blockMessage(block))
+	}
+
+	if stats := sub.Stats(14); stats.Dropped != 2 || stats.Queued != 2 {
+		t.Fatalf("expected 2 dropped and 2 queued messages, got %+v", stats)
+	}
+
+	go sub.Run()
+
+	for block := uint64(13); block <= 14; block++ {
+		if data := receiveMessage(t, ch); data.ToBlock != block {
+			t.Fatalf("expected block %d, got %d", block, data.ToBlock)
+		}
+	}
+}
+
+func TestEventSubscriberResyncs(t *testing.T) {
+	ch := make(chan *indexer.EventSubscriptionData)
+	sub := newTestSubscriber(indexer.OverflowResync, ch)
+	defer sub.Close()
+
+	ctx := context.Background()
+	sub.Enqueue(ctx, blockMessage(11))
+	sub.Enqueue(ctx, blockMessage(12))
+
+	if _, ok := sub.ReadyToResync(); ok {
+		t.Fatal("expected no resync before overflowing")
+	}
+
+	// Overflowing drops the queue and rolls the subscriber back to the last
+	// block it received.
+	sub.Enqueue(ctx, blockMessage(13))
+
+	stats := sub.Stats(13)
+	if !stats.Resyncing || stats.Resyncs != 1 || stats.Dropped != 2 || stats.Queued != 1 {
+		t.Fatalf("unexpected stats after overflow: %+v", stats)
+	}
+
+	// Messages are ignored while resyncing, but rollbacks are still queued
+	// and move the resync point back.
+	sub.Enqueue(ctx, blockMessage(14))
+	sub.Enqueue(ctx, rollbackMessage(8))
+
+	if _, ok := sub.ReadyToResync(); ok {
+		t.Fatal("expected no resync before the rollback is delivered")
+	}
+
+	go sub.Run()
+
+	if data := receiveMessage(t, ch); !data.Rollback || data.ToBlock != 10 {
+		t.Fatalf("expected rollback to block 10, got %+v", data)
+	}
+	if data := receiveMessage(t, ch); !data.Rollback || data.ToBlock != 8 {
+		t.Fatalf("expected rollback to block 8, got %+v", data)
+	}
+
+	var resyncFrom uint64
+	waitForCondition(t, func() bool {
+		var ok bool
+		resyncFrom, ok = sub.ReadyToResync()
+		return ok
+	})
+	if resyncFrom != 8 {
+		t.Fatalf("expected resync from block 8, got %d", resyncFrom)
+	}
+
+	sub.FinishResync([]*indexer.EventSubscriptionData{blockMessage(9), blockMessage(14)})
+
+	for _, block := range []uint64{9, 14} {
+		if data := receiveMessage(t, ch); data.Rollback || data.ToBlock != block {
+			t.Fatalf("expected block %d, got %+v", block, data)
+		}
+	}
+
+	waitForCondition(t, func() bool { return sub.Stats(14).LastDeliveredBlock == 14 })
+	if stats := sub.Stats(14); stats.Resyncing {
+		t.Errorf("expected resync to be finished, got %+v", stats)
+	}
+
+	// Live delivery resumed.
+	sub.Enqueue(ctx, blockMessage(15))
+	if data := receiveMessage(t, ch); data.ToBlock != 15 {
+		t.Fatalf("expected block 15, got %d", data.ToBlock)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/teeception/pkg/metrics"
 	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
 	snaccount "github.com/NethermindEth/teeception/pkg/wallet/starknet"
 )
@@ -32,6 +34,7 @@ const (
 	EventTokenAdded
 	EventTokenRemoved
 	EventTeeUnencumbered
+	EventPromptReclaimed
 )
 
 const (
@@ -56,6 +59,7 @@ var (
 		{tokenAddedSelectorBytes, EventTokenAdded},
 		{tokenRemovedSelectorBytes, EventTokenRemoved},
 		{teeUnencumberedSelectorBytes, EventTeeUnencumbered},
+		{promptReclaimedSelectorBytes, EventPromptReclaimed},
 	}
 
 	EventSelectors = []*felt.Felt{
@@ -68,12 +72,22 @@ var (
 		tokenAddedSelector,
 		tokenRemovedSelector,
 		teeUnencumberedSelector,
+		promptReclaimedSelector,
 	}
 )
 
 type Event struct {
 	Type EventType
 	Raw  rpc.EmittedEvent
+	// Block is the block the event was emitted in. For events of the pending
+	// block, it is the number the pending block is expected to get.
+	Block uint64
+	// ID identifies the event across deliveries, see EventIdentifier.
+	ID [32]byte
+	// Timestamp is the timestamp of the block of the event, or the time it
+	// was fetched at for events of the pending block. It is only set for the
+	// types in TimestampedEvents.
+	Timestamp uint64
 }
 
 type AgentRegisteredEvent struct {
@@ -269,6 +283,48 @@ func (e *Event) ToPromptConsumedEvent() (*PromptConsumedEvent, bool) {
 	}, true
 }
 
+type PromptReclaimedEvent struct {
+	PromptID  uint64
+	Amount    *big.Int
+	Reclaimer *felt.Felt
+}
+
+const (
+	PromptReclaimedEventKeysMinimumSize = 0 +
+		MinimumSizeSelector +
+		MinimumSizeUint64
+
+	PromptReclaimedEventDataMinimumSize = 0 +
+		MinimumSizeUint256 +
+		MinimumSizeFelt252
+)
+
+func (e *Event) ToPromptReclaimedEvent() (*PromptReclaimedEvent, bool) {
+	if e.Type != EventPromptReclaimed {
+		return nil, false
+	}
+
+	if len(e.Raw.Keys) < PromptReclaimedEventKeysMinimumSize {
+		slog.Warn("invalid prompt reclaimed event", "keys", e.Raw.Keys)
+		return nil, false
+	}
+
+	if len(e.Raw.Data) < PromptReclaimedEventDataMinimumSize {
+		slog.Warn("invalid prompt reclaimed event", "data", e.Raw.Data)
+		return nil, false
+	}
+
+	promptID := e.Raw.Keys[1].Uint64()
+	amount := snaccount.Uint256ToBigInt([2]*felt.Felt(e.Raw.Data[0:2]))
+	reclaimer := e.Raw.Data[2]
+
+	return &PromptReclaimedEvent{
+		PromptID:  promptID,
+		Amount:    amount,
+		Reclaimer: reclaimer,
+	}, true
+}
+
 type DrainedEvent struct {
 	PromptID uint64
 	User     *felt.Felt
@@ -516,19 +572,14 @@ type EventSubscriptionData struct {
 	Events    []*Event
 	FromBlock uint64
 	ToBlock   uint64
+	// Rollback is set when every block after ToBlock was reverted by a chain
+	// reorganization. Subscribers must revert any state derived from them.
+	Rollback bool
 }
 
-// EventSubscriberConfig holds the necessary settings for constructing an EventSubscriber.
-type EventSubscriberConfig struct {
-	Type EventType
-}
-
-// EventSubscriber is a write-only channel to receive parsed Events.
-type EventSubscriber struct {
-	id  int64
-	typ EventType
-	ch  chan<- *EventSubscriptionData
-}
+// ReorgWindow is the number of recent blocks tracked for chain
+// reorganizations. Indexer databases must be able to revert this many blocks.
+const ReorgWindow = 64
 
 // EventWatcherInitialState holds the initial state of the EventWatcher.
 type EventWatcherInitialState struct {
@@ -544,6 +595,13 @@ type EventWatcherConfig struct {
 	IndexChunkSize  uint
 	RegistryAddress *felt.Felt
 	InitialState    *EventWatcherInitialState
+	// WebsocketURL enables push-based ingestion over the Starknet JSON-RPC
+	// WebSocket API. Polling remains as a fallback when the stream is quiet.
+	WebsocketURL string
+	// SubscriberBufferSize and SubscriberOverflowPolicy are the defaults for
+	// subscribers that do not set their own.
+	SubscriberBufferSize     int
+	SubscriberOverflowPolicy OverflowPolicy
 }
 
 // EventsListEntry holds a list of events and the number of subscribers watching them.
@@ -569,8 +627,21 @@ type EventWatcher struct {
 	eventsLists map[EventType]*EventsListEntry
 	nextSubID   int64
 
+	subscriberBufferSize     int
+	subscriberOverflowPolicy OverflowPolicy
+
 	// Add event cache
 	eventCache *lru.Cache[[32]byte, struct{}]
+
+	blockTimestamps *blockTimestampCache
+
+	stream           *EventStream
+	streamIdentifier *EventIdentifier
+
+	// Reorg tracking over the last ReorgWindow blocks
+	blockHashes   map[uint64]*felt.Felt
+	blockEvents   map[uint64][][32]byte
+	pendingEvents map[[32]byte]uint64
 }
 
 // NewEventWatcher initializes a new EventWatcher.
@@ -586,6 +657,19 @@ func NewEventWatcher(cfg *EventWatcherConfig) (*EventWatcher, error) {
 		return nil, fmt.Errorf("failed to create event cache: %w", err)
 	}
 
+	blockTimestamps, err := newBlockTimestampCache(cfg.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block timestamp cache: %w", err)
+	}
+
+	var stream *EventStream
+	if cfg.WebsocketURL != "" {
+		stream = NewEventStream(&EventStreamConfig{
+			URL:  cfg.WebsocketURL,
+			Keys: [][]*felt.Felt{EventSelectors},
+		})
+	}
+
 	return &EventWatcher{
 		client:             cfg.Client,
 		lastIndexedBlock:   cfg.InitialState.LastIndexedBlock,
@@ -597,24 +681,45 @@ func NewEventWatcher(cfg *EventWatcherConfig) (*EventWatcher, error) {
 		subs:               make(map[EventType][]*EventSubscriber),
 		eventsLists:        make(map[EventType]*EventsListEntry),
 		eventCache:         cache,
+		blockTimestamps:    blockTimestamps,
+
+		stream:                   stream,
+		streamIdentifier:         NewEventIdentifier(),
+		blockHashes:              make(map[uint64]*felt.Felt),
+		blockEvents:              make(map[uint64][][32]byte),
+		pendingEvents:            make(map[[32]byte]uint64),
+		subscriberBufferSize:     cfg.SubscriberBufferSize,
+		subscriberOverflowPolicy: cfg.SubscriberOverflowPolicy,
 	}, nil
 }
 
 // Subscribe registers a subscriber for events and returns a subscription ID that can be used to unsubscribe.
 func (w *EventWatcher) Subscribe(typ EventType, ch chan<- *EventSubscriptionData) int64 {
+	return w.SubscribeWithConfig(&EventSubscriberConfig{Type: typ}, ch)
+}
+
+// SubscribeWithConfig registers a subscriber with its own queue settings and
+// returns a subscription ID that can be used to unsubscribe.
+func (w *EventWatcher) SubscribeWithConfig(cfg *EventSubscriberConfig, ch chan<- *EventSubscriptionData) int64 {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	w.nextSubID++
 	id := w.nextSubID
 
-	subscriber := EventSubscriber{
-		id:  id,
-		typ: typ,
-		ch:  ch,
+	subCfg := *cfg
+	if subCfg.BufferSize == 0 {
+		subCfg.BufferSize = w.subscriberBufferSize
+	}
+	if subCfg.OverflowPolicy == "" {
+		subCfg.OverflowPolicy = w.subscriberOverflowPolicy
 	}
 
-	w.subs[typ] = append(w.subs[typ], &subscriber)
+	typ := subCfg.Type
+	subscriber := NewEventSubscriber(id, &subCfg, ch, w.lastIndexedBlock)
+	go subscriber.Run()
+
+	w.subs[typ] = append(w.subs[typ], subscriber)
 
 	eventsList, ok := w.eventsLists[typ]
 	if !ok {
@@ -650,6 +755,8 @@ func (w *EventWatcher) Unsubscribe(id int64) bool {
 					w.eventsLists[typ] = eventsList
 				}
 
+				sub.Close()
+
 				// Remove subscriber by swapping with last element and truncating
 				lastIdx := len(subscribers) - 1
 				subscribers[i] = subscribers[lastIdx]
@@ -682,6 +789,10 @@ func (w *EventWatcher) run(ctx context.Context) error {
 		return fmt.Errorf("failed to get current block number: %w", snaccount.FormatRpcError(err))
 	}
 
+	if w.stream != nil {
+		return w.runStreaming(ctx)
+	}
+
 	tickDuration := w.startupTickRate
 
 	for {
@@ -701,11 +812,197 @@ func (w *EventWatcher) run(ctx context.Context) error {
 	}
 }
 
-// fetchEvents fetches events from the Starknet node following a continuation token.
-func (w *EventWatcher) fetchEvents(ctx context.Context, filter rpc.EventFilter) ([]rpc.EmittedEvent, error) {
-	var events []rpc.EmittedEvent
+// runStreaming indexes on every streamed head and delivers streamed events as
+// soon as they arrive once the watcher has caught up. Reconnects, gaps and
+// quiet periods are covered by the regular polling backfill.
+func (w *EventWatcher) runStreaming(ctx context.Context) error {
+	slog.Info("starting event stream", "url", w.stream.url)
+
+	msgCh := make(chan *EventStreamMessage, 1000)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return w.stream.Run(ctx, msgCh)
+	})
+	g.Go(func() error {
+		for {
+			tickDuration := w.tickRate
+			if w.lastIndexedBlock < w.initializedAtBlock {
+				tickDuration = w.startupTickRate
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case msg := <-msgCh:
+				w.onStreamMessage(ctx, msg)
+			case <-time.After(tickDuration):
+				if err := w.indexBlocks(ctx); err != nil {
+					slog.Error("indexBlocks failed", "error", err)
+				}
+			}
+		}
+	})
+
+	return g.Wait()
+}
+
+func (w *EventWatcher) onStreamMessage(ctx context.Context, msg *EventStreamMessage) {
+	switch msg.Type {
+	case EventStreamConnected, EventStreamReorg:
+		slog.Info("backfilling events after stream (re)connection or reorg", "lastIndexedBlock", w.lastIndexedBlock)
+		// The stream may deliver events of a transaction again from its start.
+		w.streamIdentifier = NewEventIdentifier()
+	case EventStreamNewHead:
+		metrics.EventWatcherHeadBlock.Set(float64(msg.BlockNumber))
+		if msg.BlockNumber <= w.lastIndexedBlock+w.safeBlockDelta {
+			return
+		}
+	case EventStreamEvent:
+		w.onStreamedEvent(ctx, msg.Event)
+		return
+	default:
+		return
+	}
+
+	if err := w.indexBlocks(ctx); err != nil {
+		slog.Error("indexBlocks failed", "error", err)
+	}
+}
+
+// onStreamedEvent broadcasts an event of the block right after the last
+// indexed one. Events further ahead are left to the backfill so subscribers
+// keep receiving them in order, and unsafe events wait for the next head.
+func (w *EventWatcher) onStreamedEvent(ctx context.Context, raw rpc.EmittedEvent) {
+	if raw.TransactionHash == nil {
+		return
+	}
+
+	hash := w.streamIdentifier.Identify(raw)
+
+	if w.safeBlockDelta > 0 || w.lastIndexedBlock < w.initializedAtBlock || raw.BlockNumber > w.lastIndexedBlock+1 {
+		return
+	}
+
+	fetched := fetchedEvent{
+		raw:     raw,
+		hash:    hash,
+		block:   raw.BlockNumber,
+		pending: raw.BlockHash == nil,
+	}
+	if fetched.pending {
+		fetched.block = w.lastIndexedBlock + 1
+	}
+	if _, exists := w.eventCache.Peek(fetched.hash); exists {
+		return
+	}
+	// The backfill delivers the event if its block timestamp is unavailable.
+	events := []fetchedEvent{fetched}
+	if err := w.stampEvents(ctx, events); err != nil {
+		slog.Warn("failed to get streamed event timestamp", "block", fetched.block, "error", err)
+		return
+	}
+	w.eventCache.Add(fetched.hash, struct{}{})
+	w.trackEvent(&events[0])
+
+	// The event is delivered ahead of its block, so the indexed range does
+	// not advance until the block is backfilled.
+	w.dispatchEvents(ctx, events, w.lastIndexedBlock, w.lastIndexedBlock)
+}
+
+// fetchedEvent is a raw event along with its identity and block.
+type fetchedEvent struct {
+	raw     rpc.EmittedEvent
+	hash    [32]byte
+	block   uint64
+	pending bool
+	// seen is set for events that were already broadcast.
+	seen bool
+	// timestamp is set by stampEvents.
+	timestamp uint64
+}
+
+// fetchEvents fetches events from the Starknet node, marking the ones that
+// were already broadcast. Events of the pending block are attributed to
+// pendingBlock. New events are only marked as broadcast once their block
+// timestamps are fetched, so that the batch can be retried.
+func (w *EventWatcher) fetchEvents(ctx context.Context, filter rpc.EventFilter, pendingBlock uint64) ([]fetchedEvent, error) {
+	events, err := w.fetchRawEvents(ctx, filter, pendingBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range events {
+		if _, exists := w.eventCache.Peek(events[idx].hash); exists {
+			events[idx].seen = true
+		}
+	}
+
+	if err := w.stampEvents(ctx, events); err != nil {
+		return nil, err
+	}
+
+	for idx := range events {
+		if !events[idx].seen {
+			w.eventCache.Add(events[idx].hash, struct{}{})
+		}
+	}
+
+	return events, nil
+}
+
+// TimestampedEvents are the event types that carry the timestamp of their
+// block.
+const TimestampedEvents = EventAgentRegistered | EventPromptPaid | EventPromptConsumed | EventPromptReclaimed | EventDrained
+
+// stampEvents sets the timestamps of the new events of TimestampedEvents.
+// Events of the pending block get the current time, as their block has no
+// timestamp yet.
+func (w *EventWatcher) stampEvents(ctx context.Context, events []fetchedEvent) error {
+	now := uint64(time.Now().Unix())
+
+	for idx := range events {
+		if events[idx].seen {
+			continue
+		}
+
+		timestamp, err := w.eventTimestamp(ctx, &events[idx], now)
+		if err != nil {
+			return err
+		}
+		events[idx].timestamp = timestamp
+	}
+
+	return nil
+}
+
+func (w *EventWatcher) eventTimestamp(ctx context.Context, ev *fetchedEvent, now uint64) (uint64, error) {
+	parsedEvent, ok := w.parseEvent(ev.raw)
+	if !ok || parsedEvent.Type&TimestampedEvents == 0 {
+		return 0, nil
+	}
+
+	if ev.pending {
+		return now, nil
+	}
+
+	return w.blockTimestamps.get(ctx, ev.block)
+}
+
+// BlockTimestamp returns the timestamp of a block.
+func (w *EventWatcher) BlockTimestamp(ctx context.Context, block uint64) (uint64, error) {
+	return w.blockTimestamps.get(ctx, block)
+}
+
+// fetchRawEvents fetches events from the Starknet node following a
+// continuation token.
+func (w *EventWatcher) fetchRawEvents(ctx context.Context, filter rpc.EventFilter, pendingBlock uint64) ([]fetchedEvent, error) {
+	var events []fetchedEvent
 	continuationToken := ""
 
+	// A transaction's events may be split across pages.
+	identifier := NewEventIdentifier()
+
 	for {
 		var eventsResp *rpc.EventChunk
 		var err error
@@ -723,22 +1020,18 @@ func (w *EventWatcher) fetchEvents(ctx context.Context, filter rpc.EventFilter)
 			return nil, fmt.Errorf("failed to get events from %v to %v: %w", filter.FromBlock, filter.ToBlock, snaccount.FormatRpcError(err))
 		}
 
-		lastTxHash := new(felt.Felt)
-		eventId := 0
-
 		for _, ev := range eventsResp.Events {
-			if lastTxHash.Equal(ev.TransactionHash) {
-				eventId++
-			} else {
-				lastTxHash = ev.TransactionHash
-				eventId = 0
+			fetched := fetchedEvent{
+				raw:     ev,
+				hash:    identifier.Identify(ev),
+				block:   ev.BlockNumber,
+				pending: ev.BlockHash == nil,
 			}
-
-			hash := eventHash(ev, eventId)
-			if _, exists := w.eventCache.Peek(hash); !exists {
-				events = append(events, ev)
-				w.eventCache.Add(hash, struct{}{})
+			if fetched.pending {
+				fetched.block = pendingBlock
 			}
+
+			events = append(events, fetched)
 		}
 
 		continuationToken = eventsResp.ContinuationToken
@@ -762,6 +1055,14 @@ func (w *EventWatcher) indexBlocks(ctx context.Context) error {
 		return fmt.Errorf("failed to get current block number: %w", snaccount.FormatRpcError(err))
 	}
 
+	metrics.EventWatcherHeadBlock.Set(float64(currentBlock))
+
+	if err := w.checkReorg(ctx, currentBlock); err != nil {
+		return fmt.Errorf("failed to check for chain reorganization: %w", err)
+	}
+
+	w.resyncSubscribers(ctx, currentBlock)
+
 	safeBlock := currentBlock - w.safeBlockDelta
 
 	from := w.lastIndexedBlock
@@ -790,7 +1091,7 @@ func (w *EventWatcher) indexBlocks(ctx context.Context) error {
 			ToBlock:   blockId,
 			// We'll fetch all possible keys of interest in a single request:
 			Keys: [][]*felt.Felt{EventSelectors},
-		})
+		}, currentBlock+1)
 		if err != nil {
 			return fmt.Errorf("failed to get events from %v to %v: %w", from, toBlock, snaccount.FormatRpcError(err))
 		}
@@ -799,29 +1100,23 @@ func (w *EventWatcher) indexBlocks(ctx context.Context) error {
 			slog.Info("got events", "count", len(events))
 		}
 
-		// Parse each event into our local struct and broadcast.
-		for _, rawEvent := range events {
-			parsedEvent, ok := w.parseEvent(rawEvent)
-			if ok {
-				for typ, eventList := range w.eventsLists {
-					if typ&parsedEvent.Type != 0 {
-						eventList.events = append(eventList.events, &parsedEvent)
-					}
-				}
+		newEvents := make([]fetchedEvent, 0, len(events))
+		for idx := range events {
+			w.trackEvent(&events[idx])
+			if !events[idx].seen {
+				newEvents = append(newEvents, events[idx])
 			}
 		}
 
-		w.broadcast(from, toBlock)
-
-		// clean up event lists
-		for _, eventList := range w.eventsLists {
-			eventList.events = eventList.events[:0]
-		}
+		w.dispatchEvents(ctx, newEvents, from, toBlock)
 
 		w.mu.Lock()
 		w.lastIndexedBlock = toBlock
 		w.mu.Unlock()
 
+		metrics.EventWatcherLastIndexedBlock.Set(float64(toBlock))
+		metrics.EventWatcherLag.Set(float64(currentBlock - min(toBlock, currentBlock)))
+
 		if from != toBlock {
 			slog.Info("finished chunk", "lastIndexedBlock", w.lastIndexedBlock)
 		}
@@ -833,11 +1128,236 @@ func (w *EventWatcher) indexBlocks(ctx context.Context) error {
 		from += uint64(w.indexChunkSize)
 	}
 
+	if err := w.recordBlockHash(ctx, currentBlock); err != nil {
+		slog.Warn("failed to record block hash", "block", currentBlock, "error", err)
+	}
+
+	w.checkDroppedPendingEvents(ctx, currentBlock)
+	w.pruneReorgWindow(currentBlock)
+
 	return nil
 }
 
+// trackEvent records the block of an event so it can be rolled back, and
+// confirms events first seen in the pending block.
+func (w *EventWatcher) trackEvent(ev *fetchedEvent) {
+	if ev.pending {
+		// Events still pending are expected in the next block again.
+		if _, ok := w.pendingEvents[ev.hash]; ok || !ev.seen {
+			w.pendingEvents[ev.hash] = ev.block
+		}
+		return
+	}
+
+	if ev.raw.BlockHash != nil {
+		w.blockHashes[ev.block] = ev.raw.BlockHash
+	}
+
+	if _, ok := w.pendingEvents[ev.hash]; ok {
+		delete(w.pendingEvents, ev.hash)
+	} else if ev.seen {
+		return
+	}
+
+	w.blockEvents[ev.block] = append(w.blockEvents[ev.block], ev.hash)
+}
+
+// checkReorg compares the recorded block hashes with the chain, newest first,
+// and rolls back to the newest block that is still part of it.
+func (w *EventWatcher) checkReorg(ctx context.Context, currentBlock uint64) error {
+	if len(w.blockHashes) == 0 {
+		return nil
+	}
+
+	blocks := make([]uint64, 0, len(w.blockHashes))
+	for block := range w.blockHashes {
+		blocks = append(blocks, block)
+	}
+	slices.Sort(blocks)
+	slices.Reverse(blocks)
+
+	reverted := false
+	for _, block := range blocks {
+		// The node may lag behind the one the block was seen on.
+		if block > currentBlock {
+			continue
+		}
+
+		hash, err := w.getBlockHash(ctx, block)
+		if err != nil {
+			return err
+		}
+
+		if hash.Equal(w.blockHashes[block]) {
+			if reverted {
+				w.rollback(ctx, block)
+			}
+			return nil
+		}
+
+		slog.Warn("block hash changed", "block", block, "recorded_hash", w.blockHashes[block], "hash", hash)
+		reverted = true
+	}
+
+	if !reverted {
+		return nil
+	}
+
+	ancestor := blocks[len(blocks)-1]
+	if ancestor > 0 {
+		ancestor--
+	}
+	slog.Error("chain reorganization deeper than the tracked window", "window", ReorgWindow, "rollback_to", ancestor)
+	w.rollback(ctx, ancestor)
+
+	return nil
+}
+
+// checkDroppedPendingEvents rolls back pending events that did not make it
+// into the block they were expected in, or the one after.
+func (w *EventWatcher) checkDroppedPendingEvents(ctx context.Context, currentBlock uint64) {
+	dropped := false
+	ancestor := currentBlock
+	for _, block := range w.pendingEvents {
+		if block < currentBlock {
+			dropped = true
+			ancestor = min(ancestor, block-1)
+		}
+	}
+
+	if dropped {
+		slog.Warn("pending events were dropped", "rollback_to", ancestor)
+		w.rollback(ctx, ancestor)
+	}
+}
+
+// rollback forgets every block after ancestor, so that it is indexed again,
+// and notifies subscribers.
+func (w *EventWatcher) rollback(ctx context.Context, ancestor uint64) {
+	slog.Warn("rolling back reverted blocks", "rollback_to", ancestor, "lastIndexedBlock", w.lastIndexedBlock)
+	metrics.EventWatcherReorgs.Inc()
+
+	for block, hashes := range w.blockEvents {
+		if block > ancestor {
+			for _, hash := range hashes {
+				w.eventCache.Remove(hash)
+			}
+			delete(w.blockEvents, block)
+		}
+	}
+
+	for hash := range w.pendingEvents {
+		w.eventCache.Remove(hash)
+		delete(w.pendingEvents, hash)
+	}
+
+	w.blockTimestamps.revert(ancestor)
+
+	for block := range w.blockHashes {
+		if block > ancestor {
+			delete(w.blockHashes, block)
+		}
+	}
+
+	w.mu.Lock()
+	if w.lastIndexedBlock > ancestor {
+		w.lastIndexedBlock = ancestor
+	}
+	w.mu.Unlock()
+
+	metrics.EventWatcherLastIndexedBlock.Set(float64(w.lastIndexedBlock))
+
+	w.broadcastRollback(ctx, ancestor)
+}
+
+// pruneReorgWindow forgets blocks that are too old to be reverted.
+func (w *EventWatcher) pruneReorgWindow(currentBlock uint64) {
+	if currentBlock < ReorgWindow {
+		return
+	}
+	oldest := currentBlock - ReorgWindow
+
+	for block := range w.blockHashes {
+		if block <= oldest {
+			delete(w.blockHashes, block)
+		}
+	}
+	for block := range w.blockEvents {
+		if block <= oldest {
+			delete(w.blockEvents, block)
+		}
+	}
+}
+
+func (w *EventWatcher) recordBlockHash(ctx context.Context, block uint64) error {
+	if _, ok := w.blockHashes[block]; ok {
+		return nil
+	}
+
+	hash, err := w.getBlockHash(ctx, block)
+	if err != nil {
+		return err
+	}
+
+	w.blockHashes[block] = hash
+	return nil
+}
+
+func (w *EventWatcher) getBlockHash(ctx context.Context, block uint64) (*felt.Felt, error) {
+	var resp interface{}
+	var err error
+
+	if err := w.client.Do(func(provider rpc.RpcProvider) error {
+		resp, err = provider.BlockWithTxHashes(ctx, rpc.WithBlockNumber(block))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", block, snaccount.FormatRpcError(err))
+	}
+
+	header, ok := resp.(*rpc.BlockTxHashes)
+	if !ok || header.BlockHash == nil {
+		return nil, fmt.Errorf("unexpected response for block %d: %T", block, resp)
+	}
+
+	return header.BlockHash, nil
+}
+
+// dispatchEvents parses the raw events and broadcasts them to subscribers.
+func (w *EventWatcher) dispatchEvents(ctx context.Context, events []fetchedEvent, fromBlock, toBlock uint64) {
+	// Parse each event into our local struct and broadcast.
+	for _, fetched := range events {
+		parsedEvent, ok := w.parseEvent(fetched.raw)
+		if ok {
+			parsedEvent.Block = fetched.block
+			parsedEvent.ID = fetched.hash
+			parsedEvent.Timestamp = fetched.timestamp
+			for typ, eventList := range w.eventsLists {
+				if typ&parsedEvent.Type != 0 {
+					eventList.events = append(eventList.events, &parsedEvent)
+				}
+			}
+		}
+	}
+
+	w.broadcast(ctx, fromBlock, toBlock)
+
+	// clean up event lists
+	for _, eventList := range w.eventsLists {
+		eventList.events = eventList.events[:0]
+	}
+}
+
 // parseEvent examines the raw keys/data to determine the event type and produce an Event struct.
 func (w *EventWatcher) parseEvent(raw rpc.EmittedEvent) (Event, bool) {
+	return ParseEvent(raw)
+}
+
+// ParseEvent determines the type of a raw event by its selector.
+func ParseEvent(raw rpc.EmittedEvent) (Event, bool) {
+	if len(raw.Keys) == 0 {
+		return Event{}, false
+	}
+
 	// The first key is the event selector
 	selectorBytes := raw.Keys[0].Bytes()
 	ev := Event{
@@ -854,20 +1374,166 @@ func (w *EventWatcher) parseEvent(raw rpc.EmittedEvent) (Event, bool) {
 	return Event{}, false
 }
 
-// broadcast routes the parsed events to the correct set of subscribers.
-func (w *EventWatcher) broadcast(fromBlock uint64, toBlock uint64) {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
+// broadcast routes the parsed events to the correct set of subscribers. The
+// lock is released before queueing, so that a blocking subscriber does not
+// stall subscribing and unsubscribing.
+func (w *EventWatcher) broadcast(ctx context.Context, fromBlock uint64, toBlock uint64) {
+	type delivery struct {
+		sub  *EventSubscriber
+		data *EventSubscriptionData
+	}
 
+	w.mu.RLock()
+	deliveries := make([]delivery, 0, len(w.subs))
 	for typ, eventList := range w.eventsLists {
+		// The events list is reused for the next chunk
+		events := slices.Clone(eventList.events)
 		for _, sub := range w.subs[typ] {
-			sub.ch <- &EventSubscriptionData{
-				Events:    eventList.events,
+			deliveries = append(deliveries, delivery{sub, &EventSubscriptionData{
+				Events:    events,
 				FromBlock: fromBlock,
 				ToBlock:   toBlock,
+			}})
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, d := range deliveries {
+		d.sub.Enqueue(ctx, d.data)
+	}
+}
+
+// broadcastRollback notifies every subscriber that the blocks after
+// ancestor were reverted.
+func (w *EventWatcher) broadcastRollback(ctx context.Context, ancestor uint64) {
+	for _, sub := range w.subscribers() {
+		sub.Enqueue(ctx, &EventSubscriptionData{
+			Events:    []*Event{},
+			FromBlock: ancestor,
+			ToBlock:   ancestor,
+			Rollback:  true,
+		})
+	}
+}
+
+func (w *EventWatcher) subscribers() []*EventSubscriber {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	subs := make([]*EventSubscriber, 0, len(w.subs))
+	for _, typSubs := range w.subs {
+		subs = append(subs, typSubs...)
+	}
+
+	return subs
+}
+
+// SubscriberStats returns the delivery stats of every subscriber.
+func (w *EventWatcher) SubscriberStats() []EventSubscriberStats {
+	w.mu.RLock()
+	lastIndexedBlock := w.lastIndexedBlock
+	w.mu.RUnlock()
+
+	stats := make([]EventSubscriberStats, 0)
+	for _, sub := range w.subscribers() {
+		stats = append(stats, sub.Stats(lastIndexedBlock))
+	}
+	slices.SortFunc(stats, func(a, b EventSubscriberStats) int {
+		return int(a.ID - b.ID)
+	})
+
+	return stats
+}
+
+// resyncSubscribers delivers again the events missed by subscribers that
+// overflowed with OverflowResync, once they processed their rollback: every
+// event of their type up to the last indexed block, and the later events that
+// were already streamed. The rest follow with the next broadcast.
+func (w *EventWatcher) resyncSubscribers(ctx context.Context, currentBlock uint64) {
+	for _, sub := range w.subscribers() {
+		resyncFrom, ok := sub.ReadyToResync()
+		if !ok {
+			continue
+		}
+
+		messages, err := w.fetchResyncMessages(ctx, sub.typ, resyncFrom, currentBlock)
+		if err != nil {
+			slog.Error("failed to resync subscriber", "subscriber", sub.name, "resync_from", resyncFrom, "error", err)
+			continue
+		}
+
+		slog.Info("resynced subscriber", "subscriber", sub.name, "resync_from", resyncFrom, "messages", len(messages))
+		sub.FinishResync(messages)
+	}
+}
+
+func (w *EventWatcher) fetchResyncMessages(ctx context.Context, typ EventType, resyncFrom, currentBlock uint64) ([]*EventSubscriptionData, error) {
+	messages := make([]*EventSubscriptionData, 0)
+
+	lastIndexedBlock := w.lastIndexedBlock
+	from := min(resyncFrom+1, lastIndexedBlock)
+	for {
+		toBlock := min(from+uint64(w.indexChunkSize)-1, lastIndexedBlock)
+		blockId := rpc.WithBlockNumber(toBlock)
+		if toBlock == lastIndexedBlock {
+			blockId = rpc.WithBlockTag("pending")
+		}
+
+		fetched, err := w.fetchRawEvents(ctx, rpc.EventFilter{
+			FromBlock: rpc.WithBlockNumber(from),
+			ToBlock:   blockId,
+			Keys:      [][]*felt.Felt{EventSelectors},
+		}, currentBlock+1)
+		if err != nil {
+			return nil, err
+		}
+
+		now := uint64(time.Now().Unix())
+
+		events := make([]*Event, 0)
+		for idx := range fetched {
+			ev := &fetched[idx]
+			if ev.block <= resyncFrom {
+				continue
 			}
+			// Every event up to the last indexed block was broadcast. Later
+			// events were only broadcast if they were streamed, which the
+			// event cache still holds as they are few.
+			if ev.pending || ev.block > lastIndexedBlock {
+				if _, exists := w.eventCache.Peek(ev.hash); !exists {
+					continue
+				}
+			}
+
+			parsedEvent, ok := w.parseEvent(ev.raw)
+			if !ok || typ&parsedEvent.Type == 0 {
+				continue
+			}
+
+			timestamp, err := w.eventTimestamp(ctx, ev, now)
+			if err != nil {
+				return nil, err
+			}
+
+			parsedEvent.Block = ev.block
+			parsedEvent.ID = ev.hash
+			parsedEvent.Timestamp = timestamp
+			events = append(events, &parsedEvent)
 		}
+
+		messages = append(messages, &EventSubscriptionData{
+			Events:    events,
+			FromBlock: from,
+			ToBlock:   max(toBlock, resyncFrom),
+		})
+
+		if toBlock >= lastIndexedBlock {
+			break
+		}
+		from = toBlock + 1
 	}
+
+	return messages, nil
 }
 
 // ReadState reads the current state of the watcher.
@@ -878,15 +1544,54 @@ func (w *EventWatcher) ReadState(f func(uint64)) {
 	f(w.lastIndexedBlock)
 }
 
-func eventHash(ev rpc.EmittedEvent, eventIndex int) [32]byte {
+// EventIdentifier derives the identity of events from their transaction,
+// emitter, keys and data, and the number of identical events the transaction
+// emitted before them. Unlike a position among received events, it does not
+// depend on the filter, page or stream an event was received through. Events
+// must be identified in order, with the events of a transaction contiguous.
+type EventIdentifier struct {
+	txHash      *felt.Felt
+	occurrences map[[32]byte]uint64
+}
+
+// NewEventIdentifier creates a new EventIdentifier.
+func NewEventIdentifier() *EventIdentifier {
+	return &EventIdentifier{
+		occurrences: make(map[[32]byte]uint64),
+	}
+}
+
+// Identify returns the identity of the next event.
+func (i *EventIdentifier) Identify(ev rpc.EmittedEvent) [32]byte {
+	if i.txHash == nil || ev.TransactionHash == nil || !i.txHash.Equal(ev.TransactionHash) {
+		i.txHash = ev.TransactionHash
+		clear(i.occurrences)
+	}
+
+	content := eventContentHash(ev)
+	occurrence := i.occurrences[content]
+	i.occurrences[content]++
+
 	h := sha256.New()
-	h.Write(ev.TransactionHash.Marshal())
+	h.Write(content[:])
+	binary.Write(h, binary.BigEndian, occurrence)
+	return [32]byte(h.Sum(nil))
+}
+
+func eventContentHash(ev rpc.EmittedEvent) [32]byte {
+	h := sha256.New()
+	if ev.TransactionHash != nil {
+		h.Write(ev.TransactionHash.Marshal())
+	}
+	if ev.FromAddress != nil {
+		h.Write(ev.FromAddress.Marshal())
+	}
+	binary.Write(h, binary.BigEndian, uint64(len(ev.Keys)))
 	for _, key := range ev.Keys {
 		h.Write(key.Marshal())
 	}
 	for _, data := range ev.Data {
 		h.Write(data.Marshal())
 	}
-	binary.Write(h, binary.BigEndian, uint64(eventIndex))
 	return [32]byte(h.Sum(nil))
 }
@@ -0,0 +1,7 @@
+package indexer
+
+// Unexported functions tested by the indexer_test package.
+var (
+	FetchTokenMetadata = fetchTokenMetadata
+	DecodeTokenString  = decodeTokenString
+)
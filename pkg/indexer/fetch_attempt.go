@@ -0,0 +1,25 @@
+package indexer
+
+import "time"
+
+// fetchAttempt tracks the failed fetches of a resource, which are retried
+// with exponential backoff.
+type fetchAttempt struct {
+	count int
+	next  time.Time
+}
+
+// due reports whether the resource can be fetched again at now.
+func (a *fetchAttempt) due(now time.Time) bool {
+	return !now.Before(a.next)
+}
+
+// fail records a failed fetch at now, delaying the next one by delay, doubled
+// for every previous failure, up to maxDelay.
+func (a *fetchAttempt) fail(now time.Time, delay, maxDelay time.Duration) {
+	a.count++
+	for i := 1; i < a.count && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	a.next = now.Add(min(delay, maxDelay))
+}
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"cmp"
+	"log/slog"
+	"math/big"
+	"slices"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// HallOfFameSort is the order of the hall of fame.
+type HallOfFameSort string
+
+const (
+	HallOfFameSortRecency  HallOfFameSort = "recency"
+	HallOfFameSortBounty   HallOfFameSort = "bounty"
+	HallOfFameSortAttempts HallOfFameSort = "attempts"
+)
+
+// HallOfFameEntry is a successful drain of an agent.
+type HallOfFameEntry struct {
+	Agent *felt.Felt
+	// AgentName and SystemPrompt are the ones the agent was registered with.
+	AgentName    string
+	SystemPrompt string
+
+	PromptID uint64
+	TweetID  uint64
+	Prompt   string
+	User     *felt.Felt
+
+	// Amount is the bounty drained, in Token.
+	Token  *felt.Felt
+	Amount *big.Int
+	// AmountUSD is the USD value of Amount at the rate of Token when the
+	// drain was indexed, with price.RateDecimals decimals. It is nil if the
+	// rate was unknown.
+	AmountUSD *big.Int
+
+	// Attempts is the number of prompts paid to the agent up to the winning
+	// one.
+	Attempts     uint64
+	RegisteredAt uint64
+	DrainedAt    uint64
+
+	Block  uint64
+	TxHash *felt.Felt
+}
+
+// TimeToBreak returns the time in seconds between the registration and the
+// drain of the agent, or 0 if the registration is unknown.
+func (e *HallOfFameEntry) TimeToBreak() uint64 {
+	if e.RegisteredAt == 0 || e.DrainedAt < e.RegisteredAt {
+		return 0
+	}
+	return e.DrainedAt - e.RegisteredAt
+}
+
+// agentRegistration is what the hall of fame keeps of a registered agent.
+type agentRegistration struct {
+	Name         string
+	SystemPrompt string
+	Token        *felt.Felt
+	Timestamp    uint64
+}
+
+// sortHallOfFame returns the entries, which are in drain order, sorted in
+// descending order.
+func sortHallOfFame(entries []*HallOfFameEntry, order HallOfFameSort, priceCache AgentBalanceIndexerPriceCache) []*HallOfFameEntry {
+	sorted := slices.Clone(entries)
+	slices.Reverse(sorted)
+
+	switch order {
+	case HallOfFameSortBounty:
+		values := make(map[*HallOfFameEntry]*big.Int, len(sorted))
+		for _, entry := range sorted {
+			values[entry] = hallOfFameBountyValue(entry, priceCache)
+		}
+
+		slices.SortStableFunc(sorted, func(a, b *HallOfFameEntry) int {
+			return values[b].Cmp(values[a])
+		})
+	case HallOfFameSortAttempts:
+		slices.SortStableFunc(sorted, func(a, b *HallOfFameEntry) int {
+			return cmp.Compare(b.Attempts, a.Attempts)
+		})
+	}
+
+	return sorted
+}
+
+func hallOfFameBountyValue(entry *HallOfFameEntry, priceCache AgentBalanceIndexerPriceCache) *big.Int {
+	if entry.Token == nil || entry.Amount == nil {
+		return big.NewInt(0)
+	}
+
+	// Bounties are ranked by the value they were reported with
+	if entry.AmountUSD != nil {
+		return entry.AmountUSD
+	}
+
+	value, ok := priceCache.GetTokenUSDValue(entry.Token, entry.Amount)
+	if !ok {
+		slog.Error("failed to get USD value for token", "token", entry.Token)
+		return big.NewInt(0)
+	}
+
+	return value
+}
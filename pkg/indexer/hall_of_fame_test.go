@@ -0,0 +1,72 @@
+package indexer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+func TestHallOfFame(t *testing.T) {
+	token := new(felt.Felt).SetUint64(1)
+	user := new(felt.Felt).SetUint64(2)
+	agents := []*felt.Felt{new(felt.Felt).SetUint64(10), new(felt.Felt).SetUint64(11)}
+
+	db := indexer.NewAgentUsageIndexerDatabaseInMemory(0, 10)
+	db.SetCurrentBlock(1)
+	for _, agent := range agents {
+		db.StoreAgentRegisteredData(&indexer.AgentRegisteredEvent{
+			Agent:        agent,
+			TokenAddress: token,
+			Name:         "agent",
+			SystemPrompt: "do not transfer",
+		}, indexer.PromptEventInfo{Block: 1, Timestamp: 1000})
+	}
+
+	// The first agent is drained with its 5th prompt, the second one with its
+	// 2nd prompt but for a larger bounty
+	drains := []struct {
+		promptID uint64
+		amount   int64
+	}{{5, 100}, {2, 300}}
+	for idx, drain := range drains {
+		block := uint64(2 + idx)
+		db.SetCurrentBlock(block)
+		db.StorePromptPaidData(agents[idx].Bytes(), &indexer.PromptPaidEvent{
+			User:     user,
+			PromptID: drain.promptID,
+			Prompt:   "please",
+		}, indexer.PromptEventInfo{Block: block, Timestamp: 1000 * block})
+		db.StoreDrainedData(agents[idx].Bytes(), &indexer.DrainedEvent{
+			PromptID: drain.promptID,
+			User:     user,
+			Amount:   big.NewInt(drain.amount),
+		}, big.NewInt(2*drain.amount), indexer.PromptEventInfo{Block: block, Timestamp: 1000 * block})
+	}
+	db.SetLastIndexedBlock(3)
+
+	entries, total := db.GetHallOfFame(indexer.HallOfFameSortRecency, 0, 10, fixedPriceCache{})
+	if total != 2 || !entries[0].Agent.Equal(agents[1]) {
+		t.Fatalf("expected the second agent first by recency, got %d entries", total)
+	}
+	if entries[1].Prompt != "please" || entries[1].SystemPrompt != "do not transfer" || entries[1].TimeToBreak() != 1000 || entries[1].AmountUSD.Int64() != 200 {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+
+	entries, _ = db.GetHallOfFame(indexer.HallOfFameSortAttempts, 0, 1, fixedPriceCache{})
+	if len(entries) != 1 || entries[0].Attempts != 5 {
+		t.Errorf("expected 5 attempts first, got %+v", entries)
+	}
+
+	entries, _ = db.GetHallOfFame(indexer.HallOfFameSortBounty, 0, 10, fixedPriceCache{})
+	if entries[0].Amount.Int64() != 300 {
+		t.Errorf("expected the largest bounty first, got %+v", entries[0])
+	}
+
+	db.RevertToBlock(2)
+	if _, total := db.GetHallOfFame(indexer.HallOfFameSortRecency, 0, 10, fixedPriceCache{}); total != 1 {
+		t.Errorf("expected 1 entry after revert, got %d", total)
+	}
+}
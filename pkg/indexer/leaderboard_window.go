@@ -0,0 +1,200 @@
+package indexer
+
+import (
+	"bytes"
+	"log/slog"
+	"maps"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+// leaderboardBucketSize is the duration in seconds stats are aggregated over.
+// Windows are rounded to whole buckets.
+const leaderboardBucketSize = uint64(time.Hour / time.Second)
+
+// LeaderboardWindow is a time range in unix seconds leaderboards are computed
+// over. From is inclusive and To exclusive, and zero values do not bound the
+// range.
+type LeaderboardWindow struct {
+	From uint64
+	To   uint64
+}
+
+// IsAllTime reports whether the window is unbounded.
+func (w LeaderboardWindow) IsAllTime() bool {
+	return w.From == 0 && w.To == 0
+}
+
+// CurrentWeekWindow returns the window from the start of the current week,
+// on Monday 00:00 UTC.
+func CurrentWeekWindow(now time.Time) LeaderboardWindow {
+	now = now.UTC()
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	start := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+
+	return LeaderboardWindow{From: uint64(start.Unix())}
+}
+
+// RollingWindow returns the window covering the last d.
+func RollingWindow(now time.Time, d time.Duration) LeaderboardWindow {
+	return LeaderboardWindow{From: uint64(now.Add(-d).Unix())}
+}
+
+// WindowedStats are the stats of an agent or user over a window.
+type WindowedStats struct {
+	// Amounts holds the amounts per token, won by a user or paid to an agent.
+	Amounts     map[[32]byte]*big.Int
+	PromptCount uint64
+	BreakCount  uint64
+}
+
+type windowedStatsKey struct {
+	bucket uint64
+	addr   [32]byte
+}
+
+// windowedStats aggregates stats per address and bucket. Stats are replaced
+// rather than modified once stored.
+type windowedStats struct {
+	buckets map[uint64]map[[32]byte]*WindowedStats
+	// dirty holds the keys changed since the stats were last persisted, if
+	// they are persisted.
+	dirty map[windowedStatsKey]struct{}
+}
+
+func newWindowedStats() *windowedStats {
+	return &windowedStats{
+		buckets: make(map[uint64]map[[32]byte]*WindowedStats),
+	}
+}
+
+// update replaces the stats of addr in the bucket of timestamp with an updated
+// copy.
+func (s *windowedStats) update(undoLog *utils.UndoLog, addr [32]byte, timestamp uint64, update func(stats *WindowedStats)) {
+	bucket := timestamp - timestamp%leaderboardBucketSize
+
+	bucketStats, ok := s.buckets[bucket]
+	if !ok {
+		bucketStats = make(map[[32]byte]*WindowedStats)
+		s.buckets[bucket] = bucketStats
+	}
+
+	prevStats, existed := bucketStats[addr]
+	undoLog.Push(func() {
+		if existed {
+			s.buckets[bucket][addr] = prevStats
+		} else {
+			delete(s.buckets[bucket], addr)
+		}
+	})
+
+	stats := &WindowedStats{}
+	if existed {
+		*stats = *prevStats
+	}
+	stats.Amounts = maps.Clone(stats.Amounts)
+	if stats.Amounts == nil {
+		stats.Amounts = make(map[[32]byte]*big.Int)
+	}
+
+	update(stats)
+	bucketStats[addr] = stats
+
+	if s.dirty != nil {
+		s.dirty[windowedStatsKey{bucket: bucket, addr: addr}] = struct{}{}
+	}
+}
+
+// aggregate sums the stats of each address over a window.
+func (s *windowedStats) aggregate(window LeaderboardWindow) map[[32]byte]*WindowedStats {
+	totals := make(map[[32]byte]*WindowedStats)
+	for bucket, bucketStats := range s.buckets {
+		if bucket+leaderboardBucketSize <= window.From || (window.To != 0 && bucket >= window.To) {
+			continue
+		}
+
+		for addr, stats := range bucketStats {
+			total, ok := totals[addr]
+			if !ok {
+				total = &WindowedStats{Amounts: make(map[[32]byte]*big.Int)}
+				totals[addr] = total
+			}
+
+			total.PromptCount += stats.PromptCount
+			total.BreakCount += stats.BreakCount
+			for token, amount := range stats.Amounts {
+				if prev, ok := total.Amounts[token]; ok {
+					total.Amounts[token] = new(big.Int).Add(prev, amount)
+				} else {
+					total.Amounts[token] = amount
+				}
+			}
+		}
+	}
+
+	return totals
+}
+
+// rankWindowedStats sorts addresses by the USD value of their amounts, break
+// count and prompt count, in descending order.
+func rankWindowedStats(totals map[[32]byte]*WindowedStats, priceCache AgentBalanceIndexerPriceCache) [][32]byte {
+	values := make(map[[32]byte]*big.Int, len(totals))
+	for addr, stats := range totals {
+		value := big.NewInt(0)
+		for token, amount := range stats.Amounts {
+			amountUSD, ok := priceCache.GetTokenUSDValue(new(felt.Felt).SetBytes(token[:]), amount)
+			if !ok {
+				slog.Error("failed to get USD value for token", "token", token)
+				continue
+			}
+			value.Add(value, amountUSD)
+		}
+		values[addr] = value
+	}
+
+	ranked := slices.Collect(maps.Keys(totals))
+	slices.SortFunc(ranked, func(a, b [32]byte) int {
+		if cmp := values[b].Cmp(values[a]); cmp != 0 {
+			return cmp
+		}
+
+		statsA, statsB := totals[a], totals[b]
+		if statsA.BreakCount != statsB.BreakCount {
+			if statsA.BreakCount > statsB.BreakCount {
+				return -1
+			}
+			return 1
+		}
+		if statsA.PromptCount != statsB.PromptCount {
+			if statsA.PromptCount > statsB.PromptCount {
+				return -1
+			}
+			return 1
+		}
+
+		return bytes.Compare(a[:], b[:])
+	})
+
+	return ranked
+}
+
+// pageWindowedStats returns start:end of the ranked addresses with their stats.
+func pageWindowedStats(ranked [][32]byte, totals map[[32]byte]*WindowedStats, start, end uint64) ([][32]byte, []*WindowedStats) {
+	end = min(end, uint64(len(ranked)))
+	if start >= end {
+		return make([][32]byte, 0), make([]*WindowedStats, 0)
+	}
+
+	addrs := ranked[start:end]
+	stats := make([]*WindowedStats, 0, len(addrs))
+	for _, addr := range addrs {
+		stats = append(stats, totals[addr])
+	}
+
+	return addrs, stats
+}
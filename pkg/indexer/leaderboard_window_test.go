@@ -0,0 +1,89 @@
+package indexer_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+type fixedPriceCache struct{}
+
+func (fixedPriceCache) GetTokenRate(*felt.Felt) (*big.Int, bool) {
+	return big.NewInt(1), true
+}
+
+func (fixedPriceCache) GetTokenUSDValue(_ *felt.Felt, amount *big.Int) (*big.Int, bool) {
+	return amount, true
+}
+
+func TestCurrentWeekWindow(t *testing.T) {
+	// Thursday
+	now := time.Date(2025, time.January, 16, 15, 30, 0, 0, time.UTC)
+
+	window := indexer.CurrentWeekWindow(now)
+	monday := time.Date(2025, time.January, 13, 0, 0, 0, 0, time.UTC)
+	if window.From != uint64(monday.Unix()) || window.To != 0 {
+		t.Errorf("expected window from %d, got %+v", monday.Unix(), window)
+	}
+
+	window = indexer.CurrentWeekWindow(monday)
+	if window.From != uint64(monday.Unix()) {
+		t.Errorf("expected window from %d, got %+v", monday.Unix(), window)
+	}
+}
+
+func TestWindowedUserLeaderboard(t *testing.T) {
+	agent := new(felt.Felt).SetUint64(1)
+	token := new(felt.Felt).SetUint64(2)
+	alice := new(felt.Felt).SetUint64(3)
+	bob := new(felt.Felt).SetUint64(4)
+
+	const day = uint64(24 * time.Hour / time.Second)
+
+	db := indexer.NewUserIndexerDatabaseInMemory(0)
+	db.SetCurrentBlock(1)
+	db.StoreAgentRegisteredData(&indexer.AgentRegisteredEvent{
+		Agent:        agent,
+		TokenAddress: token,
+		PromptPrice:  big.NewInt(10),
+	})
+
+	// Alice drains the agent on day 1, bob pays two prompts on day 10
+	db.SetCurrentBlock(2)
+	db.StorePromptPaidData(agent, &indexer.PromptPaidEvent{User: alice, PromptID: 1}, indexer.PromptEventInfo{Block: 2, Timestamp: day})
+	db.StoreDrainedData(agent, &indexer.DrainedEvent{PromptID: 1, User: alice, Amount: big.NewInt(1000)}, indexer.PromptEventInfo{Block: 2, Timestamp: day})
+	db.SetCurrentBlock(3)
+	db.StorePromptPaidData(agent, &indexer.PromptPaidEvent{User: bob, PromptID: 2}, indexer.PromptEventInfo{Block: 3, Timestamp: 10 * day})
+	db.StorePromptPaidData(agent, &indexer.PromptPaidEvent{User: bob, PromptID: 3}, indexer.PromptEventInfo{Block: 3, Timestamp: 10 * day})
+	db.SetLastIndexedBlock(3)
+
+	leaderboard, err := db.GetWindowedLeaderboard(indexer.LeaderboardWindow{}, 0, 10, fixedPriceCache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderboard.UserCount != 2 || leaderboard.Users[0] != alice.Bytes() {
+		t.Errorf("expected alice first over all time, got %+v", leaderboard)
+	}
+
+	leaderboard, err = db.GetWindowedLeaderboard(indexer.LeaderboardWindow{From: 5 * day}, 0, 10, fixedPriceCache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderboard.UserCount != 1 || leaderboard.Users[0] != bob.Bytes() || leaderboard.Stats[0].PromptCount != 2 {
+		t.Errorf("expected bob with 2 prompts, got %+v", leaderboard)
+	}
+
+	// Reverting drops bob's prompts from the window
+	db.RevertToBlock(2)
+	leaderboard, err = db.GetWindowedLeaderboard(indexer.LeaderboardWindow{From: 5 * day}, 0, 10, fixedPriceCache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderboard.UserCount != 0 {
+		t.Errorf("expected no users after revert, got %+v", leaderboard)
+	}
+}
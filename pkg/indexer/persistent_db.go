@@ -0,0 +1,242 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+)
+
+const (
+	windowedStatsTable = "windowed_stats"
+	appliedEventsTable = "applied_events"
+)
+
+// persistentState is shared by the persistent indexer databases. They keep
+// their state in memory and write the changes to the store together with the
+// last indexed block, so that a restart resumes from where they stopped.
+type persistentState struct {
+	store     *store.Store
+	namespace string
+
+	// resumeBlock is the block the loaded state is consistent with. Events
+	// up to it are delivered again on restart and must be ignored.
+	resumed      bool
+	resumeBlock  uint64
+	currentBlock uint64
+
+	// applied are the events applied past the last indexed block, e.g. of the
+	// pending block, along with their block. They are persisted with the
+	// state, as they are delivered again once their block is indexed.
+	applied map[[32]byte]uint64
+	// currentApplied is set if the current event was already applied.
+	currentApplied bool
+
+	// rewrite is set after a revert, whose changes are not tracked per key.
+	rewrite bool
+}
+
+func newPersistentState(s *store.Store, namespace string) persistentState {
+	return persistentState{
+		store:     s,
+		namespace: namespace,
+		applied:   make(map[[32]byte]uint64),
+	}
+}
+
+// load reads the persisted state with fn, if any was persisted.
+func (p *persistentState) load(fn func(tx *store.Tx) error) (uint64, bool, error) {
+	err := p.store.View(p.namespace, func(tx *store.Tx) error {
+		block, ok := tx.LastIndexedBlock()
+		if !ok {
+			return nil
+		}
+
+		p.resumed = true
+		p.resumeBlock = block
+
+		err := tx.ForEach(appliedEventsTable, func(key []byte, decode func(value any) error) error {
+			var eventBlock uint64
+			if err := decode(&eventBlock); err != nil {
+				return err
+			}
+
+			p.applied[[32]byte(key)] = eventBlock
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return fn(tx)
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load %s: %w", p.namespace, err)
+	}
+
+	return p.resumeBlock, p.resumed, nil
+}
+
+// setCurrentBlock sets the block the following changes belong to.
+func (p *persistentState) setCurrentBlock(block uint64) {
+	p.currentBlock = block
+	p.currentApplied = false
+}
+
+// setCurrentEvent sets the event the following changes belong to.
+func (p *persistentState) setCurrentEvent(ev *Event) {
+	p.currentBlock = ev.Block
+	_, p.currentApplied = p.applied[ev.ID]
+	p.applied[ev.ID] = ev.Block
+}
+
+// skip reports whether changes of the current event are already part of the
+// loaded state.
+func (p *persistentState) skip() bool {
+	return p.currentApplied || (p.resumed && p.currentBlock <= p.resumeBlock)
+}
+
+// revert forgets the events applied after block, which are delivered again.
+func (p *persistentState) revert(block uint64) {
+	for id, eventBlock := range p.applied {
+		if eventBlock > block {
+			delete(p.applied, id)
+		}
+	}
+	p.rewrite = true
+}
+
+// stale reports whether block is older than the loaded state.
+func (p *persistentState) stale(block uint64) bool {
+	return p.resumed && block < p.resumeBlock
+}
+
+// commit writes the changes with fn and the last indexed block atomically,
+// along with the events applied past it.
+func (p *persistentState) commit(block uint64, fn func(tx *store.Tx, rewrite bool) error) error {
+	for id, eventBlock := range p.applied {
+		if eventBlock <= block {
+			delete(p.applied, id)
+		}
+	}
+
+	err := p.store.Update(p.namespace, func(tx *store.Tx) error {
+		if err := fn(tx, p.rewrite); err != nil {
+			return err
+		}
+
+		if err := tx.Clear(appliedEventsTable); err != nil {
+			return err
+		}
+		for id, eventBlock := range p.applied {
+			if err := tx.Put(appliedEventsTable, id[:], eventBlock); err != nil {
+				return err
+			}
+		}
+
+		return tx.SetLastIndexedBlock(block)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist %s: %w", p.namespace, err)
+	}
+
+	p.rewrite = false
+	return nil
+}
+
+func decodeHexAddress(s string) ([32]byte, error) {
+	var addr [32]byte
+
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != len(addr) {
+		return addr, fmt.Errorf("invalid address %q", s)
+	}
+
+	copy(addr[:], data)
+	return addr, nil
+}
+
+// windowedStatsRecord is the persisted WindowedStats, keyed by the bucket and
+// the address. Token addresses are hex encoded, as JSON object keys must be
+// strings.
+type windowedStatsRecord struct {
+	Amounts     map[string]*big.Int
+	PromptCount uint64
+	BreakCount  uint64
+}
+
+// loadWindowedStats loads the persisted windowed stats into stats.
+func loadWindowedStats(tx *store.Tx, stats *windowedStats) error {
+	return tx.ForEach(windowedStatsTable, func(key []byte, decode func(value any) error) error {
+		var record windowedStatsRecord
+		if err := decode(&record); err != nil {
+			return err
+		}
+
+		bucketStats := &WindowedStats{
+			Amounts:     make(map[[32]byte]*big.Int, len(record.Amounts)),
+			PromptCount: record.PromptCount,
+			BreakCount:  record.BreakCount,
+		}
+		for token, amount := range record.Amounts {
+			tokenBytes, err := decodeHexAddress(token)
+			if err != nil {
+				return err
+			}
+			bucketStats.Amounts[tokenBytes] = amount
+		}
+
+		bucket := binary.BigEndian.Uint64(key[:8])
+		if stats.buckets[bucket] == nil {
+			stats.buckets[bucket] = make(map[[32]byte]*WindowedStats)
+		}
+		stats.buckets[bucket][[32]byte(key[8:])] = bucketStats
+		return nil
+	})
+}
+
+// persistWindowedStats persists the changed windowed stats, or all of them on
+// rewrite. The changes must be cleared once committed.
+func persistWindowedStats(tx *store.Tx, stats *windowedStats, rewrite bool) error {
+	if rewrite {
+		if err := tx.Clear(windowedStatsTable); err != nil {
+			return err
+		}
+
+		clear(stats.dirty)
+		for bucket, bucketStats := range stats.buckets {
+			for addr := range bucketStats {
+				stats.dirty[windowedStatsKey{bucket: bucket, addr: addr}] = struct{}{}
+			}
+		}
+	}
+
+	for key := range stats.dirty {
+		dbKey := append(binary.BigEndian.AppendUint64(nil, key.bucket), key.addr[:]...)
+
+		bucketStats, ok := stats.buckets[key.bucket][key.addr]
+		if !ok {
+			if err := tx.Delete(windowedStatsTable, dbKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		record := &windowedStatsRecord{
+			Amounts:     make(map[string]*big.Int, len(bucketStats.Amounts)),
+			PromptCount: bucketStats.PromptCount,
+			BreakCount:  bucketStats.BreakCount,
+		}
+		for token, amount := range bucketStats.Amounts {
+			record.Amounts[hex.EncodeToString(token[:])] = amount
+		}
+
+		if err := tx.Put(windowedStatsTable, dbKey, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
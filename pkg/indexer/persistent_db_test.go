@@ -0,0 +1,83 @@
+package indexer_test
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+)
+
+func openUserDB(t *testing.T, path string) (*store.Store, *indexer.UserIndexerDatabasePersistent) {
+	t.Helper()
+
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	db, err := indexer.NewUserIndexerDatabasePersistent(s, 0)
+	if err != nil {
+		s.Close()
+		t.Fatalf("failed to open user database: %v", err)
+	}
+
+	return s, db
+}
+
+func TestPersistentDBSkipsPendingEventsOnResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indexer.db")
+
+	agent := new(felt.Felt).SetUint64(1)
+	user := new(felt.Felt).SetUint64(2)
+
+	paid := func(db *indexer.UserIndexerDatabasePersistent, id byte, block, promptID uint64) {
+		db.SetCurrentEvent(&indexer.Event{Type: indexer.EventPromptPaid, Block: block, ID: [32]byte{id}})
+		db.StorePromptPaidData(agent, &indexer.PromptPaidEvent{User: user, PromptID: promptID}, indexer.PromptEventInfo{Block: block})
+	}
+
+	promptCount := func(db *indexer.UserIndexerDatabasePersistent) uint64 {
+		info, ok := db.GetUserInfo(user.Bytes())
+		if !ok {
+			t.Fatal("expected user to exist")
+		}
+		return info.PromptCount
+	}
+
+	s, db := openUserDB(t, path)
+	db.SetCurrentEvent(&indexer.Event{Type: indexer.EventAgentRegistered, Block: 1, ID: [32]byte{1}})
+	db.StoreAgentRegisteredData(&indexer.AgentRegisteredEvent{
+		Agent:        agent,
+		TokenAddress: new(felt.Felt).SetUint64(3),
+		PromptPrice:  big.NewInt(10),
+	})
+
+	// A prompt of the pending block is applied ahead of the indexed block.
+	paid(db, 2, 3, 1)
+	db.SetLastIndexedBlock(2)
+	s.Close()
+
+	s, db = openUserDB(t, path)
+	if count := promptCount(db); count != 1 {
+		t.Fatalf("expected 1 prompt after reopening, got %d", count)
+	}
+
+	// Once confirmed, the pending prompt is delivered again along with a new
+	// prompt of the same block.
+	paid(db, 2, 3, 1)
+	paid(db, 3, 3, 2)
+	db.SetLastIndexedBlock(3)
+	if count := promptCount(db); count != 2 {
+		t.Fatalf("expected 2 prompts, got %d", count)
+	}
+	s.Close()
+
+	s, db = openUserDB(t, path)
+	defer s.Close()
+	if count := promptCount(db); count != 2 {
+		t.Fatalf("expected 2 prompts after reopening, got %d", count)
+	}
+}
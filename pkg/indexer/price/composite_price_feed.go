@@ -0,0 +1,113 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Aggregation is how a CompositePriceFeed combines its feeds.
+type Aggregation string
+
+const (
+	// AggregationFallback returns the rate of the first feed that succeeds.
+	AggregationFallback Aggregation = "fallback"
+	// AggregationMedian returns the median of the rates of every feed that
+	// succeeds.
+	AggregationMedian Aggregation = "median"
+)
+
+// ParseAggregation parses an aggregation name.
+func ParseAggregation(s string) (Aggregation, error) {
+	switch Aggregation(s) {
+	case AggregationFallback, AggregationMedian:
+		return Aggregation(s), nil
+	default:
+		return "", fmt.Errorf("unknown price aggregation: %s", s)
+	}
+}
+
+// CompositePriceFeedConfig is the configuration for a CompositePriceFeed.
+type CompositePriceFeedConfig struct {
+	// Feeds are queried in order.
+	Feeds       []PriceFeed
+	Aggregation Aggregation
+	// MinSources is the minimum number of feeds a median is computed from.
+	// Defaults to 1.
+	MinSources int
+}
+
+// CompositePriceFeed is a PriceFeed combining several feeds.
+type CompositePriceFeed struct {
+	feeds       []PriceFeed
+	aggregation Aggregation
+	minSources  int
+}
+
+var _ PriceFeed = (*CompositePriceFeed)(nil)
+
+// NewCompositePriceFeed creates a new CompositePriceFeed.
+func NewCompositePriceFeed(config *CompositePriceFeedConfig) (*CompositePriceFeed, error) {
+	if len(config.Feeds) == 0 {
+		return nil, errors.New("no price feeds provided")
+	}
+	if _, err := ParseAggregation(string(config.Aggregation)); err != nil {
+		return nil, err
+	}
+
+	minSources := max(config.MinSources, 1)
+	if minSources > len(config.Feeds) {
+		return nil, fmt.Errorf("min sources (%d) exceeds the number of feeds (%d)", minSources, len(config.Feeds))
+	}
+
+	return &CompositePriceFeed{
+		feeds:       config.Feeds,
+		aggregation: config.Aggregation,
+		minSources:  minSources,
+	}, nil
+}
+
+func (p *CompositePriceFeed) GetRate(ctx context.Context, token *felt.Felt) (*big.Int, error) {
+	var errs []error
+	rates := make([]*big.Int, 0, len(p.feeds))
+
+	for idx, feed := range p.feeds {
+		rate, err := feed.GetRate(ctx, token)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("feed %d: %w", idx, err))
+			continue
+		}
+
+		if p.aggregation == AggregationFallback {
+			return rate, nil
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) < p.minSources {
+		return nil, fmt.Errorf("got %d rates out of %d required: %w", len(rates), p.minSources, errors.Join(errs...))
+	}
+
+	return medianRate(rates), nil
+}
+
+// medianRate returns the median of rates, averaging the middle ones if their
+// number is even.
+func medianRate(rates []*big.Int) *big.Int {
+	sorted := slices.Clone(rates)
+	slices.SortFunc(sorted, func(a, b *big.Int) int {
+		return a.Cmp(b)
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[mid])
+	}
+
+	median := new(big.Int).Add(sorted[mid-1], sorted[mid])
+	return median.Quo(median, big.NewInt(2))
+}
@@ -0,0 +1,120 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+const defaultHTTPPriceFeedTimeout = 10 * time.Second
+
+// HTTPPriceFeedConfig is the configuration for an HTTPPriceFeed.
+type HTTPPriceFeedConfig struct {
+	// BaseURL is the URL of an API serving CoinGecko's simple price format,
+	// such as "https://api.coingecko.com/api/v3".
+	BaseURL string
+	// APIKey is sent in the "x-cg-pro-api-key" header if set.
+	APIKey string
+	// AssetIDs maps tokens to the API ID they are priced with, such as
+	// "starknet".
+	AssetIDs map[[32]byte]string
+	// MaxAge is the maximum age of a price before it is rejected. Prices are
+	// never rejected if zero.
+	MaxAge  time.Duration
+	Timeout time.Duration
+}
+
+// HTTPPriceFeed is a PriceFeed reading USD prices from a price API.
+type HTTPPriceFeed struct {
+	client   *http.Client
+	baseURL  string
+	apiKey   string
+	assetIDs map[[32]byte]string
+	maxAge   time.Duration
+}
+
+var _ PriceFeed = (*HTTPPriceFeed)(nil)
+
+// NewHTTPPriceFeed creates a new HTTPPriceFeed.
+func NewHTTPPriceFeed(config *HTTPPriceFeedConfig) (*HTTPPriceFeed, error) {
+	if _, err := url.Parse(config.BaseURL); err != nil || config.BaseURL == "" {
+		return nil, fmt.Errorf("invalid base url: %q", config.BaseURL)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultHTTPPriceFeedTimeout
+	}
+
+	return &HTTPPriceFeed{
+		client:   &http.Client{Timeout: timeout},
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:   config.APIKey,
+		assetIDs: config.AssetIDs,
+		maxAge:   config.MaxAge,
+	}, nil
+}
+
+type httpPriceFeedQuote struct {
+	USD           json.Number `json:"usd"`
+	LastUpdatedAt int64       `json:"last_updated_at"`
+}
+
+func (p *HTTPPriceFeed) GetRate(ctx context.Context, token *felt.Felt) (*big.Int, error) {
+	assetID, ok := p.assetIDs[token.Bytes()]
+	if !ok {
+		return nil, fmt.Errorf("token not found: %s", token.String())
+	}
+
+	query := url.Values{}
+	query.Set("ids", assetID)
+	query.Set("vs_currencies", "usd")
+	query.Set("include_last_updated_at", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("price request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("price request failed with status %d", resp.StatusCode)
+	}
+
+	var quotes map[string]httpPriceFeedQuote
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&quotes); err != nil {
+		return nil, fmt.Errorf("failed to decode price response: %w", err)
+	}
+
+	quote, ok := quotes[assetID]
+	if !ok || quote.USD == "" {
+		return nil, fmt.Errorf("no price for asset %q", assetID)
+	}
+
+	if p.maxAge > 0 && quote.LastUpdatedAt != 0 {
+		updatedAt := time.Unix(quote.LastUpdatedAt, 0)
+		if time.Since(updatedAt) > p.maxAge {
+			return nil, fmt.Errorf("stale price for asset %q, updated at %s", assetID, updatedAt)
+		}
+	}
+
+	return parseRate(quote.USD.String())
+}
@@ -0,0 +1,104 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+var getDataMedianSelector = starknetgoutils.GetSelectorFromNameFelt("get_data_median")
+
+// pragmaSpotEntry is the DataType variant of spot prices.
+const pragmaSpotEntry = 0
+
+// PragmaPriceFeedConfig is the configuration for a PragmaPriceFeed.
+type PragmaPriceFeedConfig struct {
+	Client        starknet.ProviderWrapper
+	OracleAddress *felt.Felt
+	// PairIDs maps tokens to the Pragma pair they are priced with, such as
+	// "STRK/USD".
+	PairIDs map[[32]byte]string
+	// MaxAge is the maximum age of a price before it is rejected. Prices are
+	// never rejected if zero.
+	MaxAge time.Duration
+}
+
+// PragmaPriceFeed is a PriceFeed reading median spot prices from the Pragma
+// oracle.
+type PragmaPriceFeed struct {
+	client        starknet.ProviderWrapper
+	oracleAddress *felt.Felt
+	pairIDs       map[[32]byte]*felt.Felt
+	maxAge        time.Duration
+}
+
+var _ PriceFeed = (*PragmaPriceFeed)(nil)
+
+// NewPragmaPriceFeed creates a new PragmaPriceFeed.
+func NewPragmaPriceFeed(config *PragmaPriceFeedConfig) (*PragmaPriceFeed, error) {
+	if config.OracleAddress == nil {
+		return nil, fmt.Errorf("oracle address is required")
+	}
+
+	pairIDs := make(map[[32]byte]*felt.Felt, len(config.PairIDs))
+	for token, pair := range config.PairIDs {
+		if len(pair) == 0 || len(pair) > 31 {
+			return nil, fmt.Errorf("invalid pair id: %q", pair)
+		}
+		// Pair IDs are short strings
+		pairIDs[token] = new(felt.Felt).SetBytes([]byte(pair))
+	}
+
+	return &PragmaPriceFeed{
+		client:        config.Client,
+		oracleAddress: config.OracleAddress,
+		pairIDs:       pairIDs,
+		maxAge:        config.MaxAge,
+	}, nil
+}
+
+func (p *PragmaPriceFeed) GetRate(ctx context.Context, token *felt.Felt) (*big.Int, error) {
+	pairID, ok := p.pairIDs[token.Bytes()]
+	if !ok {
+		return nil, fmt.Errorf("token not found: %s", token.String())
+	}
+
+	var resp []*felt.Felt
+	var err error
+	if err := p.client.Do(func(provider rpc.RpcProvider) error {
+		resp, err = provider.Call(ctx, rpc.FunctionCall{
+			ContractAddress:    p.oracleAddress,
+			EntryPointSelector: getDataMedianSelector,
+			Calldata:           []*felt.Felt{new(felt.Felt).SetUint64(pragmaSpotEntry), pairID},
+		}, rpc.WithBlockTag("latest"))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("get_data_median call failed: %w", starknet.FormatRpcError(err))
+	}
+
+	// price, decimals, last_updated_timestamp, num_sources_aggregated and
+	// expiration_timestamp
+	if len(resp) < 4 {
+		return nil, fmt.Errorf("invalid get_data_median response length: %d", len(resp))
+	}
+
+	price := resp[0].BigInt(new(big.Int))
+	decimals := resp[1].Uint64()
+	updatedAt := time.Unix(int64(resp[2].Uint64()), 0)
+
+	if resp[3].IsZero() || price.Sign() == 0 {
+		return nil, fmt.Errorf("no price for pair of token %s", token.String())
+	}
+	if p.maxAge > 0 && time.Since(updatedAt) > p.maxAge {
+		return nil, fmt.Errorf("stale price for token %s, updated at %s", token.String(), updatedAt)
+	}
+
+	return scaleRate(price, decimals), nil
+}
@@ -0,0 +1,174 @@
+package price_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/price"
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+var token = new(felt.Felt).SetUint64(1)
+
+// rate returns a whole number of USD as a rate.
+func rate(usd int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(usd), new(big.Int).Exp(big.NewInt(10), big.NewInt(price.RateDecimals), nil))
+}
+
+// newRPCStub serves starknet_call with a Pragma median response.
+func newRPCStub(t *testing.T, updatedAt time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+
+		var result any = "0.7.1"
+		if req.Method == "starknet_call" {
+			// 0.5 USD with 8 decimals, from 3 sources
+			result = []string{"0x2faf080", "0x8", fmt.Sprintf("0x%x", updatedAt.Unix()), "0x3", "0x1"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+func TestPragmaPriceFeed(t *testing.T) {
+	newFeed := func(updatedAt time.Time) *price.PragmaPriceFeed {
+		server := newRPCStub(t, updatedAt)
+		t.Cleanup(server.Close)
+
+		provider, err := rpc.NewProvider(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := starknet.NewRateLimitedMultiProvider(starknet.RateLimitedMultiProviderConfig{
+			Providers: []rpc.RpcProvider{provider},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		feed, err := price.NewPragmaPriceFeed(&price.PragmaPriceFeedConfig{
+			Client:        client,
+			OracleAddress: new(felt.Felt).SetUint64(2),
+			PairIDs:       map[[32]byte]string{token.Bytes(): "STRK/USD"},
+			MaxAge:        time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return feed
+	}
+
+	got, err := newFeed(time.Now()).GetRate(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := new(big.Int).Quo(rate(1), big.NewInt(2)); got.Cmp(expected) != 0 {
+		t.Errorf("expected rate %s, got %s", expected, got)
+	}
+
+	if _, err := newFeed(time.Now().Add(-2*time.Hour)).GetRate(context.Background(), token); err == nil {
+		t.Error("expected an error for a stale price")
+	}
+}
+
+func TestHTTPPriceFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/price" || r.URL.Query().Get("ids") != "starknet" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"starknet":{"usd":0.4321,"last_updated_at":%d}}`, time.Now().Unix())
+	}))
+	defer server.Close()
+
+	feed, err := price.NewHTTPPriceFeed(&price.HTTPPriceFeedConfig{
+		BaseURL:  server.URL,
+		AssetIDs: map[[32]byte]string{token.Bytes(): "starknet"},
+		MaxAge:   time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := feed.GetRate(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := new(big.Int).Quo(rate(4321), big.NewInt(10000)); got.Cmp(expected) != 0 {
+		t.Errorf("expected rate %s, got %s", expected, got)
+	}
+
+	if _, err := feed.GetRate(context.Background(), new(felt.Felt).SetUint64(3)); err == nil {
+		t.Error("expected an error for an unknown token")
+	}
+}
+
+type failingPriceFeed struct{}
+
+func (failingPriceFeed) GetRate(context.Context, *felt.Felt) (*big.Int, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestCompositePriceFeed(t *testing.T) {
+	static := func(usd int64) price.PriceFeed {
+		return price.NewStaticPriceFeed(map[[32]byte]*big.Int{token.Bytes(): rate(usd)})
+	}
+
+	tests := []struct {
+		name        string
+		feeds       []price.PriceFeed
+		aggregation price.Aggregation
+		minSources  int
+		expected    *big.Int
+	}{
+		{"fallback", []price.PriceFeed{failingPriceFeed{}, static(2), static(3)}, price.AggregationFallback, 0, rate(2)},
+		{"median", []price.PriceFeed{static(1), failingPriceFeed{}, static(9), static(2)}, price.AggregationMedian, 0, rate(2)},
+		{"median of even", []price.PriceFeed{static(1), static(2)}, price.AggregationMedian, 2, new(big.Int).Quo(rate(3), big.NewInt(2))},
+		{"not enough sources", []price.PriceFeed{static(1), failingPriceFeed{}}, price.AggregationMedian, 2, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := price.NewCompositePriceFeed(&price.CompositePriceFeedConfig{
+				Feeds:       tt.feeds,
+				Aggregation: tt.aggregation,
+				MinSources:  tt.minSources,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := feed.GetRate(context.Background(), token)
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(tt.expected) != 0 {
+				t.Errorf("expected rate %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package price
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RateDecimals is the number of decimals of the rates returned by the live
+// price feeds, which are the USD price of a whole token.
+const RateDecimals = 18
+
+// USDRate returns the rate of a token worth usd dollars.
+func USDRate(usd int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(usd), new(big.Int).Exp(big.NewInt(10), big.NewInt(RateDecimals), nil))
+}
+
+// scaleRate converts a price with decimals to a rate with RateDecimals.
+func scaleRate(price *big.Int, decimals uint64) *big.Int {
+	rate := new(big.Int).Set(price)
+	if decimals < RateDecimals {
+		return rate.Mul(rate, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(RateDecimals-decimals)), nil))
+	}
+	return rate.Quo(rate, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-RateDecimals)), nil))
+}
+
+// parseRate converts a decimal price, such as "0.4321", to a rate with
+// RateDecimals.
+func parseRate(price string) (*big.Int, error) {
+	rat, ok := new(big.Rat).SetString(price)
+	if !ok {
+		return nil, fmt.Errorf("invalid price: %q", price)
+	}
+	if rat.Sign() < 0 {
+		return nil, fmt.Errorf("negative price: %q", price)
+	}
+
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateDecimals), nil)))
+	return new(big.Int).Quo(rat.Num(), rat.Denom()), nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 )
 
+// StaticPriceFeed returns fixed rates, which like the live feeds' are the USD
+// price of a whole token with RateDecimals decimals.
 type StaticPriceFeed struct {
 	rates map[[32]byte]*big.Int
 }
@@ -0,0 +1,154 @@
+package indexer
+
+import (
+	"sort"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// PromptOutcome is the outcome of a paid prompt.
+type PromptOutcome string
+
+const (
+	PromptOutcomePending   PromptOutcome = "pending"
+	PromptOutcomeFailed    PromptOutcome = "failed"
+	PromptOutcomeDrained   PromptOutcome = "drained"
+	PromptOutcomeReclaimed PromptOutcome = "reclaimed"
+)
+
+// PromptHistoryEntry is a prompt paid to an agent. Entries are replaced rather
+// than modified once stored.
+type PromptHistoryEntry struct {
+	PromptID  uint64
+	TweetID   uint64
+	Prompt    string
+	User      *felt.Felt
+	Outcome   PromptOutcome
+	DrainedTo *felt.Felt
+	// Reply is the agent's reply, taken from its signed receipt once the
+	// prompt is consumed.
+	Reply string
+
+	// Block, Timestamp and TxHash locate the payment of the prompt.
+	Block     uint64
+	Timestamp uint64
+	TxHash    *felt.Felt
+	// PendingTimestamp is set while Timestamp is the time the prompt was seen
+	// in the pending block, until the timestamp of its block is known.
+	PendingTimestamp bool
+
+	// ConsumeBlock and ConsumeTxHash locate the consumption or reclaim of the
+	// prompt, once it is no longer pending.
+	ConsumeBlock  uint64
+	ConsumeTxHash *felt.Felt
+}
+
+// needsReply reports whether the agent replied to the prompt, but the reply
+// was not fetched yet.
+func (e *PromptHistoryEntry) needsReply() bool {
+	return (e.Outcome == PromptOutcomeDrained || e.Outcome == PromptOutcomeFailed) && e.ConsumeTxHash != nil && e.Reply == ""
+}
+
+// PromptRef identifies a prompt of an agent.
+type PromptRef struct {
+	Agent    [32]byte
+	PromptID uint64
+}
+
+// PromptEventInfo locates the event a prompt change comes from.
+type PromptEventInfo struct {
+	Block     uint64
+	Timestamp uint64
+	TxHash    *felt.Felt
+	// Pending is set for events of the pending block, whose timestamp is
+	// provisional.
+	Pending bool
+}
+
+// PromptHistoryFilter selects a page of an agent's prompt history, newest
+// first. Zero values do not filter.
+type PromptHistoryFilter struct {
+	// Cursor is the prompt ID the page starts below.
+	Cursor uint64
+	Limit  int
+
+	Outcome   PromptOutcome
+	User      *felt.Felt
+	FromBlock uint64
+	ToBlock   uint64
+	FromTime  uint64
+	ToTime    uint64
+}
+
+// PromptHistoryPage is a page of an agent's prompt history.
+type PromptHistoryPage struct {
+	Prompts []*PromptHistoryEntry
+	// NextCursor is the cursor of the next page, or 0 if there is none.
+	NextCursor uint64
+	LastBlock  uint64
+}
+
+func (f *PromptHistoryFilter) matches(entry *PromptHistoryEntry) bool {
+	if f.Outcome != "" && entry.Outcome != f.Outcome {
+		return false
+	}
+	if f.User != nil && (entry.User == nil || !f.User.Equal(entry.User)) {
+		return false
+	}
+	if entry.Block < f.FromBlock || (f.ToBlock != 0 && entry.Block > f.ToBlock) {
+		return false
+	}
+	if entry.Timestamp < f.FromTime || (f.ToTime != 0 && entry.Timestamp > f.ToTime) {
+		return false
+	}
+	return true
+}
+
+// filterPromptHistory returns a page of history, which is sorted by prompt ID.
+func filterPromptHistory(history []*PromptHistoryEntry, filter *PromptHistoryFilter) ([]*PromptHistoryEntry, uint64) {
+	return pageBelow(history, func(idx int) uint64 {
+		return history[idx].PromptID
+	}, filter.Cursor, filter.Limit, filter.matches)
+}
+
+// pageBelow returns up to limit matching items, newest first, of items sorted
+// by an increasing key. The page starts below the key cursor, or at the newest
+// item if it is 0. It also returns the cursor of the next page, which is the
+// key of the last returned item, or 0 if there is none.
+func pageBelow[T any](items []T, key func(idx int) uint64, cursor uint64, limit int, matches func(T) bool) ([]T, uint64) {
+	if limit <= 0 {
+		return []T{}, 0
+	}
+
+	end := len(items)
+	if cursor != 0 {
+		end = sort.Search(len(items), func(i int) bool {
+			return key(i) >= cursor
+		})
+	}
+
+	page := make([]T, 0, limit)
+	lastIdx := 0
+	for idx := end - 1; idx >= 0; idx-- {
+		if matches != nil && !matches(items[idx]) {
+			continue
+		}
+
+		if len(page) == limit {
+			return page, key(lastIdx)
+		}
+		page = append(page, items[idx])
+		lastIdx = idx
+	}
+
+	return page, 0
+}
+
+// findPromptHistoryEntry returns the index of a prompt in history, which is
+// sorted by prompt ID, or where to insert it.
+func findPromptHistoryEntry(history []*PromptHistoryEntry, promptID uint64) (int, bool) {
+	idx := sort.Search(len(history), func(i int) bool {
+		return history[i].PromptID >= promptID
+	})
+	return idx, idx < len(history) && history[idx].PromptID == promptID
+}
@@ -0,0 +1,146 @@
+package indexer_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+)
+
+func TestPromptHistoryPagination(t *testing.T) {
+	agent := new(felt.Felt).SetUint64(1)
+	alice := new(felt.Felt).SetUint64(2)
+	bob := new(felt.Felt).SetUint64(3)
+
+	db := indexer.NewAgentUsageIndexerDatabaseInMemory(0, 2)
+	db.SetCurrentBlock(1)
+	db.StoreAgent(agent.Bytes())
+
+	for promptID := uint64(1); promptID <= 5; promptID++ {
+		user := alice
+		if promptID%2 == 0 {
+			user = bob
+		}
+
+		db.SetCurrentBlock(promptID)
+		db.StorePromptPaidData(agent.Bytes(), &indexer.PromptPaidEvent{
+			User:     user,
+			PromptID: promptID,
+			Prompt:   "prompt",
+		}, indexer.PromptEventInfo{Block: promptID, Timestamp: 100 * promptID})
+	}
+
+	db.SetCurrentBlock(6)
+	db.StorePromptConsumedData(agent.Bytes(), &indexer.PromptConsumedEvent{
+		PromptID:  3,
+		DrainedTo: alice,
+	}, indexer.PromptEventInfo{Block: 6})
+	db.StorePromptConsumedData(agent.Bytes(), &indexer.PromptConsumedEvent{
+		PromptID:  4,
+		DrainedTo: agent,
+	}, indexer.PromptEventInfo{Block: 6})
+	db.SetLastIndexedBlock(6)
+
+	var promptIDs []uint64
+	cursor := uint64(0)
+	for {
+		prompts, nextCursor, ok := db.GetPromptHistory(agent.Bytes(), &indexer.PromptHistoryFilter{
+			Cursor: cursor,
+			Limit:  2,
+		})
+		if !ok {
+			t.Fatalf("agent not found")
+		}
+		for _, prompt := range prompts {
+			promptIDs = append(promptIDs, prompt.PromptID)
+		}
+		if nextCursor == 0 {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if len(promptIDs) != 5 || promptIDs[0] != 5 || promptIDs[4] != 1 {
+		t.Errorf("expected prompts 5 to 1, got %v", promptIDs)
+	}
+
+	prompts, _, _ := db.GetPromptHistory(agent.Bytes(), &indexer.PromptHistoryFilter{
+		Limit:   10,
+		Outcome: indexer.PromptOutcomeDrained,
+	})
+	if len(prompts) != 1 || prompts[0].PromptID != 3 || prompts[0].ConsumeBlock != 6 {
+		t.Errorf("expected drained prompt 3, got %v", prompts)
+	}
+
+	prompts, _, _ = db.GetPromptHistory(agent.Bytes(), &indexer.PromptHistoryFilter{
+		Limit:    10,
+		User:     bob,
+		FromTime: 300,
+	})
+	if len(prompts) != 1 || prompts[0].PromptID != 4 || prompts[0].Outcome != indexer.PromptOutcomeFailed {
+		t.Errorf("expected failed prompt 4 of bob, got %v", prompts)
+	}
+
+	// The history is kept beyond the latest prompts
+	usage, _ := db.GetAgentUsage(agent.Bytes())
+	if len(usage.LatestPrompts) != 2 {
+		t.Errorf("expected 2 latest prompts, got %d", len(usage.LatestPrompts))
+	}
+}
+
+func TestPromptHistoryFollowUps(t *testing.T) {
+	agent := new(felt.Felt).SetUint64(1)
+	user := new(felt.Felt).SetUint64(2)
+	ref := indexer.PromptRef{Agent: agent.Bytes(), PromptID: 1}
+
+	db := indexer.NewAgentUsageIndexerDatabaseInMemory(0, 2)
+	db.SetCurrentBlock(1)
+	db.StoreAgent(agent.Bytes())
+
+	// The prompt is paid and consumed in the pending block.
+	db.SetCurrentBlock(3)
+	db.StorePromptPaidData(agent.Bytes(), &indexer.PromptPaidEvent{
+		User:     user,
+		PromptID: 1,
+		Prompt:   "prompt",
+	}, indexer.PromptEventInfo{Block: 3, Timestamp: 999, Pending: true})
+	db.StorePromptConsumedData(agent.Bytes(), &indexer.PromptConsumedEvent{
+		PromptID:  1,
+		DrainedTo: agent,
+	}, indexer.PromptEventInfo{Block: 3, TxHash: new(felt.Felt).SetUint64(42), Pending: true})
+	db.SetLastIndexedBlock(2)
+
+	if blocks := db.GetPendingTimestampBlocks(2); len(blocks) != 0 {
+		t.Fatalf("expected no pending timestamps up to block 2, got %v", blocks)
+	}
+	if blocks := db.GetPendingTimestampBlocks(3); len(blocks) != 1 || blocks[0] != 3 {
+		t.Fatalf("expected a pending timestamp at block 3, got %v", blocks)
+	}
+
+	db.SetBlockTimestamp(3, 300)
+	db.SetLastIndexedBlock(3)
+
+	prompts, _, _ := db.GetPromptHistory(agent.Bytes(), &indexer.PromptHistoryFilter{Limit: 10, ToTime: 300})
+	if len(prompts) != 1 || prompts[0].Timestamp != 300 || prompts[0].PendingTimestamp {
+		t.Fatalf("expected prompt 1 at its block timestamp, got %+v", prompts)
+	}
+	if blocks := db.GetPendingTimestampBlocks(3); len(blocks) != 0 {
+		t.Errorf("expected no pending timestamps, got %v", blocks)
+	}
+
+	missing := db.GetPromptsMissingReply()
+	if _, ok := missing[ref]; len(missing) != 1 || !ok {
+		t.Fatalf("expected the reply of prompt 1 to be missing, got %v", missing)
+	}
+
+	db.SetPromptReply(ref, "no")
+	if missing := db.GetPromptsMissingReply(); len(missing) != 0 {
+		t.Errorf("expected no missing replies, got %v", missing)
+	}
+
+	prompts, _, _ = db.GetPromptHistory(agent.Bytes(), &indexer.PromptHistoryFilter{Limit: 10})
+	if len(prompts) != 1 || prompts[0].Reply != "no" {
+		t.Errorf("expected prompt 1 with its reply, got %+v", prompts)
+	}
+}
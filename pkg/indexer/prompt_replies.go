@@ -0,0 +1,183 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/agent/receipt"
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+const (
+	replyFetchInterval  = 30 * time.Second
+	replyRetryDelay     = time.Minute
+	replyMaxRetryDelay  = time.Hour
+	replyMaxAttempts    = 12
+	receiptMaxSize      = 1 << 20
+	receiptFetchTimeout = 10 * time.Second
+)
+
+// receiptClient fetches the receipts the agent signs for the prompts it
+// consumed, which hold its replies.
+type receiptClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+func newReceiptClient(baseURL string) *receiptClient {
+	return &receiptClient{
+		client:  &http.Client{Timeout: receiptFetchTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// fetchReply fetches the receipt of a prompt and returns its reply, once the
+// receipt is checked to be signed by tee for the consumption of the entry.
+func (c *receiptClient) fetchReply(ctx context.Context, ref PromptRef, entry *PromptHistoryEntry, tee *felt.Felt) (string, error) {
+	agent := new(felt.Felt).SetBytes(ref.Agent[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/receipts/%s/%d", c.baseURL, agent, ref.PromptID), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch receipt: %s", resp.Status)
+	}
+
+	var r receipt.Receipt
+	if err := json.NewDecoder(io.LimitReader(resp.Body, receiptMaxSize)).Decode(&r); err != nil {
+		return "", fmt.Errorf("failed to decode receipt: %w", err)
+	}
+
+	if err := r.Verify(); err != nil {
+		return "", fmt.Errorf("invalid receipt: %w", err)
+	}
+	if !r.Signer.Equal(tee) {
+		return "", fmt.Errorf("receipt is signed by %s, not by the registry tee %s", r.Signer, tee)
+	}
+	if r.AgentAddress == nil || !r.AgentAddress.Equal(agent) || r.PromptID != ref.PromptID {
+		return "", errors.New("receipt is for another prompt")
+	}
+	if r.ConsumeTxHash == nil || !r.ConsumeTxHash.Equal(entry.ConsumeTxHash) {
+		return "", fmt.Errorf("receipt is for consume transaction %s, not %s", r.ConsumeTxHash, entry.ConsumeTxHash)
+	}
+	if r.PromptHash == nil || !r.PromptHash.Equal(receipt.HashString(entry.Prompt)) {
+		return "", errors.New("receipt is for another prompt text")
+	}
+
+	return r.Response, nil
+}
+
+// runReplyFetcher periodically fetches the replies of consumed prompts.
+func (i *AgentUsageIndexer) runReplyFetcher(ctx context.Context) error {
+	ticker := time.NewTicker(replyFetchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			i.fetchReplies(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fetchReplies fetches the missing replies, backing off on failure and giving
+// up after replyMaxAttempts.
+func (i *AgentUsageIndexer) fetchReplies(ctx context.Context) {
+	i.mu.RLock()
+	entries := i.db.GetPromptsMissingReply()
+	i.mu.RUnlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	tee, err := i.fetchTee(ctx)
+	if err != nil {
+		slog.Warn("failed to fetch registry tee, retrying later", "error", err)
+		return
+	}
+
+	for ref := range i.replyAttempts {
+		if _, ok := entries[ref]; !ok {
+			delete(i.replyAttempts, ref)
+		}
+	}
+
+	now := time.Now()
+	for ref, entry := range entries {
+		attempt, ok := i.replyAttempts[ref]
+		if ok && (attempt.count >= replyMaxAttempts || !attempt.due(now)) {
+			continue
+		}
+
+		reply, err := i.receipts.fetchReply(ctx, ref, entry, tee)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !ok {
+				attempt = &fetchAttempt{}
+				i.replyAttempts[ref] = attempt
+			}
+			attempt.fail(now, replyRetryDelay, replyMaxRetryDelay)
+
+			if attempt.count >= replyMaxAttempts {
+				slog.Warn("giving up fetching prompt reply", "agent", new(felt.Felt).SetBytes(ref.Agent[:]), "prompt_id", ref.PromptID, "error", err)
+			} else {
+				slog.Debug("failed to fetch prompt reply", "agent", new(felt.Felt).SetBytes(ref.Agent[:]), "prompt_id", ref.PromptID, "attempt", attempt.count, "error", err)
+			}
+			continue
+		}
+
+		delete(i.replyAttempts, ref)
+
+		i.mu.Lock()
+		i.db.SetPromptReply(ref, reply)
+		i.mu.Unlock()
+	}
+}
+
+// fetchTee reads the tee account of the registry, the only valid signer of
+// receipts.
+func (i *AgentUsageIndexer) fetchTee(ctx context.Context) (*felt.Felt, error) {
+	var resp []*felt.Felt
+	var err error
+
+	if err := i.client.Do(func(provider rpc.RpcProvider) error {
+		resp, err = provider.Call(ctx, rpc.FunctionCall{
+			ContractAddress:    i.registryAddress,
+			EntryPointSelector: getTeeSelector,
+			Calldata:           []*felt.Felt{},
+		}, rpc.WithBlockTag("pending"))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("get_tee call failed: %w", starknet.FormatRpcError(err))
+	}
+
+	if len(resp) < 1 {
+		return nil, fmt.Errorf("invalid get_tee response length: got %d, want at least 1", len(resp))
+	}
+
+	return resp[0], nil
+}
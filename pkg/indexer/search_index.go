@@ -0,0 +1,296 @@
+package indexer
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+// SearchField is the field of an agent a search result matched.
+type SearchField string
+
+const (
+	SearchFieldName         SearchField = "name"
+	SearchFieldSystemPrompt SearchField = "system_prompt"
+	SearchFieldPrompt       SearchField = "prompt"
+)
+
+// SearchScope selects the fields searched.
+type SearchScope string
+
+const (
+	// SearchScopeAll searches agents and the prompts paid to them.
+	SearchScopeAll SearchScope = ""
+	// SearchScopeAgents searches agent names and system prompts.
+	SearchScopeAgents SearchScope = "agents"
+	// SearchScopePrompts searches the prompts paid to agents.
+	SearchScopePrompts SearchScope = "prompts"
+)
+
+// IsValid returns true if s is a supported search scope.
+func (s SearchScope) IsValid() bool {
+	return s == SearchScopeAll || s == SearchScopeAgents || s == SearchScopePrompts
+}
+
+func (s SearchScope) includes(field SearchField) bool {
+	switch s {
+	case SearchScopeAgents:
+		return field == SearchFieldName || field == SearchFieldSystemPrompt
+	case SearchScopePrompts:
+		return field == SearchFieldPrompt
+	default:
+		return true
+	}
+}
+
+// searchFieldBoosts weighs matches by field, so that agents matching by name
+// rank above those matching by system prompt or prompts.
+var searchFieldBoosts = map[SearchField]float64{
+	SearchFieldName:         3,
+	SearchFieldSystemPrompt: 1.5,
+	SearchFieldPrompt:       1,
+}
+
+const (
+	// searchMinTermLength is the minimum length in runes of an indexed term.
+	searchMinTermLength = 2
+	// searchSnippetContext is the number of runes kept around the first match
+	// of a snippet.
+	searchSnippetContext = 60
+)
+
+// SearchResult is a text of an agent matching a search query.
+type SearchResult struct {
+	Agent     *felt.Felt
+	AgentName string
+	Field     SearchField
+	// PromptID is the ID of the matched prompt, for SearchFieldPrompt.
+	PromptID uint64
+	Score    float64
+	// Snippet is an excerpt of the text around its first match.
+	Snippet string
+}
+
+// searchDocumentKey identifies an indexed text.
+type searchDocumentKey struct {
+	agent    [32]byte
+	field    SearchField
+	promptID uint64
+}
+
+type searchDocument struct {
+	text string
+	// length is the number of terms of the text.
+	length int
+}
+
+// searchIndex is an inverted index over the texts of agents. Terms are
+// lowercased words, and documents are ranked with BM25.
+type searchIndex struct {
+	documents map[searchDocumentKey]*searchDocument
+	// postings maps each term to the frequency of the term in each document
+	// containing it.
+	postings    map[string]map[searchDocumentKey]int
+	totalLength int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		documents: make(map[searchDocumentKey]*searchDocument),
+		postings:  make(map[string]map[searchDocumentKey]int),
+	}
+}
+
+// set indexes the text of a document, replacing its previous text if any.
+func (idx *searchIndex) set(undoLog *utils.UndoLog, key searchDocumentKey, text string) {
+	prevDocument, existed := idx.documents[key]
+	if existed {
+		if prevDocument.text == text {
+			return
+		}
+		idx.remove(key)
+	}
+
+	undoLog.Push(func() {
+		idx.remove(key)
+		if existed {
+			idx.add(key, prevDocument.text)
+		}
+	})
+
+	idx.add(key, text)
+}
+
+func (idx *searchIndex) add(key searchDocumentKey, text string) {
+	terms := tokenizeSearchText(text)
+	if len(terms) == 0 {
+		return
+	}
+
+	idx.documents[key] = &searchDocument{text: text, length: len(terms)}
+	idx.totalLength += len(terms)
+
+	for _, term := range terms {
+		postings, ok := idx.postings[term.value]
+		if !ok {
+			postings = make(map[searchDocumentKey]int)
+			idx.postings[term.value] = postings
+		}
+		postings[key]++
+	}
+}
+
+func (idx *searchIndex) remove(key searchDocumentKey) {
+	document, ok := idx.documents[key]
+	if !ok {
+		return
+	}
+
+	for _, term := range tokenizeSearchText(document.text) {
+		postings := idx.postings[term.value]
+		delete(postings, key)
+		if len(postings) == 0 {
+			delete(idx.postings, term.value)
+		}
+	}
+
+	idx.totalLength -= document.length
+	delete(idx.documents, key)
+}
+
+// search returns the documents within scope containing every term of query,
+// by descending score.
+func (idx *searchIndex) search(query string, scope SearchScope) ([]searchDocumentKey, map[searchDocumentKey]float64, []string) {
+	terms := uniqueSearchTerms(query)
+	if len(terms) == 0 || len(idx.documents) == 0 {
+		return nil, nil, terms
+	}
+
+	// Start from the rarest term, as every term must match
+	slices.SortFunc(terms, func(a, b string) int {
+		return cmp.Compare(len(idx.postings[a]), len(idx.postings[b]))
+	})
+
+	scores := make(map[searchDocumentKey]float64)
+	for key := range idx.postings[terms[0]] {
+		if scope.includes(key.field) {
+			scores[key] = 0
+		}
+	}
+
+	// BM25 parameters
+	const k1, b = 1.2, 0.75
+	documentCount := float64(len(idx.documents))
+	avgLength := float64(idx.totalLength) / documentCount
+
+	for _, term := range terms {
+		postings := idx.postings[term]
+		idf := math.Log(1 + (documentCount-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+
+		for key := range scores {
+			frequency, ok := postings[key]
+			if !ok {
+				delete(scores, key)
+				continue
+			}
+
+			tf := float64(frequency)
+			length := float64(idx.documents[key].length)
+			scores[key] += searchFieldBoosts[key.field] * idf * tf * (k1 + 1) / (tf + k1*(1-b+b*length/avgLength))
+		}
+	}
+
+	keys := make([]searchDocumentKey, 0, len(scores))
+	for key := range scores {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, c searchDocumentKey) int {
+		if res := cmp.Compare(scores[c], scores[a]); res != 0 {
+			return res
+		}
+		if res := slices.Compare(a.agent[:], c.agent[:]); res != 0 {
+			return res
+		}
+		if res := cmp.Compare(a.field, c.field); res != 0 {
+			return res
+		}
+		return cmp.Compare(a.promptID, c.promptID)
+	})
+
+	return keys, scores, terms
+}
+
+// searchTerm is a term of a text, along with its position in runes.
+type searchTerm struct {
+	value      string
+	start, end int
+}
+
+// tokenizeSearchText splits text into lowercased words of letters and digits.
+func tokenizeSearchText(text string) []searchTerm {
+	var terms []searchTerm
+
+	runes := []rune(text)
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 && i-start >= searchMinTermLength {
+			terms = append(terms, searchTerm{
+				value: strings.ToLower(string(runes[start:i])),
+				start: start,
+				end:   i,
+			})
+		}
+		start = -1
+	}
+
+	return terms
+}
+
+func uniqueSearchTerms(query string) []string {
+	var terms []string
+	for _, term := range tokenizeSearchText(query) {
+		if !slices.Contains(terms, term.value) {
+			terms = append(terms, term.value)
+		}
+	}
+	return terms
+}
+
+// searchSnippet returns an excerpt of text around the first of terms it
+// contains.
+func searchSnippet(text string, terms []string) string {
+	runes := []rune(text)
+
+	start, end := 0, min(len(runes), 2*searchSnippetContext)
+	for _, term := range tokenizeSearchText(text) {
+		if slices.Contains(terms, term.value) {
+			start = max(0, term.start-searchSnippetContext)
+			end = min(len(runes), term.end+searchSnippetContext)
+			break
+		}
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+
+	return snippet
+}
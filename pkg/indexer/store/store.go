@@ -0,0 +1,218 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SchemaVersion is the version of the stored state. Stores written with
+// another version are cleared on open, so that the indexers index again from
+// scratch. Version 2 stores USD values with price.RateDecimals decimals.
+const SchemaVersion = 2
+
+var (
+	metaKey             = []byte("__meta")
+	lastIndexedBlockKey = []byte("last_indexed_block")
+	schemaKey           = []byte("__schema")
+	schemaVersionKey    = []byte("version")
+)
+
+// Store is an embedded key-value store holding the state of indexers. Each
+// indexer keeps its state in its own namespace.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %s: %w", path, err)
+	}
+
+	if err := checkSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to check schema of store %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// checkSchema clears the store if it was written with another schema version.
+// Stores written before the schema was versioned are version 1.
+func checkSchema(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		namespaces := make([][]byte, 0)
+		if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			namespaces = append(namespaces, append([]byte(nil), name...))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		version := uint64(1)
+		if schema := tx.Bucket(schemaKey); schema != nil {
+			if data := schema.Get(schemaVersionKey); len(data) == 8 {
+				version = binary.BigEndian.Uint64(data)
+			}
+		} else if len(namespaces) == 0 {
+			version = SchemaVersion
+		}
+
+		if version != SchemaVersion {
+			slog.Warn("clearing store of another schema version", "version", version, "schema_version", SchemaVersion)
+
+			for _, name := range namespaces {
+				if err := tx.DeleteBucket(name); err != nil {
+					return fmt.Errorf("failed to clear namespace %s: %w", name, err)
+				}
+			}
+		}
+
+		schema, err := tx.CreateBucketIfNotExists(schemaKey)
+		if err != nil {
+			return fmt.Errorf("failed to create schema table: %w", err)
+		}
+
+		return schema.Put(schemaVersionKey, binary.BigEndian.AppendUint64(nil, SchemaVersion))
+	})
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Tx is a read-write transaction over a namespace.
+type Tx struct {
+	bucket *bolt.Bucket
+}
+
+// Update runs fn in a read-write transaction over namespace. Every change,
+// including the last indexed block, is committed atomically.
+func (s *Store) Update(namespace string, fn func(tx *Tx) error) error {
+	return s.db.Update(func(boltTx *bolt.Tx) error {
+		bucket, err := boltTx.CreateBucketIfNotExists([]byte(namespace))
+		if err != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+		}
+
+		return fn(&Tx{bucket: bucket})
+	})
+}
+
+// View runs fn in a read-only transaction over namespace. fn is not called if
+// nothing was stored in namespace yet.
+func (s *Store) View(namespace string, fn func(tx *Tx) error) error {
+	return s.db.View(func(boltTx *bolt.Tx) error {
+		bucket := boltTx.Bucket([]byte(namespace))
+		if bucket == nil {
+			return nil
+		}
+
+		return fn(&Tx{bucket: bucket})
+	})
+}
+
+// Put stores the JSON encoding of value under key in table.
+func (tx *Tx) Put(table string, key []byte, value any) error {
+	bucket, err := tx.bucket.CreateBucketIfNotExists([]byte(table))
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s value: %w", table, err)
+	}
+
+	return bucket.Put(key, data)
+}
+
+// Delete removes key from table.
+func (tx *Tx) Delete(table string, key []byte) error {
+	bucket := tx.bucket.Bucket([]byte(table))
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.Delete(key)
+}
+
+// Get decodes the value stored under key in table into value.
+func (tx *Tx) Get(table string, key []byte, value any) (bool, error) {
+	bucket := tx.bucket.Bucket([]byte(table))
+	if bucket == nil {
+		return false, nil
+	}
+
+	data := bucket.Get(key)
+	if data == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s value: %w", table, err)
+	}
+
+	return true, nil
+}
+
+// ForEach calls fn for every key of table, in key order. decode decodes the
+// value into the given pointer.
+func (tx *Tx) ForEach(table string, fn func(key []byte, decode func(value any) error) error) error {
+	bucket := tx.bucket.Bucket([]byte(table))
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.ForEach(func(key, data []byte) error {
+		return fn(key, func(value any) error {
+			if err := json.Unmarshal(data, value); err != nil {
+				return fmt.Errorf("failed to unmarshal %s value: %w", table, err)
+			}
+			return nil
+		})
+	})
+}
+
+// Clear removes every key of table.
+func (tx *Tx) Clear(table string) error {
+	if tx.bucket.Bucket([]byte(table)) == nil {
+		return nil
+	}
+
+	return tx.bucket.DeleteBucket([]byte(table))
+}
+
+// SetLastIndexedBlock stores the last block the namespace's state is
+// consistent with.
+func (tx *Tx) SetLastIndexedBlock(block uint64) error {
+	bucket, err := tx.bucket.CreateBucketIfNotExists(metaKey)
+	if err != nil {
+		return fmt.Errorf("failed to create meta table: %w", err)
+	}
+
+	return bucket.Put(lastIndexedBlockKey, binary.BigEndian.AppendUint64(nil, block))
+}
+
+// LastIndexedBlock returns the last block the namespace's state is consistent
+// with, if any was stored.
+func (tx *Tx) LastIndexedBlock() (uint64, bool) {
+	bucket := tx.bucket.Bucket(metaKey)
+	if bucket == nil {
+		return 0, false
+	}
+
+	data := bucket.Get(lastIndexedBlockKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(data), true
+}
@@ -0,0 +1,159 @@
+package store_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+)
+
+type record struct {
+	Name  string
+	Count int
+}
+
+func TestStorePersistsStateWithLastIndexedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indexer.db")
+
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	err = s.Update("agents", func(tx *store.Tx) error {
+		for i := range 3 {
+			if err := tx.Put("records", []byte{byte(i)}, &record{Name: fmt.Sprintf("agent-%d", i), Count: i}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete("records", []byte{1}); err != nil {
+			return err
+		}
+		return tx.SetLastIndexedBlock(42)
+	})
+	if err != nil {
+		t.Fatalf("failed to update store: %v", err)
+	}
+
+	// A failed update leaves no trace
+	err = s.Update("agents", func(tx *store.Tx) error {
+		if err := tx.Put("records", []byte{3}, &record{Name: "agent-3"}); err != nil {
+			return err
+		}
+		if err := tx.SetLastIndexedBlock(43); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback")
+	})
+	if err == nil {
+		t.Fatalf("expected update to fail")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	s, err = store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer s.Close()
+
+	var names []string
+	var block uint64
+	var hasBlock bool
+	err = s.View("agents", func(tx *store.Tx) error {
+		block, hasBlock = tx.LastIndexedBlock()
+		return tx.ForEach("records", func(key []byte, decode func(value any) error) error {
+			var r record
+			if err := decode(&r); err != nil {
+				return err
+			}
+			names = append(names, r.Name)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("failed to read store: %v", err)
+	}
+
+	if !hasBlock || block != 42 {
+		t.Errorf("expected last indexed block 42, got %d (%v)", block, hasBlock)
+	}
+	if fmt.Sprint(names) != "[agent-0 agent-2]" {
+		t.Errorf("unexpected records: %v", names)
+	}
+
+	called := false
+	err = s.View("users", func(tx *store.Tx) error {
+		called = true
+		return nil
+	})
+	if err != nil || called {
+		t.Errorf("expected empty namespace to be skipped, called=%v err=%v", called, err)
+	}
+}
+
+func TestStoreClearsOtherSchemaVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indexer.db")
+
+	// A store written before the schema was versioned
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucket([]byte("agents"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	called := false
+	err = s.View("agents", func(tx *store.Tx) error {
+		called = true
+		return nil
+	})
+	if err != nil || called {
+		t.Errorf("expected namespace of the old schema to be cleared, called=%v err=%v", called, err)
+	}
+
+	err = s.Update("agents", func(tx *store.Tx) error {
+		return tx.SetLastIndexedBlock(42)
+	})
+	if err != nil {
+		t.Fatalf("failed to update store: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	s, err = store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer s.Close()
+
+	var block uint64
+	err = s.View("agents", func(tx *store.Tx) error {
+		block, _ = tx.LastIndexedBlock()
+		return nil
+	})
+	if err != nil || block != 42 {
+		t.Errorf("expected state of the current schema to be kept, got block %d err=%v", block, err)
+	}
+}
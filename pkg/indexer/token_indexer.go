@@ -3,7 +3,9 @@ package indexer
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"math/big"
+	"slices"
 	"sync"
 	"time"
 
@@ -11,6 +13,12 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/NethermindEth/teeception/pkg/indexer/price"
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+const (
+	metadataRetryDelay    = time.Minute
+	metadataMaxRetryDelay = time.Hour
 )
 
 // TokenPriceUpdate is a struct that contains the token price and its update block
@@ -24,11 +32,21 @@ type TokenInfo struct {
 
 // TokenIndexer processes token events and tracks token prices.
 type TokenIndexer struct {
+	client starknet.ProviderWrapper
+
 	dbMu            sync.RWMutex
 	db              TokenIndexerDatabase
 	registryAddress *felt.Felt
 	priceFeed       price.PriceFeed
 	priceTickRate   time.Duration
+	priceMaxAge     time.Duration
+
+	// metadata caches the metadata of the tokens, which is not part of the
+	// indexed state. metadataAttempts backs off the tokens whose metadata
+	// could not be fetched.
+	metadataMu       sync.RWMutex
+	metadata         map[[32]byte]*TokenMetadata
+	metadataAttempts map[[32]byte]*fetchAttempt
 
 	eventCh      chan *EventSubscriptionData
 	eventSubID   int64
@@ -42,8 +60,12 @@ type TokenIndexerInitialState struct {
 
 // TokenIndexerConfig is the configuration for a TokenIndexer.
 type TokenIndexerConfig struct {
-	PriceFeed       price.PriceFeed
-	PriceTickRate   time.Duration
+	Client        starknet.ProviderWrapper
+	PriceFeed     price.PriceFeed
+	PriceTickRate time.Duration
+	// PriceMaxAge is the age after which a rate that could not be updated is
+	// dropped. Rates are kept until updated if zero.
+	PriceMaxAge     time.Duration
 	RegistryAddress *felt.Felt
 	InitialState    *TokenIndexerInitialState
 	EventWatcher    *EventWatcher
@@ -58,16 +80,23 @@ func NewTokenIndexer(cfg *TokenIndexerConfig) *TokenIndexer {
 	}
 
 	eventCh := make(chan *EventSubscriptionData, 1000)
-	eventSubID := cfg.EventWatcher.Subscribe(EventTokenAdded|EventTokenRemoved, eventCh)
+	eventSubID := cfg.EventWatcher.SubscribeWithConfig(&EventSubscriberConfig{
+		Type: EventTokenAdded | EventTokenRemoved,
+		Name: "token_indexer",
+	}, eventCh)
 
 	return &TokenIndexer{
-		db:              cfg.InitialState.Db,
-		priceFeed:       cfg.PriceFeed,
-		priceTickRate:   cfg.PriceTickRate,
-		registryAddress: cfg.RegistryAddress,
-		eventCh:         eventCh,
-		eventSubID:      eventSubID,
-		eventWatcher:    cfg.EventWatcher,
+		client:           cfg.Client,
+		metadata:         make(map[[32]byte]*TokenMetadata),
+		metadataAttempts: make(map[[32]byte]*fetchAttempt),
+		db:               cfg.InitialState.Db,
+		priceFeed:        cfg.PriceFeed,
+		priceTickRate:    cfg.PriceTickRate,
+		priceMaxAge:      cfg.PriceMaxAge,
+		registryAddress:  cfg.RegistryAddress,
+		eventCh:          eventCh,
+		eventSubID:       eventSubID,
+		eventWatcher:     cfg.EventWatcher,
 	}
 }
 
@@ -92,8 +121,23 @@ func (i *TokenIndexer) run(ctx context.Context) error {
 	for {
 		select {
 		case data := <-i.eventCh:
+			if !data.Rollback {
+				i.fetchAddedTokensMetadata(ctx, data.Events)
+			}
+
 			i.dbMu.Lock()
+			if data.Rollback {
+				slog.Warn("reverting tokens", "block", data.ToBlock)
+				if err := i.db.RevertToBlock(data.ToBlock); err != nil {
+					slog.Error("failed to revert tokens", "error", err)
+				}
+				i.dbMu.Unlock()
+				continue
+			}
 			for _, ev := range data.Events {
+				if err := i.db.SetCurrentEvent(ev); err != nil {
+					slog.Error("failed to set current event", "error", err)
+				}
 				switch ev.Type {
 				case EventTokenAdded:
 					i.onTokenAdded(ev)
@@ -118,36 +162,120 @@ func (i *TokenIndexer) updatePricesTask(ctx context.Context) error {
 	for {
 		select {
 		case <-ticker.C:
-			i.dbMu.RLock()
-			tokens := i.db.GetTokens()
-			i.dbMu.RUnlock()
-
-			token := new(felt.Felt)
-			for tokenBytes := range tokens {
-				token.SetBytes(tokenBytes[:])
-				price, err := i.priceFeed.GetRate(ctx, token)
-				if err != nil {
-					slog.Error("failed to get token price", "token", token.String(), "error", err)
-					continue
-				}
-				tokens[tokenBytes].Rate = price
-				tokens[tokenBytes].RateTime = time.Now()
-			}
-
-			i.dbMu.Lock()
-			for tokenBytes, info := range tokens {
-				if err := i.db.SetTokenInfo(tokenBytes, info); err != nil {
-					slog.Error("failed to set token info", "error", err)
-				}
-			}
-			i.dbMu.Unlock()
-
+			i.updatePrices(ctx)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
 
+// updatePrices fetches the rate of every token, and drops the rates that
+// could not be updated for longer than the max age.
+func (i *TokenIndexer) updatePrices(ctx context.Context) {
+	i.dbMu.RLock()
+	tokens := slices.Collect(maps.Keys(i.db.GetTokens()))
+	i.dbMu.RUnlock()
+
+	// Retry tokens loaded from a previous run or whose metadata could not be
+	// fetched when added, unless their last failure is being backed off
+	for _, tokenBytes := range tokens {
+		i.cacheTokenMetadata(ctx, tokenBytes)
+	}
+
+	rates := make(map[[32]byte]*big.Int, len(tokens))
+	token := new(felt.Felt)
+	for _, tokenBytes := range tokens {
+		token.SetBytes(tokenBytes[:])
+		rate, err := i.priceFeed.GetRate(ctx, token)
+		if err != nil {
+			slog.Error("failed to get token price", "token", token.String(), "error", err)
+			continue
+		}
+		rates[tokenBytes] = rate
+	}
+
+	now := time.Now()
+
+	i.dbMu.Lock()
+	defer i.dbMu.Unlock()
+
+	// Rates are updated in place, as they are not part of the indexed state
+	for tokenBytes, info := range i.db.GetTokens() {
+		if rate, ok := rates[tokenBytes]; ok {
+			info.Rate = rate
+			info.RateTime = now
+			continue
+		}
+
+		if !info.RateTime.IsZero() && i.isRateStale(info, now) {
+			slog.Warn("dropping stale token rate", "token", new(felt.Felt).SetBytes(tokenBytes[:]).String(), "rate_time", info.RateTime)
+			info.Rate = nil
+			info.RateTime = time.Time{}
+		}
+	}
+}
+
+// fetchAddedTokensMetadata fetches the metadata of the tokens added by events
+// before they are indexed, so that no RPC call is made while holding the
+// database lock.
+func (i *TokenIndexer) fetchAddedTokensMetadata(ctx context.Context, events []*Event) {
+	for _, ev := range events {
+		if ev.Type != EventTokenAdded {
+			continue
+		}
+
+		tokenAddedEv, ok := ev.ToTokenAddedEvent()
+		if !ok {
+			continue
+		}
+
+		i.cacheTokenMetadata(ctx, tokenAddedEv.Token.Bytes())
+	}
+}
+
+// cacheTokenMetadata fetches and caches the metadata of a token, unless it is
+// cached already or a failed fetch is backed off.
+func (i *TokenIndexer) cacheTokenMetadata(ctx context.Context, tokenBytes [32]byte) {
+	now := time.Now()
+
+	i.metadataMu.RLock()
+	_, ok := i.metadata[tokenBytes]
+	attempt, failed := i.metadataAttempts[tokenBytes]
+	due := !failed || attempt.due(now)
+	i.metadataMu.RUnlock()
+	if ok || !due || i.client == nil {
+		return
+	}
+
+	token := new(felt.Felt).SetBytes(tokenBytes[:])
+	metadata, err := fetchTokenMetadata(ctx, i.client, token)
+	if err != nil {
+		i.metadataMu.Lock()
+		attempt, failed := i.metadataAttempts[tokenBytes]
+		if !failed {
+			attempt = &fetchAttempt{}
+			i.metadataAttempts[tokenBytes] = attempt
+		}
+		attempt.fail(now, metadataRetryDelay, metadataMaxRetryDelay)
+		count, next := attempt.count, attempt.next
+		i.metadataMu.Unlock()
+
+		slog.Error("failed to fetch token metadata", "token", token.String(), "attempt", count, "retry_at", next, "error", err)
+		return
+	}
+
+	slog.Info("fetched token metadata", "token", token.String(), "symbol", metadata.Symbol, "decimals", metadata.Decimals)
+
+	i.metadataMu.Lock()
+	i.metadata[tokenBytes] = metadata
+	delete(i.metadataAttempts, tokenBytes)
+	i.metadataMu.Unlock()
+}
+
+func (i *TokenIndexer) isRateStale(info *TokenInfo, now time.Time) bool {
+	return i.priceMaxAge > 0 && now.Sub(info.RateTime) > i.priceMaxAge
+}
+
 func (i *TokenIndexer) onTokenAdded(ev *Event) {
 	if ev.Raw.FromAddress.Cmp(i.registryAddress) != 0 {
 		slog.Warn("ignoring token added event from non-registry address", "address", ev.Raw.FromAddress.String())
@@ -221,13 +349,83 @@ func (i *TokenIndexer) GetTokenRate(token *felt.Felt) (*big.Int, bool) {
 		return nil, false
 	}
 
-	if tokenInfo.RateTime.IsZero() {
+	if tokenInfo.RateTime.IsZero() || i.isRateStale(tokenInfo, time.Now()) {
 		return nil, false
 	}
 
 	return tokenInfo.Rate, true
 }
 
+// GetTokenUSDValue returns the USD value of an amount of a token, with
+// price.RateDecimals decimals, if the token's rate and decimals are known.
+func (i *TokenIndexer) GetTokenUSDValue(token *felt.Felt, amount *big.Int) (*big.Int, bool) {
+	rate, ok := i.GetTokenRate(token)
+	if !ok {
+		return nil, false
+	}
+
+	metadata, ok := i.GetTokenMetadata(token)
+	if !ok {
+		return nil, false
+	}
+
+	return TokenUSDValue(amount, rate, metadata.Decimals), true
+}
+
+// GetTokenMetadata returns a token's metadata, if it is known.
+func (i *TokenIndexer) GetTokenMetadata(token *felt.Felt) (*TokenMetadata, bool) {
+	i.metadataMu.RLock()
+	defer i.metadataMu.RUnlock()
+
+	metadata, ok := i.metadata[token.Bytes()]
+	return metadata, ok
+}
+
+// SupportedToken is a token supported by the registry.
+type SupportedToken struct {
+	Address           *felt.Felt
+	MinPromptPrice    *big.Int
+	MinInitialBalance *big.Int
+	// Rate and RateTime are unset if the rate is unknown or stale.
+	Rate     *big.Int
+	RateTime time.Time
+	// Metadata is nil if it could not be fetched yet.
+	Metadata *TokenMetadata
+}
+
+// GetSupportedTokens returns the tokens supported by the registry, sorted by
+// address.
+func (i *TokenIndexer) GetSupportedTokens() []*SupportedToken {
+	i.dbMu.RLock()
+	defer i.dbMu.RUnlock()
+
+	i.metadataMu.RLock()
+	defer i.metadataMu.RUnlock()
+
+	now := time.Now()
+	tokens := make([]*SupportedToken, 0, len(i.db.GetTokens()))
+	for tokenBytes, info := range i.db.GetTokens() {
+		token := &SupportedToken{
+			Address:           new(felt.Felt).SetBytes(tokenBytes[:]),
+			MinPromptPrice:    info.MinPromptPrice,
+			MinInitialBalance: info.MinInitialBalance,
+			Metadata:          i.metadata[tokenBytes],
+		}
+		if !info.RateTime.IsZero() && !i.isRateStale(info, now) {
+			token.Rate = info.Rate
+			token.RateTime = info.RateTime
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	slices.SortFunc(tokens, func(a, b *SupportedToken) int {
+		return a.Address.Cmp(b.Address)
+	})
+
+	return tokens
+}
+
 // GetLastIndexedBlock returns the last indexed block.
 func (i *TokenIndexer) GetLastIndexedBlock() uint64 {
 	i.dbMu.RLock()
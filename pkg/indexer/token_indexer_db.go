@@ -1,5 +1,7 @@
 package indexer
 
+import "github.com/NethermindEth/teeception/pkg/indexer/utils"
+
 // TokenIndexerDatabaseReader is the database reader for a TokenIndexer.
 type TokenIndexerDatabaseReader interface {
 	GetTokenInfo(token [32]byte) (*TokenInfo, bool)
@@ -11,6 +13,9 @@ type TokenIndexerDatabaseReader interface {
 type TokenIndexerDatabaseWriter interface {
 	SetTokenInfo(token [32]byte, info *TokenInfo) error
 	SetLastIndexedBlock(block uint64) error
+	SetCurrentBlock(block uint64) error
+	SetCurrentEvent(ev *Event) error
+	RevertToBlock(block uint64) error
 }
 
 // TokenIndexerDatabase is the database for a TokenIndexer.
@@ -23,6 +28,7 @@ type TokenIndexerDatabase interface {
 type TokenIndexerDatabaseInMemory struct {
 	tokens           map[[32]byte]*TokenInfo
 	lastIndexedBlock uint64
+	undoLog          *utils.UndoLog
 }
 
 var _ TokenIndexerDatabase = (*TokenIndexerDatabaseInMemory)(nil)
@@ -32,6 +38,7 @@ func NewTokenIndexerDatabaseInMemory(initialBlock uint64) *TokenIndexerDatabaseI
 	return &TokenIndexerDatabaseInMemory{
 		tokens:           make(map[[32]byte]*TokenInfo),
 		lastIndexedBlock: initialBlock,
+		undoLog:          utils.NewUndoLog(),
 	}
 }
 
@@ -43,6 +50,18 @@ func (db *TokenIndexerDatabaseInMemory) GetTokenInfo(token [32]byte) (*TokenInfo
 
 // SetTokenInfo sets the TokenInfo for a given token address.
 func (db *TokenIndexerDatabaseInMemory) SetTokenInfo(token [32]byte, info *TokenInfo) error {
+	// Price updates set the stored info again, which is not a change of the
+	// indexed state.
+	if prevInfo, existed := db.tokens[token]; !existed || prevInfo != info {
+		db.undoLog.Push(func() {
+			if existed {
+				db.tokens[token] = prevInfo
+			} else {
+				delete(db.tokens, token)
+			}
+		})
+	}
+
 	if info == nil {
 		delete(db.tokens, token)
 		return nil
@@ -60,6 +79,27 @@ func (db *TokenIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 // SetLastIndexedBlock sets the last indexed block.
 func (db *TokenIndexerDatabaseInMemory) SetLastIndexedBlock(block uint64) error {
 	db.lastIndexedBlock = block
+	db.undoLog.Prune(block - min(block, ReorgWindow))
+	return nil
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *TokenIndexerDatabaseInMemory) SetCurrentBlock(block uint64) error {
+	db.undoLog.SetBlock(block)
+	return nil
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *TokenIndexerDatabaseInMemory) SetCurrentEvent(ev *Event) error {
+	return db.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block.
+func (db *TokenIndexerDatabaseInMemory) RevertToBlock(block uint64) error {
+	db.undoLog.Revert(block)
+	if db.lastIndexedBlock > block {
+		db.lastIndexedBlock = block
+	}
 	return nil
 }
 
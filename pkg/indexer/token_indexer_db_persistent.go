@@ -0,0 +1,150 @@
+package indexer
+
+import (
+	"log/slog"
+	"math/big"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+const tokensTable = "tokens"
+
+// TokenIndexerDatabasePersistent is a TokenIndexerDatabase kept in memory and
+// persisted to a store on every indexed block. Rates are not persisted, they
+// are fetched again by the price updater.
+type TokenIndexerDatabasePersistent struct {
+	*TokenIndexerDatabaseInMemory
+
+	state persistentState
+	dirty map[[32]byte]struct{}
+}
+
+type tokenRecord struct {
+	MinPromptPrice    *big.Int
+	MinInitialBalance *big.Int
+}
+
+var _ TokenIndexerDatabase = (*TokenIndexerDatabasePersistent)(nil)
+
+// NewTokenIndexerDatabasePersistent creates a TokenIndexerDatabase persisted
+// to s, loading the state persisted by a previous run if any.
+func NewTokenIndexerDatabasePersistent(s *store.Store, initialBlock uint64) (*TokenIndexerDatabasePersistent, error) {
+	db := &TokenIndexerDatabasePersistent{
+		TokenIndexerDatabaseInMemory: NewTokenIndexerDatabaseInMemory(initialBlock),
+		state:                        newPersistentState(s, "token_indexer"),
+		dirty:                        make(map[[32]byte]struct{}),
+	}
+
+	block, ok, err := db.state.load(func(tx *store.Tx) error {
+		return tx.ForEach(tokensTable, func(key []byte, decode func(value any) error) error {
+			var record tokenRecord
+			if err := decode(&record); err != nil {
+				return err
+			}
+
+			db.tokens[[32]byte(key)] = &TokenInfo{
+				MinPromptPrice:    record.MinPromptPrice,
+				MinInitialBalance: record.MinInitialBalance,
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		db.lastIndexedBlock = block
+		db.undoLog = utils.NewUndoLog()
+		slog.Info("loaded persisted tokens", "tokens", len(db.tokens), "last_indexed_block", block)
+	}
+
+	return db, nil
+}
+
+// SetTokenInfo sets the TokenInfo for a given token address.
+func (db *TokenIndexerDatabasePersistent) SetTokenInfo(token [32]byte, info *TokenInfo) error {
+	// Price updates set the stored info again and are not persisted.
+	if prevInfo, existed := db.tokens[token]; existed && prevInfo == info {
+		return db.TokenIndexerDatabaseInMemory.SetTokenInfo(token, info)
+	}
+
+	if db.state.skip() {
+		return nil
+	}
+
+	db.dirty[token] = struct{}{}
+	return db.TokenIndexerDatabaseInMemory.SetTokenInfo(token, info)
+}
+
+// SetLastIndexedBlock sets the last indexed block and persists the changes
+// made up to it.
+func (db *TokenIndexerDatabasePersistent) SetLastIndexedBlock(block uint64) error {
+	if db.state.stale(block) {
+		return nil
+	}
+
+	err := db.state.commit(block, func(tx *store.Tx, rewrite bool) error {
+		if rewrite {
+			if err := tx.Clear(tokensTable); err != nil {
+				return err
+			}
+
+			clear(db.dirty)
+			for token := range db.tokens {
+				db.dirty[token] = struct{}{}
+			}
+		}
+
+		for token := range db.dirty {
+			info, ok := db.tokens[token]
+			if !ok {
+				if err := tx.Delete(tokensTable, token[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(tokensTable, token[:], &tokenRecord{
+				MinPromptPrice:    info.MinPromptPrice,
+				MinInitialBalance: info.MinInitialBalance,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		clear(db.dirty)
+	}
+
+	// The changes that failed to persist are persisted with the next block.
+	if innerErr := db.TokenIndexerDatabaseInMemory.SetLastIndexedBlock(block); innerErr != nil {
+		return innerErr
+	}
+	return err
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *TokenIndexerDatabasePersistent) SetCurrentBlock(block uint64) error {
+	db.state.setCurrentBlock(block)
+	return db.TokenIndexerDatabaseInMemory.SetCurrentBlock(block)
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *TokenIndexerDatabasePersistent) SetCurrentEvent(ev *Event) error {
+	db.state.setCurrentEvent(ev)
+	return db.TokenIndexerDatabaseInMemory.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. The state is
+// persisted again on the next indexed block.
+func (db *TokenIndexerDatabasePersistent) RevertToBlock(block uint64) error {
+	if db.state.stale(block) {
+		slog.Warn("cannot revert persisted tokens", "block", block, "persisted_block", db.state.resumeBlock)
+	}
+
+	db.state.revert(block)
+	return db.TokenIndexerDatabaseInMemory.RevertToBlock(block)
+}
@@ -0,0 +1,127 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+var (
+	erc20NameSelector     = starknetgoutils.GetSelectorFromNameFelt("name")
+	erc20SymbolSelector   = starknetgoutils.GetSelectorFromNameFelt("symbol")
+	erc20DecimalsSelector = starknetgoutils.GetSelectorFromNameFelt("decimals")
+)
+
+// TokenMetadata is the ERC-20 metadata of a token.
+type TokenMetadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+// FormatAmount formats an amount of the token in whole tokens.
+func (m *TokenMetadata) FormatAmount(amount *big.Int) string {
+	return FormatTokenAmount(amount, m.Decimals)
+}
+
+// FormatTokenAmount formats an amount with decimals as a decimal number,
+// without trailing zeros.
+func FormatTokenAmount(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+
+	base := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(amount), base, new(big.Int))
+
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+
+	fracStr := strings.TrimRight(fmt.Sprintf("%0*s", int(decimals), frac.String()), "0")
+	return sign + whole.String() + "." + fracStr
+}
+
+// TokenUSDValue returns the USD value of an amount with decimals of a token
+// at rate, with the decimals of the rate.
+func TokenUSDValue(amount, rate *big.Int, decimals uint8) *big.Int {
+	value := new(big.Int).Mul(amount, rate)
+	return value.Quo(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+// fetchTokenMetadata reads the ERC-20 metadata of a token on-chain.
+func fetchTokenMetadata(ctx context.Context, client starknet.ProviderWrapper, token *felt.Felt) (*TokenMetadata, error) {
+	call := func(selector *felt.Felt) ([]*felt.Felt, error) {
+		var resp []*felt.Felt
+		var err error
+		if err := client.Do(func(provider rpc.RpcProvider) error {
+			resp, err = provider.Call(ctx, rpc.FunctionCall{
+				ContractAddress:    token,
+				EntryPointSelector: selector,
+				Calldata:           []*felt.Felt{},
+			}, rpc.WithBlockTag("latest"))
+			return err
+		}); err != nil {
+			return nil, starknet.FormatRpcError(err)
+		}
+
+		if len(resp) == 0 {
+			return nil, fmt.Errorf("empty response")
+		}
+		return resp, nil
+	}
+
+	nameResp, err := call(erc20NameSelector)
+	if err != nil {
+		return nil, fmt.Errorf("name call failed: %w", err)
+	}
+	name, err := decodeTokenString(nameResp)
+	if err != nil {
+		return nil, fmt.Errorf("parse name failed: %w", err)
+	}
+
+	symbolResp, err := call(erc20SymbolSelector)
+	if err != nil {
+		return nil, fmt.Errorf("symbol call failed: %w", err)
+	}
+	symbol, err := decodeTokenString(symbolResp)
+	if err != nil {
+		return nil, fmt.Errorf("parse symbol failed: %w", err)
+	}
+
+	decimalsResp, err := call(erc20DecimalsSelector)
+	if err != nil {
+		return nil, fmt.Errorf("decimals call failed: %w", err)
+	}
+	decimals := decimalsResp[0].Uint64()
+	if decimals > 255 {
+		return nil, fmt.Errorf("invalid decimals: %d", decimals)
+	}
+
+	return &TokenMetadata{
+		Name:     name,
+		Symbol:   symbol,
+		Decimals: uint8(decimals),
+	}, nil
+}
+
+// decodeTokenString decodes a token name or symbol, which older tokens return
+// as a short string and newer ones as a byte array.
+func decodeTokenString(resp []*felt.Felt) (string, error) {
+	if len(resp) == 1 {
+		return starknet.FeltToShortString(resp[0]), nil
+	}
+	return starknet.ByteArrFeltToString(resp)
+}
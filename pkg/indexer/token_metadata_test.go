@@ -0,0 +1,197 @@
+package indexer_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	starknetgoutils "github.com/NethermindEth/starknet.go/utils"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
+)
+
+func TestFormatTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals uint8
+		expected string
+	}{
+		{"0", 18, "0"},
+		{"1000000000000000000", 18, "1"},
+		{"1500000000000000000", 18, "1.5"},
+		{"1", 18, "0.000000000000000001"},
+		{"-2500000", 6, "-2.5"},
+		{"42", 0, "42"},
+	}
+
+	for _, tt := range tests {
+		amount, _ := new(big.Int).SetString(tt.amount, 10)
+		if got := indexer.FormatTokenAmount(amount, tt.decimals); got != tt.expected {
+			t.Errorf("FormatTokenAmount(%s, %d) = %s, expected %s", tt.amount, tt.decimals, got, tt.expected)
+		}
+	}
+}
+
+func TestTokenUSDValue(t *testing.T) {
+	// $0.5 per token, with 18 decimals
+	rate := big.NewInt(5e17)
+
+	// 3 tokens of 6 and 18 decimals are worth the same
+	for _, tt := range []struct {
+		amount   *big.Int
+		decimals uint8
+	}{
+		{big.NewInt(3e6), 6},
+		{new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)), 18},
+	} {
+		if value := indexer.TokenUSDValue(tt.amount, rate, tt.decimals); value.Cmp(big.NewInt(15e17)) != 0 {
+			t.Errorf("expected $1.5 for %s with %d decimals, got %s", tt.amount, tt.decimals, value)
+		}
+	}
+}
+
+// byteArray encodes a string as a Cairo ByteArray.
+func byteArray(s string) []*felt.Felt {
+	words := []*felt.Felt{new(felt.Felt).SetUint64(uint64(len(s) / 31))}
+	for ; len(s) >= 31; s = s[31:] {
+		words = append(words, new(felt.Felt).SetBytes([]byte(s[:31])))
+	}
+	return append(words, new(felt.Felt).SetBytes([]byte(s)), new(felt.Felt).SetUint64(uint64(len(s))))
+}
+
+func shortString(s string) []*felt.Felt {
+	return []*felt.Felt{new(felt.Felt).SetBytes([]byte(s))}
+}
+
+func TestDecodeTokenString(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     []*felt.Felt
+		expected string
+	}{
+		{"short string", shortString("ETH"), "ETH"},
+		{"byte array", byteArray("Starknet Token"), "Starknet Token"},
+		{"long byte array", byteArray("A token whose name does not fit in a single felt"), "A token whose name does not fit in a single felt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := indexer.DecodeTokenString(tt.resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := indexer.DecodeTokenString([]*felt.Felt{new(felt.Felt), new(felt.Felt)}); err == nil {
+		t.Error("expected an error for a truncated byte array")
+	}
+}
+
+// newTokenRPCStub serves starknet_call with the responses of each token to
+// each selector.
+func newTokenRPCStub(t *testing.T, responses map[[32]byte]map[string][]*felt.Felt) *httptest.Server {
+	selectors := make(map[[32]byte]string)
+	for _, name := range []string{"name", "symbol", "decimals"} {
+		selectors[starknetgoutils.GetSelectorFromNameFelt(name).Bytes()] = name
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.Method != "starknet_call" {
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0.7.1"})
+			return
+		}
+
+		var call struct {
+			ContractAddress    *felt.Felt `json:"contract_address"`
+			EntryPointSelector *felt.Felt `json:"entry_point_selector"`
+		}
+		if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &call) != nil || call.ContractAddress == nil || call.EntryPointSelector == nil {
+			t.Errorf("unexpected call params: %s", req.Params)
+			return
+		}
+
+		resp, ok := responses[call.ContractAddress.Bytes()][selectors[call.EntryPointSelector.Bytes()]]
+		if !ok {
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": 21, "message": "Requested entrypoint does not exist in the contract"}})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": resp})
+	}))
+}
+
+func TestFetchTokenMetadata(t *testing.T) {
+	legacyToken := new(felt.Felt).SetUint64(1)
+	token := new(felt.Felt).SetUint64(2)
+	brokenToken := new(felt.Felt).SetUint64(3)
+
+	server := newTokenRPCStub(t, map[[32]byte]map[string][]*felt.Felt{
+		legacyToken.Bytes(): {
+			"name":     shortString("Ether"),
+			"symbol":   shortString("ETH"),
+			"decimals": {new(felt.Felt).SetUint64(18)},
+		},
+		token.Bytes(): {
+			"name":     byteArray("Starknet Token"),
+			"symbol":   byteArray("STRK"),
+			"decimals": {new(felt.Felt).SetUint64(18)},
+		},
+		brokenToken.Bytes(): {
+			"name":   shortString("Broken"),
+			"symbol": shortString("BRK"),
+		},
+	})
+	defer server.Close()
+
+	provider, err := rpc.NewProvider(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := starknet.NewRateLimitedMultiProvider(starknet.RateLimitedMultiProviderConfig{
+		Providers: []rpc.RpcProvider{provider},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		token    *felt.Felt
+		expected indexer.TokenMetadata
+	}{
+		{legacyToken, indexer.TokenMetadata{Name: "Ether", Symbol: "ETH", Decimals: 18}},
+		{token, indexer.TokenMetadata{Name: "Starknet Token", Symbol: "STRK", Decimals: 18}},
+	} {
+		metadata, err := indexer.FetchTokenMetadata(context.Background(), client, tt.token)
+		if err != nil {
+			t.Fatalf("failed to fetch metadata of %s: %v", tt.token, err)
+		}
+		if *metadata != tt.expected {
+			t.Errorf("expected %+v, got %+v", tt.expected, *metadata)
+		}
+	}
+
+	if _, err := indexer.FetchTokenMetadata(context.Background(), client, brokenToken); err == nil {
+		t.Errorf("expected an error for %s, which has no decimals", brokenToken)
+	}
+}
@@ -20,6 +20,14 @@ type UserInfo struct {
 	BreakCount      uint64
 }
 
+// UserPromptsPage is a page of the prompts paid by a user.
+type UserPromptsPage struct {
+	Prompts []*UserPromptEntry
+	// NextCursor is the cursor of the next page, or 0 if there is none.
+	NextCursor uint64
+	LastBlock  uint64
+}
+
 type UserIndexer struct {
 	client starknet.ProviderWrapper
 
@@ -57,7 +65,10 @@ func NewUserIndexer(config *UserIndexerConfig) *UserIndexer {
 	}
 
 	eventCh := make(chan *EventSubscriptionData, 1000)
-	eventSubID := config.EventWatcher.Subscribe(EventAgentRegistered|EventDrained|EventPromptPaid, eventCh)
+	eventSubID := config.EventWatcher.SubscribeWithConfig(&EventSubscriberConfig{
+		Type: EventAgentRegistered | EventDrained | EventPromptPaid | EventPromptConsumed | EventPromptReclaimed,
+		Name: "user_indexer",
+	}, eventCh)
 
 	return &UserIndexer{
 		client:          config.Client,
@@ -107,7 +118,15 @@ func (i *UserIndexer) run(ctx context.Context) error {
 		select {
 		case data := <-i.eventCh:
 			i.mu.Lock()
+			if data.Rollback {
+				slog.Warn("reverting users", "block", data.ToBlock)
+				i.db.RevertToBlock(data.ToBlock)
+				i.db.SortUsers(i.priceCache)
+				i.mu.Unlock()
+				continue
+			}
 			for _, ev := range data.Events {
+				i.db.SetCurrentEvent(ev)
 				switch ev.Type {
 				case EventAgentRegistered:
 					i.onAgentRegisteredEvent(ev)
@@ -115,6 +134,10 @@ func (i *UserIndexer) run(ctx context.Context) error {
 					i.onDrainedEvent(ev)
 				case EventPromptPaid:
 					i.onPromptPaidEvent(ev)
+				case EventPromptConsumed:
+					i.onPromptConsumedEvent(ev)
+				case EventPromptReclaimed:
+					i.onPromptReclaimedEvent(ev)
 				}
 			}
 			i.db.SetLastIndexedBlock(data.ToBlock)
@@ -150,7 +173,7 @@ func (i *UserIndexer) onDrainedEvent(ev *Event) {
 		return
 	}
 
-	i.db.StoreDrainedData(ev.Raw.FromAddress, drainedEvent)
+	i.db.StoreDrainedData(ev.Raw.FromAddress, drainedEvent, i.promptEventInfo(ev))
 }
 
 func (i *UserIndexer) onPromptPaidEvent(ev *Event) {
@@ -164,7 +187,43 @@ func (i *UserIndexer) onPromptPaidEvent(ev *Event) {
 		return
 	}
 
-	i.db.StorePromptPaidData(ev.Raw.FromAddress, promptPaidEvent)
+	i.db.StorePromptPaidData(ev.Raw.FromAddress, promptPaidEvent, i.promptEventInfo(ev))
+}
+
+func (i *UserIndexer) onPromptConsumedEvent(ev *Event) {
+	promptConsumedEvent, ok := ev.ToPromptConsumedEvent()
+	if !ok {
+		slog.Error("failed to parse prompt consumed event")
+		return
+	}
+
+	if !i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
+		return
+	}
+
+	i.db.StorePromptConsumedData(ev.Raw.FromAddress, promptConsumedEvent, i.promptEventInfo(ev))
+}
+
+func (i *UserIndexer) onPromptReclaimedEvent(ev *Event) {
+	promptReclaimedEvent, ok := ev.ToPromptReclaimedEvent()
+	if !ok {
+		slog.Error("failed to parse prompt reclaimed event")
+		return
+	}
+
+	if !i.db.GetAgentExists(ev.Raw.FromAddress.Bytes()) {
+		return
+	}
+
+	i.db.StorePromptReclaimedData(ev.Raw.FromAddress, promptReclaimedEvent, i.promptEventInfo(ev))
+}
+
+func (i *UserIndexer) promptEventInfo(ev *Event) PromptEventInfo {
+	return PromptEventInfo{
+		Block:     ev.Block,
+		Timestamp: ev.Timestamp,
+		TxHash:    ev.Raw.TransactionHash,
+	}
 }
 
 // GetUserLeaderboardCount returns the number of users in the leaderboard.
@@ -178,10 +237,34 @@ func (i *UserIndexer) GetUserLeaderboard(start, end uint64) (*UserLeaderboardRes
 	return i.db.GetLeaderboard(start, end, i.priceCache)
 }
 
+// GetWindowedUserLeaderboard returns start:end users from the user leaderboard
+// over a window. Users are sorted by value won (desc), break count (desc) and
+// prompt count (desc) within the window.
+func (i *UserIndexer) GetWindowedUserLeaderboard(window LeaderboardWindow, start, end uint64) (*UserLeaderboardResponse, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.db.GetWindowedLeaderboard(window, start, end, i.priceCache)
+}
+
 func (i *UserIndexer) GetUserInfo(addr *felt.Felt) (*UserInfo, bool) {
 	return i.db.GetUserInfo(addr.Bytes())
 }
 
+// GetUserPrompts returns a page of the prompts paid by a user across all
+// agents, newest first.
+func (i *UserIndexer) GetUserPrompts(addr *felt.Felt, cursor uint64, limit int) *UserPromptsPage {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	prompts, nextCursor := i.db.GetUserPrompts(addr.Bytes(), cursor, limit)
+	return &UserPromptsPage{
+		Prompts:    prompts,
+		NextCursor: nextCursor,
+		LastBlock:  i.db.GetLastIndexedBlock(),
+	}
+}
+
 func (i *UserIndexer) GetLastIndexedBlock() uint64 {
 	return i.db.GetLastIndexedBlock()
 }
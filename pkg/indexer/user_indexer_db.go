@@ -19,14 +19,21 @@ type UserIndexerDatabaseReader interface {
 	GetAgentExists(addr [32]byte) bool
 	GetLastIndexedBlock() uint64
 	GetLeaderboard(start, end uint64, priceCache AgentBalanceIndexerPriceCache) (*UserLeaderboardResponse, error)
+	GetWindowedLeaderboard(window LeaderboardWindow, start, end uint64, priceCache AgentBalanceIndexerPriceCache) (*UserLeaderboardResponse, error)
 	GetLeaderboardCount() uint64
+	GetUserPrompts(addr [32]byte, cursor uint64, limit int) ([]*UserPromptEntry, uint64)
 }
 
 type UserIndexerDatabaseWriter interface {
 	StoreAgentRegisteredData(agentRegisteredEvent *AgentRegisteredEvent)
-	StoreDrainedData(agentAddr *felt.Felt, drainedEvent *DrainedEvent)
-	StorePromptPaidData(agentAddr *felt.Felt, promptPaidEvent *PromptPaidEvent)
+	StoreDrainedData(agentAddr *felt.Felt, drainedEvent *DrainedEvent, info PromptEventInfo)
+	StorePromptPaidData(agentAddr *felt.Felt, promptPaidEvent *PromptPaidEvent, info PromptEventInfo)
+	StorePromptConsumedData(agentAddr *felt.Felt, promptConsumedEvent *PromptConsumedEvent, info PromptEventInfo)
+	StorePromptReclaimedData(agentAddr *felt.Felt, promptReclaimedEvent *PromptReclaimedEvent, info PromptEventInfo)
 	SetLastIndexedBlock(block uint64)
+	SetCurrentBlock(block uint64)
+	SetCurrentEvent(ev *Event)
+	RevertToBlock(block uint64)
 	SortUsers(priceCache AgentBalanceIndexerPriceCache)
 }
 
@@ -36,11 +43,45 @@ type UserIndexerDatabase interface {
 }
 
 type UserIndexerDatabaseInMemory struct {
-	mu               sync.RWMutex
-	agentTokens      map[[32]byte][32]byte
-	infos            map[[32]byte]*UserInfo
+	mu                sync.RWMutex
+	agentTokens       map[[32]byte][32]byte
+	agentPromptPrices map[[32]byte]*big.Int
+	infos             map[[32]byte]*UserInfo
+	// prompts holds the prompts paid by each user, in payment order.
+	prompts          map[[32]byte][]*UserPromptEntry
+	promptRefs       map[userPromptKey]userPromptRef
+	windowedStats    *windowedStats
 	lastIndexedBlock uint64
 	sortedUsers      *utils.LazySortedList[[32]byte]
+	undoLog          *utils.UndoLog
+}
+
+// UserPromptEntry is a prompt paid by a user. Entries are replaced rather than
+// modified once stored.
+type UserPromptEntry struct {
+	Agent    *felt.Felt
+	PromptID uint64
+	TweetID  uint64
+	Prompt   string
+	Outcome  PromptOutcome
+	// Amount is the price paid for the prompt, in Token.
+	Amount *big.Int
+	Token  *felt.Felt
+	// DrainAmount is the amount won by draining the agent with the prompt.
+	DrainAmount *big.Int
+	Block       uint64
+	Timestamp   uint64
+	TxHash      *felt.Felt
+}
+
+type userPromptKey struct {
+	agent    [32]byte
+	promptID uint64
+}
+
+type userPromptRef struct {
+	user [32]byte
+	idx  int
 }
 
 type UserPromptCacheKey [32]byte
@@ -52,17 +93,25 @@ type UserPromptCacheData struct {
 var _ UserIndexerDatabase = (*UserIndexerDatabaseInMemory)(nil)
 
 type UserLeaderboardResponse struct {
-	Users     [][32]byte
+	Users [][32]byte
+	// Stats holds the stats of each user over the window of a windowed
+	// leaderboard.
+	Stats     []*WindowedStats
 	UserCount uint64
 	LastBlock uint64
 }
 
 func NewUserIndexerDatabaseInMemory(initialBlock uint64) *UserIndexerDatabaseInMemory {
 	return &UserIndexerDatabaseInMemory{
-		infos:            make(map[[32]byte]*UserInfo),
-		agentTokens:      make(map[[32]byte][32]byte),
-		lastIndexedBlock: initialBlock,
-		sortedUsers:      utils.NewLazySortedList[[32]byte](),
+		infos:             make(map[[32]byte]*UserInfo),
+		agentTokens:       make(map[[32]byte][32]byte),
+		agentPromptPrices: make(map[[32]byte]*big.Int),
+		prompts:           make(map[[32]byte][]*UserPromptEntry),
+		promptRefs:        make(map[userPromptKey]userPromptRef),
+		windowedStats:     newWindowedStats(),
+		lastIndexedBlock:  initialBlock,
+		sortedUsers:       utils.NewLazySortedList[[32]byte](),
+		undoLog:           utils.NewUndoLog(),
 	}
 }
 
@@ -91,27 +140,111 @@ func (db *UserIndexerDatabaseInMemory) StoreAgentRegisteredData(agentRegisteredE
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	prevToken, existed := db.agentTokens[addrBytes]
+	prevPromptPrice := db.agentPromptPrices[addrBytes]
+	db.undoLog.Push(func() {
+		if existed {
+			db.agentTokens[addrBytes] = prevToken
+			db.agentPromptPrices[addrBytes] = prevPromptPrice
+		} else {
+			delete(db.agentTokens, addrBytes)
+			delete(db.agentPromptPrices, addrBytes)
+		}
+	})
+
 	db.agentTokens[addrBytes] = agentRegisteredEvent.TokenAddress.Bytes()
+	db.agentPromptPrices[addrBytes] = agentRegisteredEvent.PromptPrice
 }
 
-func (db *UserIndexerDatabaseInMemory) StorePromptPaidData(agentAddr *felt.Felt, promptPaidEvent *PromptPaidEvent) {
+func (db *UserIndexerDatabaseInMemory) StorePromptPaidData(agentAddr *felt.Felt, promptPaidEvent *PromptPaidEvent, eventInfo PromptEventInfo) {
+	agentAddrBytes := agentAddr.Bytes()
 	userAddrBytes := promptPaidEvent.User.Bytes()
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUserUndo(userAddrBytes)
+
 	info := db.getOrCreateUserInfo(userAddrBytes)
 	info.PromptCount++
 	db.infos[userAddrBytes] = info
+
+	db.windowedStats.update(db.undoLog, userAddrBytes, eventInfo.Timestamp, func(stats *WindowedStats) {
+		stats.PromptCount++
+	})
+
+	key := userPromptKey{agent: agentAddrBytes, promptID: promptPaidEvent.PromptID}
+	if _, ok := db.promptRefs[key]; ok {
+		return
+	}
+
+	agentToken := db.agentTokens[agentAddrBytes]
+	prompts := db.prompts[userAddrBytes]
+	db.undoLog.Push(func() {
+		db.prompts[userAddrBytes] = db.prompts[userAddrBytes][:len(prompts)]
+		delete(db.promptRefs, key)
+	})
+
+	db.promptRefs[key] = userPromptRef{user: userAddrBytes, idx: len(prompts)}
+	db.prompts[userAddrBytes] = append(prompts, &UserPromptEntry{
+		Agent:     agentAddr,
+		PromptID:  promptPaidEvent.PromptID,
+		TweetID:   promptPaidEvent.TweetID,
+		Prompt:    promptPaidEvent.Prompt,
+		Outcome:   PromptOutcomePending,
+		Amount:    db.agentPromptPrices[agentAddrBytes],
+		Token:     new(felt.Felt).SetBytes(agentToken[:]),
+		Block:     eventInfo.Block,
+		Timestamp: eventInfo.Timestamp,
+		TxHash:    eventInfo.TxHash,
+	})
 }
 
-func (db *UserIndexerDatabaseInMemory) StoreDrainedData(agentAddr *felt.Felt, drainedEvent *DrainedEvent) {
+func (db *UserIndexerDatabaseInMemory) StorePromptConsumedData(agentAddr *felt.Felt, promptConsumedEvent *PromptConsumedEvent, _ PromptEventInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	outcome := PromptOutcomeDrained
+	if promptConsumedEvent.DrainedTo.Equal(agentAddr) {
+		outcome = PromptOutcomeFailed
+	}
+
+	db.updateUserPrompt(agentAddr.Bytes(), promptConsumedEvent.PromptID, func(entry *UserPromptEntry) {
+		entry.Outcome = outcome
+	})
+}
+
+func (db *UserIndexerDatabaseInMemory) StorePromptReclaimedData(agentAddr *felt.Felt, promptReclaimedEvent *PromptReclaimedEvent, _ PromptEventInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.updateUserPrompt(agentAddr.Bytes(), promptReclaimedEvent.PromptID, func(entry *UserPromptEntry) {
+		entry.Outcome = PromptOutcomeReclaimed
+	})
+}
+
+// GetUserPrompts returns the prompts paid by a user, newest first, below the
+// cursor of a previous page. The cursor of a prompt is its position among the
+// prompts of the user, starting at 1. It also returns the cursor of the next
+// page, or 0 if there is none.
+func (db *UserIndexerDatabaseInMemory) GetUserPrompts(addr [32]byte, cursor uint64, limit int) ([]*UserPromptEntry, uint64) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return pageBelow(db.prompts[addr], func(idx int) uint64 {
+		return uint64(idx) + 1
+	}, cursor, limit, nil)
+}
+
+func (db *UserIndexerDatabaseInMemory) StoreDrainedData(agentAddr *felt.Felt, drainedEvent *DrainedEvent, eventInfo PromptEventInfo) {
 	agentAddrBytes := agentAddr.Bytes()
 	userAddrBytes := drainedEvent.User.Bytes()
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.pushUserUndo(userAddrBytes)
+
 	userInfo := db.getOrCreateUserInfo(userAddrBytes)
 	agentToken, ok := db.agentTokens[agentAddrBytes]
 	if !ok {
@@ -122,10 +255,24 @@ func (db *UserIndexerDatabaseInMemory) StoreDrainedData(agentAddr *felt.Felt, dr
 	if !ok {
 		accruedBalanceInToken = big.NewInt(0)
 	}
-	accruedBalanceInToken = accruedBalanceInToken.Add(accruedBalanceInToken, drainedEvent.Amount)
+	accruedBalanceInToken = new(big.Int).Add(accruedBalanceInToken, drainedEvent.Amount)
 	userInfo.AccruedBalances[agentToken] = accruedBalanceInToken
 	userInfo.BreakCount++
 	db.infos[userAddrBytes] = userInfo
+
+	db.windowedStats.update(db.undoLog, userAddrBytes, eventInfo.Timestamp, func(stats *WindowedStats) {
+		amount, ok := stats.Amounts[agentToken]
+		if !ok {
+			amount = big.NewInt(0)
+		}
+		stats.Amounts[agentToken] = new(big.Int).Add(amount, drainedEvent.Amount)
+		stats.BreakCount++
+	})
+
+	db.updateUserPrompt(agentAddrBytes, drainedEvent.PromptID, func(entry *UserPromptEntry) {
+		entry.Outcome = PromptOutcomeDrained
+		entry.DrainAmount = drainedEvent.Amount
+	})
 }
 
 func (db *UserIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
@@ -134,6 +281,67 @@ func (db *UserIndexerDatabaseInMemory) GetLastIndexedBlock() uint64 {
 
 func (db *UserIndexerDatabaseInMemory) SetLastIndexedBlock(block uint64) {
 	db.lastIndexedBlock = block
+	db.undoLog.Prune(block - min(block, ReorgWindow))
+}
+
+func (db *UserIndexerDatabaseInMemory) SetCurrentBlock(block uint64) {
+	db.undoLog.SetBlock(block)
+}
+
+func (db *UserIndexerDatabaseInMemory) SetCurrentEvent(ev *Event) {
+	db.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. Users must be
+// sorted again afterwards.
+func (db *UserIndexerDatabaseInMemory) RevertToBlock(block uint64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.undoLog.Revert(block)
+	if db.lastIndexedBlock > block {
+		db.lastIndexedBlock = block
+	}
+
+	db.sortedUsers.Clear()
+	db.sortedUsers.Add(slices.Collect(maps.Keys(db.infos))...)
+}
+
+// pushUserUndo records how to restore a user's info as it is before a change.
+// Accrued balances are replaced rather than modified, so a shallow copy of
+// them is enough.
+func (db *UserIndexerDatabaseInMemory) pushUserUndo(addr [32]byte) {
+	info, existed := db.infos[addr]
+	var prevInfo UserInfo
+	if existed {
+		prevInfo = *info
+		prevInfo.AccruedBalances = maps.Clone(info.AccruedBalances)
+	}
+
+	db.undoLog.Push(func() {
+		if existed {
+			*db.infos[addr] = prevInfo
+		} else {
+			delete(db.infos, addr)
+		}
+	})
+}
+
+// updateUserPrompt replaces the entry of a prompt with an updated copy.
+func (db *UserIndexerDatabaseInMemory) updateUserPrompt(agentAddr [32]byte, promptID uint64, update func(entry *UserPromptEntry)) {
+	ref, ok := db.promptRefs[userPromptKey{agent: agentAddr, promptID: promptID}]
+	if !ok {
+		return
+	}
+
+	prevEntry := db.prompts[ref.user][ref.idx]
+	db.undoLog.Push(func() {
+		db.prompts[ref.user][ref.idx] = prevEntry
+	})
+
+	entry := *prevEntry
+	update(&entry)
+	db.prompts[ref.user][ref.idx] = &entry
 }
 
 func (db *UserIndexerDatabaseInMemory) getOrCreateUserInfo(addr [32]byte) *UserInfo {
@@ -186,6 +394,28 @@ func (db *UserIndexerDatabaseInMemory) GetLeaderboard(start, end uint64, priceCa
 	return leaderboard, nil
 }
 
+// GetWindowedLeaderboard returns start:end users ranked over a window, by
+// value won, break count and prompt count.
+func (db *UserIndexerDatabaseInMemory) GetWindowedLeaderboard(window LeaderboardWindow, start, end uint64, priceCache AgentBalanceIndexerPriceCache) (*UserLeaderboardResponse, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if start > end {
+		return nil, fmt.Errorf("invalid range: start (%d) > end (%d)", start, end)
+	}
+
+	totals := db.windowedStats.aggregate(window)
+	ranked := rankWindowedStats(totals, priceCache)
+	users, stats := pageWindowedStats(ranked, totals, start, end)
+
+	return &UserLeaderboardResponse{
+		Users:     users,
+		Stats:     stats,
+		UserCount: uint64(len(ranked)),
+		LastBlock: db.lastIndexedBlock,
+	}, nil
+}
+
 func (db *UserIndexerDatabaseInMemory) GetAgentExists(addr [32]byte) bool {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -213,23 +443,23 @@ func (db *UserIndexerDatabaseInMemory) SortUsers(priceCache AgentBalanceIndexerP
 		totalA := big.NewInt(0)
 		for token, balance := range infoA.AccruedBalances {
 			tokenFelt := new(felt.Felt).SetBytes(token[:])
-			rate, ok := priceCache.GetTokenRate(tokenFelt)
+			balanceUSD, ok := priceCache.GetTokenUSDValue(tokenFelt, balance)
 			if !ok {
-				slog.Error("failed to get rate for token", "token", token)
+				slog.Error("failed to get USD value for token", "token", token)
 				continue
 			}
-			totalA.Add(totalA, new(big.Int).Mul(balance, rate))
+			totalA.Add(totalA, balanceUSD)
 		}
 
 		totalB := big.NewInt(0)
 		for token, balance := range infoB.AccruedBalances {
 			tokenFelt := new(felt.Felt).SetBytes(token[:])
-			rate, ok := priceCache.GetTokenRate(tokenFelt)
+			balanceUSD, ok := priceCache.GetTokenUSDValue(tokenFelt, balance)
 			if !ok {
-				slog.Error("failed to get rate for token", "token", token)
+				slog.Error("failed to get USD value for token", "token", token)
 				continue
 			}
-			totalB.Add(totalB, new(big.Int).Mul(balance, rate))
+			totalB.Add(totalB, balanceUSD)
 		}
 
 		if totalA.Cmp(totalB) != 0 {
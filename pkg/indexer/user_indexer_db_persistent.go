@@ -0,0 +1,348 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"math/big"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+const (
+	usersTable             = "users"
+	agentTokensTable       = "agent_tokens"
+	agentPromptPricesTable = "agent_prompt_prices"
+	userPromptsTable       = "user_prompts"
+)
+
+// UserIndexerDatabasePersistent is a UserIndexerDatabase kept in memory and
+// persisted to a store on every indexed block.
+type UserIndexerDatabasePersistent struct {
+	*UserIndexerDatabaseInMemory
+
+	state        persistentState
+	dirty        map[[32]byte]struct{}
+	dirtyAgents  map[[32]byte]struct{}
+	dirtyPrompts map[userPromptRef]struct{}
+}
+
+// userRecord is the persisted UserInfo, keyed by the user address. Token
+// addresses are hex encoded, as JSON object keys must be strings.
+type userRecord struct {
+	AccruedBalances map[string]*big.Int
+	PromptCount     uint64
+	BreakCount      uint64
+}
+
+var _ UserIndexerDatabase = (*UserIndexerDatabasePersistent)(nil)
+
+// NewUserIndexerDatabasePersistent creates a UserIndexerDatabase persisted to
+// s, loading the state persisted by a previous run if any.
+func NewUserIndexerDatabasePersistent(s *store.Store, initialBlock uint64) (*UserIndexerDatabasePersistent, error) {
+	db := &UserIndexerDatabasePersistent{
+		UserIndexerDatabaseInMemory: NewUserIndexerDatabaseInMemory(initialBlock),
+		state:                       newPersistentState(s, "user_indexer"),
+		dirty:                       make(map[[32]byte]struct{}),
+		dirtyAgents:                 make(map[[32]byte]struct{}),
+		dirtyPrompts:                make(map[userPromptRef]struct{}),
+	}
+	db.windowedStats.dirty = make(map[windowedStatsKey]struct{})
+
+	block, ok, err := db.state.load(func(tx *store.Tx) error {
+		err := tx.ForEach(agentTokensTable, func(key []byte, decode func(value any) error) error {
+			var token string
+			if err := decode(&token); err != nil {
+				return err
+			}
+
+			tokenBytes, err := decodeHexAddress(token)
+			if err != nil {
+				return err
+			}
+
+			db.agentTokens[[32]byte(key)] = tokenBytes
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := loadWindowedStats(tx, db.windowedStats); err != nil {
+			return err
+		}
+
+		err = tx.ForEach(agentPromptPricesTable, func(key []byte, decode func(value any) error) error {
+			var promptPrice big.Int
+			if err := decode(&promptPrice); err != nil {
+				return err
+			}
+
+			db.agentPromptPrices[[32]byte(key)] = &promptPrice
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Keys are sorted by user, then payment order
+		err = tx.ForEach(userPromptsTable, func(key []byte, decode func(value any) error) error {
+			var entry UserPromptEntry
+			if err := decode(&entry); err != nil {
+				return err
+			}
+
+			user := [32]byte(key[:32])
+			db.promptRefs[userPromptKey{agent: entry.Agent.Bytes(), promptID: entry.PromptID}] = userPromptRef{
+				user: user,
+				idx:  len(db.prompts[user]),
+			}
+			db.prompts[user] = append(db.prompts[user], &entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.ForEach(usersTable, func(key []byte, decode func(value any) error) error {
+			var record userRecord
+			if err := decode(&record); err != nil {
+				return err
+			}
+
+			info := &UserInfo{
+				Address:         [32]byte(key),
+				AccruedBalances: make(map[[32]byte]*big.Int, len(record.AccruedBalances)),
+				PromptCount:     record.PromptCount,
+				BreakCount:      record.BreakCount,
+			}
+			for token, balance := range record.AccruedBalances {
+				tokenBytes, err := decodeHexAddress(token)
+				if err != nil {
+					return err
+				}
+				info.AccruedBalances[tokenBytes] = balance
+			}
+
+			db.infos[info.Address] = info
+			db.sortedUsers.Add(info.Address)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		db.lastIndexedBlock = block
+		db.undoLog = utils.NewUndoLog()
+		slog.Info("loaded persisted users", "users", len(db.infos), "last_indexed_block", block)
+	}
+
+	return db, nil
+}
+
+// StoreAgentRegisteredData stores the token of a registered agent.
+func (db *UserIndexerDatabasePersistent) StoreAgentRegisteredData(agentRegisteredEvent *AgentRegisteredEvent) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirtyAgents[agentRegisteredEvent.Agent.Bytes()] = struct{}{}
+	db.UserIndexerDatabaseInMemory.StoreAgentRegisteredData(agentRegisteredEvent)
+}
+
+// StoreDrainedData stores a drain by a user.
+func (db *UserIndexerDatabasePersistent) StoreDrainedData(agentAddr *felt.Felt, drainedEvent *DrainedEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[drainedEvent.User.Bytes()] = struct{}{}
+	db.UserIndexerDatabaseInMemory.StoreDrainedData(agentAddr, drainedEvent, info)
+	db.markPromptDirty(agentAddr, drainedEvent.PromptID)
+}
+
+// StorePromptPaidData stores a prompt paid by a user.
+func (db *UserIndexerDatabasePersistent) StorePromptPaidData(agentAddr *felt.Felt, promptPaidEvent *PromptPaidEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.dirty[promptPaidEvent.User.Bytes()] = struct{}{}
+	db.UserIndexerDatabaseInMemory.StorePromptPaidData(agentAddr, promptPaidEvent, info)
+	db.markPromptDirty(agentAddr, promptPaidEvent.PromptID)
+}
+
+// StorePromptConsumedData stores the outcome of a prompt.
+func (db *UserIndexerDatabasePersistent) StorePromptConsumedData(agentAddr *felt.Felt, promptConsumedEvent *PromptConsumedEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.UserIndexerDatabaseInMemory.StorePromptConsumedData(agentAddr, promptConsumedEvent, info)
+	db.markPromptDirty(agentAddr, promptConsumedEvent.PromptID)
+}
+
+// StorePromptReclaimedData stores a reclaimed prompt.
+func (db *UserIndexerDatabasePersistent) StorePromptReclaimedData(agentAddr *felt.Felt, promptReclaimedEvent *PromptReclaimedEvent, info PromptEventInfo) {
+	if db.state.skip() {
+		return
+	}
+
+	db.UserIndexerDatabaseInMemory.StorePromptReclaimedData(agentAddr, promptReclaimedEvent, info)
+	db.markPromptDirty(agentAddr, promptReclaimedEvent.PromptID)
+}
+
+func (db *UserIndexerDatabasePersistent) markPromptDirty(agentAddr *felt.Felt, promptID uint64) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if ref, ok := db.promptRefs[userPromptKey{agent: agentAddr.Bytes(), promptID: promptID}]; ok {
+		db.dirtyPrompts[ref] = struct{}{}
+	}
+}
+
+// SetLastIndexedBlock sets the last indexed block and persists the changes
+// made up to it.
+func (db *UserIndexerDatabasePersistent) SetLastIndexedBlock(block uint64) {
+	if db.state.stale(block) {
+		return
+	}
+
+	db.mu.RLock()
+	err := db.state.commit(block, func(tx *store.Tx, rewrite bool) error {
+		if rewrite {
+			if err := tx.Clear(usersTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(agentTokensTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(agentPromptPricesTable); err != nil {
+				return err
+			}
+			if err := tx.Clear(userPromptsTable); err != nil {
+				return err
+			}
+
+			clear(db.dirty)
+			for addr := range db.infos {
+				db.dirty[addr] = struct{}{}
+			}
+			clear(db.dirtyAgents)
+			for addr := range db.agentTokens {
+				db.dirtyAgents[addr] = struct{}{}
+			}
+			clear(db.dirtyPrompts)
+			for user, prompts := range db.prompts {
+				for idx := range prompts {
+					db.dirtyPrompts[userPromptRef{user: user, idx: idx}] = struct{}{}
+				}
+			}
+		}
+
+		for addr := range db.dirtyAgents {
+			token, ok := db.agentTokens[addr]
+			if !ok {
+				if err := tx.Delete(agentTokensTable, addr[:]); err != nil {
+					return err
+				}
+				if err := tx.Delete(agentPromptPricesTable, addr[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(agentTokensTable, addr[:], hex.EncodeToString(token[:])); err != nil {
+				return err
+			}
+			if promptPrice := db.agentPromptPrices[addr]; promptPrice != nil {
+				if err := tx.Put(agentPromptPricesTable, addr[:], promptPrice); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := persistWindowedStats(tx, db.windowedStats, rewrite); err != nil {
+			return err
+		}
+
+		for ref := range db.dirtyPrompts {
+			key := binary.BigEndian.AppendUint64(ref.user[:], uint64(ref.idx))
+			if ref.idx >= len(db.prompts[ref.user]) {
+				if err := tx.Delete(userPromptsTable, key); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Put(userPromptsTable, key, db.prompts[ref.user][ref.idx]); err != nil {
+				return err
+			}
+		}
+
+		for addr := range db.dirty {
+			info, ok := db.infos[addr]
+			if !ok {
+				if err := tx.Delete(usersTable, addr[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			record := &userRecord{
+				AccruedBalances: make(map[string]*big.Int, len(info.AccruedBalances)),
+				PromptCount:     info.PromptCount,
+				BreakCount:      info.BreakCount,
+			}
+			for token, balance := range info.AccruedBalances {
+				record.AccruedBalances[hex.EncodeToString(token[:])] = balance
+			}
+
+			if err := tx.Put(usersTable, addr[:], record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	db.mu.RUnlock()
+
+	if err != nil {
+		// The changes are persisted again with the next block.
+		slog.Error("failed to persist users", "block", block, "error", err)
+	} else {
+		clear(db.dirty)
+		clear(db.dirtyAgents)
+		clear(db.dirtyPrompts)
+		clear(db.windowedStats.dirty)
+	}
+
+	db.UserIndexerDatabaseInMemory.SetLastIndexedBlock(block)
+}
+
+// SetCurrentBlock sets the block the following changes belong to.
+func (db *UserIndexerDatabasePersistent) SetCurrentBlock(block uint64) {
+	db.state.setCurrentBlock(block)
+	db.UserIndexerDatabaseInMemory.SetCurrentBlock(block)
+}
+
+// SetCurrentEvent sets the event the following changes belong to.
+func (db *UserIndexerDatabasePersistent) SetCurrentEvent(ev *Event) {
+	db.state.setCurrentEvent(ev)
+	db.UserIndexerDatabaseInMemory.SetCurrentBlock(ev.Block)
+}
+
+// RevertToBlock reverts every change made after a given block. The state is
+// persisted again on the next indexed block.
+func (db *UserIndexerDatabasePersistent) RevertToBlock(block uint64) {
+	if db.state.stale(block) {
+		slog.Warn("cannot revert persisted users", "block", block, "persisted_block", db.state.resumeBlock)
+	}
+
+	db.state.revert(block)
+	db.UserIndexerDatabaseInMemory.RevertToBlock(block)
+}
@@ -0,0 +1,67 @@
+package utils
+
+// UndoLog records how to undo changes per block, so that state derived from
+// reverted blocks can be rolled back after a chain reorganization
+type UndoLog struct {
+	entries []undoEntry
+	block   uint64
+}
+
+type undoEntry struct {
+	block uint64
+	undo  func()
+}
+
+// NewUndoLog creates a new UndoLog
+func NewUndoLog() *UndoLog {
+	return &UndoLog{
+		entries: make([]undoEntry, 0),
+	}
+}
+
+// SetBlock sets the block the following changes belong to
+func (l *UndoLog) SetBlock(block uint64) {
+	l.block = block
+}
+
+// Push records how to undo a change made in the current block
+func (l *UndoLog) Push(undo func()) {
+	l.entries = append(l.entries, undoEntry{block: l.block, undo: undo})
+}
+
+// Revert undoes every change made after block, newest first
+func (l *UndoLog) Revert(block uint64) {
+	for idx := len(l.entries) - 1; idx >= 0; idx-- {
+		if l.entries[idx].block > block {
+			l.entries[idx].undo()
+		}
+	}
+
+	entries := l.entries[:0]
+	for _, entry := range l.entries {
+		if entry.block <= block {
+			entries = append(entries, entry)
+		}
+	}
+	l.entries = entries
+
+	if l.block > block {
+		l.block = block
+	}
+}
+
+// Prune forgets the changes made up to block, which can no longer be reverted
+func (l *UndoLog) Prune(block uint64) {
+	entries := make([]undoEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		if entry.block > block {
+			entries = append(entries, entry)
+		}
+	}
+	l.entries = entries
+}
+
+// Len returns the number of recorded changes
+func (l *UndoLog) Len() int {
+	return len(l.entries)
+}
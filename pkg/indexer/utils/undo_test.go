@@ -0,0 +1,41 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/teeception/pkg/indexer/utils"
+)
+
+func TestUndoLog(t *testing.T) {
+	log := utils.NewUndoLog()
+	state := []int{}
+
+	apply := func(block uint64, value int) {
+		log.SetBlock(block)
+		prev := state
+		state = append(append([]int{}, state...), value)
+		log.Push(func() {
+			state = prev
+		})
+	}
+
+	apply(10, 1)
+	apply(11, 2)
+	apply(12, 3)
+	apply(12, 4)
+
+	log.Revert(11)
+	if len(state) != 2 || state[1] != 2 {
+		t.Fatalf("expected state [1 2] after reverting to block 11, got %v", state)
+	}
+
+	log.Prune(10)
+	if log.Len() != 1 {
+		t.Fatalf("expected 1 revertible change after pruning, got %d", log.Len())
+	}
+
+	log.Revert(5)
+	if len(state) != 1 || state[0] != 1 {
+		t.Fatalf("expected pruned changes to be kept, got %v", state)
+	}
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "teeception"
+
+var (
+	PromptsReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "prompts_received_total",
+		Help:      "Number of PromptPaid events received.",
+	})
+	PromptsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "prompts_processed_total",
+		Help:      "Number of prompts processed to completion.",
+	})
+	PromptsDrained = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "prompts_drained_total",
+		Help:      "Number of prompts that drained an agent.",
+	})
+	PromptsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "prompts_failed_total",
+		Help:      "Number of prompts whose processing failed.",
+	})
+	PromptsDeferred = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "prompts_deferred_total",
+		Help:      "Number of prompts deferred as near-duplicates of a user's recent prompts.",
+	})
+
+	LLMLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of LLM prompt requests.",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"model"})
+	LLMErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "errors_total",
+		Help:      "Number of failed LLM prompt requests.",
+	}, []string{"model"})
+
+	TxQueueBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "txqueue",
+		Name:      "batch_size",
+		Help:      "Number of queued items submitted per batch.",
+		Buckets:   prometheus.LinearBuckets(1, 1, 10),
+	})
+	TxQueueSubmissions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "txqueue",
+		Name:      "submissions_total",
+		Help:      "Number of transaction submissions by mode (multicall, single) and outcome (success, failure).",
+	}, []string{"mode", "outcome"})
+	TxQueueMaxFee = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "txqueue",
+		Name:      "max_fee_wei_total",
+		Help:      "Sum of the max fees of broadcast transactions, an upper bound of the fee paid.",
+	})
+	TxQueueActualFee = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "txqueue",
+		Name:      "actual_fee_total",
+		Help:      "Sum of the fees paid by broadcast transactions, from their receipts, by fee unit (WEI, FRI).",
+	}, []string{"unit"})
+
+	TwitterPostFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "twitter",
+		Name:      "post_failures_total",
+		Help:      "Number of failed tweet and reply deliveries.",
+	}, []string{"kind"})
+
+	EventWatcherHeadBlock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "head_block",
+		Help:      "Latest block number reported by the chain.",
+	})
+	EventWatcherLastIndexedBlock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "last_indexed_block",
+		Help:      "Last block indexed by the event watcher.",
+	})
+	EventWatcherLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "lag_blocks",
+		Help:      "Number of blocks the event watcher is behind the chain head.",
+	})
+	EventWatcherReorgs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "reorgs_total",
+		Help:      "Total number of rollbacks caused by chain reorganizations.",
+	})
+	EventWatcherSubscriberQueue = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "subscriber_queue_length",
+		Help:      "Number of messages queued for a subscriber.",
+	}, []string{"subscriber"})
+	EventWatcherSubscriberDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "subscriber_dropped_total",
+		Help:      "Total number of messages dropped because a subscriber's queue was full.",
+	}, []string{"subscriber"})
+	EventWatcherSubscriberResyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event_watcher",
+		Name:      "subscriber_resyncs_total",
+		Help:      "Total number of times a subscriber was resynced after overflowing.",
+	}, []string{"subscriber"})
+)
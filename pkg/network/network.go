@@ -0,0 +1,134 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+const (
+	NameMainnet = "mainnet"
+	NameSepolia = "sepolia"
+	NameDevnet  = "devnet"
+	NameCustom  = "custom"
+)
+
+var (
+	ethAddress, _  = new(felt.Felt).SetString("0x049d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7")
+	strkAddress, _ = new(felt.Felt).SetString("0x04718f5a0fc34cc1af16a1cdee98ffb20c31f5cd61d6ab07201858f4287c938d")
+)
+
+// Profile describes a Starknet network the services are deployed on.
+type Profile struct {
+	Name    string `json:"name"`
+	ChainID string `json:"chain_id"`
+	// FeeTokenAddress is the token the agent account pays transaction fees with.
+	FeeTokenAddress *felt.Felt `json:"fee_token_address"`
+	// StrkTokenAddress is the STRK token, used as the base for token prices.
+	StrkTokenAddress *felt.Felt `json:"strk_token_address"`
+	// ExplorerTxURL and ExplorerContractURL are templates with a single %s
+	// placeholder for the transaction hash or contract address.
+	ExplorerTxURL       string   `json:"explorer_tx_url"`
+	ExplorerContractURL string   `json:"explorer_contract_url"`
+	DefaultRpcUrls      []string `json:"default_rpc_urls"`
+}
+
+var (
+	Mainnet = &Profile{
+		Name:                NameMainnet,
+		ChainID:             "SN_MAIN",
+		FeeTokenAddress:     ethAddress,
+		StrkTokenAddress:    strkAddress,
+		ExplorerTxURL:       "https://voyager.online/tx/%s",
+		ExplorerContractURL: "https://voyager.online/contract/%s",
+		DefaultRpcUrls:      []string{"https://starknet-mainnet.public.blastapi.io/rpc/v0_7"},
+	}
+
+	Sepolia = &Profile{
+		Name:                NameSepolia,
+		ChainID:             "SN_SEPOLIA",
+		FeeTokenAddress:     ethAddress,
+		StrkTokenAddress:    strkAddress,
+		ExplorerTxURL:       "https://sepolia.voyager.online/tx/%s",
+		ExplorerContractURL: "https://sepolia.voyager.online/contract/%s",
+		DefaultRpcUrls:      []string{"https://starknet-sepolia.public.blastapi.io/rpc/v0_7"},
+	}
+
+	// Devnet matches the defaults of starknet-devnet, which has no explorer.
+	Devnet = &Profile{
+		Name:             NameDevnet,
+		ChainID:          "SN_SEPOLIA",
+		FeeTokenAddress:  ethAddress,
+		StrkTokenAddress: strkAddress,
+		DefaultRpcUrls:   []string{"http://127.0.0.1:5050/rpc"},
+	}
+)
+
+// Get returns the built-in profile with the given name.
+func Get(name string) (*Profile, error) {
+	switch strings.ToLower(name) {
+	case NameMainnet:
+		return Mainnet, nil
+	case NameSepolia:
+		return Sepolia, nil
+	case NameDevnet:
+		return Devnet, nil
+	default:
+		return nil, fmt.Errorf("unknown network: %s", name)
+	}
+}
+
+// Load returns the built-in profile with the given name, or reads the
+// profile at customPath for the custom network.
+func Load(name, customPath string) (*Profile, error) {
+	if strings.ToLower(name) != NameCustom {
+		return Get(name)
+	}
+
+	if customPath == "" {
+		return nil, fmt.Errorf("profile file required for %s network", NameCustom)
+	}
+
+	data, err := os.ReadFile(customPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network profile: %v", err)
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal network profile: %v", err)
+	}
+
+	if profile.Name == "" {
+		profile.Name = NameCustom
+	}
+	if profile.FeeTokenAddress == nil {
+		return nil, fmt.Errorf("network profile is missing fee_token_address")
+	}
+	if profile.StrkTokenAddress == nil {
+		profile.StrkTokenAddress = strkAddress
+	}
+
+	return &profile, nil
+}
+
+// TxURL returns the explorer link for a transaction, or the bare hash if the
+// network has no explorer.
+func (p *Profile) TxURL(txHash *felt.Felt) string {
+	if p.ExplorerTxURL == "" {
+		return txHash.String()
+	}
+	return fmt.Sprintf(p.ExplorerTxURL, txHash.String())
+}
+
+// ContractURL returns the explorer link for a contract, or the bare address
+// if the network has no explorer.
+func (p *Profile) ContractURL(address *felt.Felt) string {
+	if p.ExplorerContractURL == "" {
+		return address.String()
+	}
+	return fmt.Sprintf(p.ExplorerContractURL, address.String())
+}
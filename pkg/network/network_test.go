@@ -0,0 +1,57 @@
+package network_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/NethermindEth/teeception/pkg/network"
+)
+
+func TestLoad(t *testing.T) {
+	profile, err := network.Load("Mainnet", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile != network.Mainnet {
+		t.Errorf("expected mainnet profile, got %s", profile.Name)
+	}
+
+	if _, err := network.Load("unknown", ""); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	if _, err := network.Load(network.NameCustom, ""); err == nil {
+		t.Error("expected error for custom network without profile file")
+	}
+
+	path := filepath.Join(t.TempDir(), "network.json")
+	err = os.WriteFile(path, []byte(`{
+		"chain_id": "SN_DEVNET",
+		"fee_token_address": "0x1",
+		"explorer_tx_url": "https://explorer.test/tx/%s"
+	}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+
+	profile, err = network.Load(network.NameCustom, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Name != network.NameCustom || profile.ChainID != "SN_DEVNET" {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+	if profile.StrkTokenAddress == nil {
+		t.Error("expected default strk token address")
+	}
+
+	txHash := new(felt.Felt).SetUint64(0xabc)
+	if url := profile.TxURL(txHash); url != "https://explorer.test/tx/0xabc" {
+		t.Errorf("unexpected tx url: %s", url)
+	}
+	if url := profile.ContractURL(txHash); url != "0xabc" {
+		t.Errorf("expected bare address without explorer, got %s", url)
+	}
+}
@@ -12,24 +12,46 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/teeception/pkg/indexer"
 	"github.com/NethermindEth/teeception/pkg/indexer/price"
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	"github.com/NethermindEth/teeception/pkg/network"
 	"github.com/NethermindEth/teeception/pkg/wallet/starknet"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/sync/errgroup"
 )
 
+const agentUsageMaxPrompts = 10
+
 type UIServiceConfig struct {
-	Client               starknet.ProviderWrapper
-	MaxPageSize          int
-	ServerAddr           string
-	RegistryAddress      *felt.Felt
-	StartingBlock        uint64
-	TokenRates           map[[32]byte]*big.Int
+	Client       starknet.ProviderWrapper
+	WebsocketURL string
+	// SubscriberBufferSize and SubscriberOverflowPolicy configure the event
+	// queue of each indexer.
+	SubscriberBufferSize     int
+	SubscriberOverflowPolicy indexer.OverflowPolicy
+	Network                  *network.Profile
+	MaxPageSize              int
+	ServerAddr               string
+	RegistryAddress          *felt.Felt
+	StartingBlock            uint64
+	// PriceFeed provides the token rates. A static feed of TokenRates, with
+	// price.RateDecimals decimals, is used if nil.
+	PriceFeed  price.PriceFeed
+	TokenRates map[[32]byte]*big.Int
+	// PriceMaxAge is the age after which a rate that could not be updated is
+	// dropped.
+	PriceMaxAge          time.Duration
 	PriceTickRate        time.Duration
 	EventTickRate        time.Duration
 	EventStartupTickRate time.Duration
 	UserTickRate         time.Duration
 	AgentBalanceTickRate time.Duration
+	// DBPath is the path of the store the indexers persist their state to.
+	// The state is kept in memory only if empty.
+	DBPath string
+	// AgentURL is the base URL of the agent API, which serves the receipts
+	// holding the replies to prompts. Replies are not indexed if empty.
+	AgentURL string
 }
 
 type UIService struct {
@@ -41,16 +63,102 @@ type UIService struct {
 	tokenIndexer        *indexer.TokenIndexer
 
 	registryAddress *felt.Felt
+	network         *network.Profile
 
 	client starknet.ProviderWrapper
+	store  *store.Store
 
 	maxPageSize int
 	serverAddr  string
 }
 
+type indexerDatabases struct {
+	agent        indexer.AgentIndexerDatabase
+	token        indexer.TokenIndexerDatabase
+	agentBalance indexer.AgentBalanceIndexerDatabase
+	agentUsage   indexer.AgentUsageIndexerDatabase
+	user         indexer.UserIndexerDatabase
+}
+
+func newInMemoryDatabases(lastIndexedBlock uint64) *indexerDatabases {
+	return &indexerDatabases{
+		agent:        indexer.NewAgentIndexerDatabaseInMemory(lastIndexedBlock),
+		token:        indexer.NewTokenIndexerDatabaseInMemory(lastIndexedBlock),
+		agentBalance: indexer.NewAgentBalanceIndexerDatabaseInMemory(lastIndexedBlock),
+		agentUsage:   indexer.NewAgentUsageIndexerDatabaseInMemory(lastIndexedBlock, agentUsageMaxPrompts),
+		user:         indexer.NewUserIndexerDatabaseInMemory(lastIndexedBlock),
+	}
+}
+
+func newPersistentDatabases(s *store.Store, lastIndexedBlock uint64) (*indexerDatabases, error) {
+	agentDb, err := indexer.NewAgentIndexerDatabasePersistent(s, lastIndexedBlock)
+	if err != nil {
+		return nil, err
+	}
+	tokenDb, err := indexer.NewTokenIndexerDatabasePersistent(s, lastIndexedBlock)
+	if err != nil {
+		return nil, err
+	}
+	agentBalanceDb, err := indexer.NewAgentBalanceIndexerDatabasePersistent(s, lastIndexedBlock)
+	if err != nil {
+		return nil, err
+	}
+	agentUsageDb, err := indexer.NewAgentUsageIndexerDatabasePersistent(s, lastIndexedBlock, agentUsageMaxPrompts)
+	if err != nil {
+		return nil, err
+	}
+	userDb, err := indexer.NewUserIndexerDatabasePersistent(s, lastIndexedBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexerDatabases{
+		agent:        agentDb,
+		token:        tokenDb,
+		agentBalance: agentBalanceDb,
+		agentUsage:   agentUsageDb,
+		user:         userDb,
+	}, nil
+}
+
+// lastIndexedBlock returns the block from which every database is up to date.
+func (d *indexerDatabases) lastIndexedBlock() uint64 {
+	return min(
+		d.agent.GetLastIndexedBlock(),
+		d.token.GetLastIndexedBlock(),
+		d.agentBalance.GetLastIndexedBlock(),
+		d.agentUsage.GetLastIndexedBlock(),
+		d.user.GetLastIndexedBlock(),
+	)
+}
+
 func NewUIService(config *UIServiceConfig) (*UIService, error) {
+	if config.Network == nil {
+		slog.Warn("no network profile configured, defaulting to " + network.NameSepolia)
+		config.Network = network.Sepolia
+	}
+
 	lastIndexedBlock := config.StartingBlock - 1
 
+	var dbStore *store.Store
+	dbs := newInMemoryDatabases(lastIndexedBlock)
+	if config.DBPath != "" {
+		var err error
+		dbStore, err = store.Open(config.DBPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open indexer store: %v", err)
+		}
+
+		dbs, err = newPersistentDatabases(dbStore, lastIndexedBlock)
+		if err != nil {
+			dbStore.Close()
+			return nil, fmt.Errorf("failed to load indexer databases: %v", err)
+		}
+
+		lastIndexedBlock = dbs.lastIndexedBlock()
+		slog.Info("resuming indexing", "last_indexed_block", lastIndexedBlock)
+	}
+
 	eventWatcher, err := indexer.NewEventWatcher(&indexer.EventWatcherConfig{
 		Client:          config.Client,
 		SafeBlockDelta:  0,
@@ -61,8 +169,14 @@ func NewUIService(config *UIServiceConfig) (*UIService, error) {
 		InitialState: &indexer.EventWatcherInitialState{
 			LastIndexedBlock: lastIndexedBlock,
 		},
+		WebsocketURL:             config.WebsocketURL,
+		SubscriberBufferSize:     config.SubscriberBufferSize,
+		SubscriberOverflowPolicy: config.SubscriberOverflowPolicy,
 	})
 	if err != nil {
+		if dbStore != nil {
+			dbStore.Close()
+		}
 		return nil, fmt.Errorf("failed to create event watcher: %v", err)
 	}
 
@@ -71,17 +185,22 @@ func NewUIService(config *UIServiceConfig) (*UIService, error) {
 		RegistryAddress: config.RegistryAddress,
 		EventWatcher:    eventWatcher,
 		InitialState: &indexer.AgentIndexerInitialState{
-			Db: indexer.NewAgentIndexerDatabaseInMemory(lastIndexedBlock),
+			Db: dbs.agent,
 		},
 	})
-	priceFeed := price.NewStaticPriceFeed(config.TokenRates)
+	priceFeed := config.PriceFeed
+	if priceFeed == nil {
+		priceFeed = price.NewStaticPriceFeed(config.TokenRates)
+	}
 	tokenIndexer := indexer.NewTokenIndexer(&indexer.TokenIndexerConfig{
+		Client:          config.Client,
 		PriceFeed:       priceFeed,
 		PriceTickRate:   config.PriceTickRate,
+		PriceMaxAge:     config.PriceMaxAge,
 		RegistryAddress: config.RegistryAddress,
 		EventWatcher:    eventWatcher,
 		InitialState: &indexer.TokenIndexerInitialState{
-			Db: indexer.NewTokenIndexerDatabaseInMemory(lastIndexedBlock),
+			Db: dbs.token,
 		},
 	})
 	agentBalanceIndexer := indexer.NewAgentBalanceIndexer(&indexer.AgentBalanceIndexerConfig{
@@ -92,16 +211,18 @@ func NewUIService(config *UIServiceConfig) (*UIService, error) {
 		EventWatcher:    eventWatcher,
 		TickRate:        config.AgentBalanceTickRate,
 		InitialState: &indexer.AgentBalanceIndexerInitialState{
-			Db: indexer.NewAgentBalanceIndexerDatabaseInMemory(lastIndexedBlock),
+			Db: dbs.agentBalance,
 		},
 	})
 	agentUsageIndexer := indexer.NewAgentUsageIndexer(&indexer.AgentUsageIndexerConfig{
 		Client:          config.Client,
 		RegistryAddress: config.RegistryAddress,
-		MaxPrompts:      10,
+		MaxPrompts:      agentUsageMaxPrompts,
 		EventWatcher:    eventWatcher,
+		PriceCache:      tokenIndexer,
+		ReceiptsURL:     config.AgentURL,
 		InitialState: &indexer.AgentUsageIndexerInitialState{
-			Db: indexer.NewAgentUsageIndexerDatabaseInMemory(lastIndexedBlock, 10),
+			Db: dbs.agentUsage,
 		},
 	})
 	userIndexer := indexer.NewUserIndexer(&indexer.UserIndexerConfig{
@@ -111,7 +232,7 @@ func NewUIService(config *UIServiceConfig) (*UIService, error) {
 		PriceCache:      tokenIndexer,
 		EventWatcher:    eventWatcher,
 		InitialState: &indexer.UserIndexerInitialState{
-			Db: indexer.NewUserIndexerDatabaseInMemory(lastIndexedBlock),
+			Db: dbs.user,
 		},
 	})
 
@@ -124,14 +245,24 @@ func NewUIService(config *UIServiceConfig) (*UIService, error) {
 		tokenIndexer:        tokenIndexer,
 
 		registryAddress: config.RegistryAddress,
+		network:         config.Network,
 
 		client:      config.Client,
+		store:       dbStore,
 		maxPageSize: config.MaxPageSize,
 		serverAddr:  config.ServerAddr,
 	}, nil
 }
 
 func (s *UIService) Run(ctx context.Context) error {
+	if s.store != nil {
+		defer func() {
+			if err := s.store.Close(); err != nil {
+				slog.Error("failed to close indexer store", "error", err)
+			}
+		}()
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		return s.eventWatcher.Run(ctx)
@@ -163,10 +294,17 @@ func (s *UIService) startServer(ctx context.Context) error {
 	router.Use(gzip.Gzip(gzip.DefaultCompression))
 	router.GET("/leaderboard", s.HandleGetLeaderboard)
 	router.GET("/agent/:address", s.HandleGetAgent)
+	router.GET("/agent/:address/prompts", s.HandleGetAgentPrompts)
 	router.GET("/user/leaderboard", s.HandleGetUserLeaderboard)
 	router.GET("/user/agents", s.HandleGetUserAgents)
+	router.GET("/user/:address", s.HandleGetUser)
+	router.GET("/user/:address/prompts", s.HandleGetUserPrompts)
+	router.GET("/hall-of-fame", s.HandleGetHallOfFame)
 	router.GET("/search", s.HandleSearchAgents)
 	router.GET("/usage", s.HandleGetUsage)
+	router.GET("/network", s.HandleGetNetwork)
+	router.GET("/tokens", s.HandleGetTokens)
+	router.GET("/subscribers", s.HandleGetSubscribers)
 
 	server := &http.Server{
 		Addr:    s.serverAddr,
@@ -188,23 +326,42 @@ func (s *UIService) startServer(ctx context.Context) error {
 }
 
 type AgentData struct {
-	Pending       bool               `json:"pending"`
-	Address       string             `json:"address"`
-	Creator       string             `json:"creator"`
-	Token         string             `json:"token"`
-	Name          string             `json:"name"`
-	SystemPrompt  string             `json:"system_prompt"`
-	PromptPrice   string             `json:"prompt_price"`
-	Balance       string             `json:"balance"`
-	EndTime       string             `json:"end_time"`
-	Model         string             `json:"model"`
-	IsDrained     bool               `json:"is_drained"`
-	DrainAmount   string             `json:"drain_amount"`
-	IsFinalized   bool               `json:"is_finalized"`
-	IsWithdrawn   bool               `json:"is_withdrawn"`
-	BreakAttempts string             `json:"break_attempts"`
-	LatestPrompts []*AgentDataPrompt `json:"latest_prompts"`
-	DrainPrompt   *AgentDataPrompt   `json:"drain_prompt"`
+	Pending       bool   `json:"pending"`
+	Address       string `json:"address"`
+	Creator       string `json:"creator"`
+	Token         string `json:"token"`
+	Name          string `json:"name"`
+	SystemPrompt  string `json:"system_prompt"`
+	PromptPrice   string `json:"prompt_price"`
+	Balance       string `json:"balance"`
+	EndTime       string `json:"end_time"`
+	Model         string `json:"model"`
+	IsDrained     bool   `json:"is_drained"`
+	DrainAmount   string `json:"drain_amount"`
+	IsFinalized   bool   `json:"is_finalized"`
+	IsWithdrawn   bool   `json:"is_withdrawn"`
+	BreakAttempts string `json:"break_attempts"`
+	// SurvivedAttempts, Uptime and FeesCollected are the metrics of the
+	// leaderboard sort modes.
+	SurvivedAttempts string `json:"survived_attempts"`
+	Uptime           string `json:"uptime"`
+	FeesCollected    string `json:"fees_collected"`
+	// TokenSymbol and the formatted amounts, in whole tokens, are set once
+	// the token metadata is known.
+	TokenSymbol          string             `json:"token_symbol,omitempty"`
+	PromptPriceFormatted string             `json:"prompt_price_formatted,omitempty"`
+	BalanceFormatted     string             `json:"balance_formatted,omitempty"`
+	DrainAmountFormatted string             `json:"drain_amount_formatted,omitempty"`
+	LatestPrompts        []*AgentDataPrompt `json:"latest_prompts"`
+	DrainPrompt          *AgentDataPrompt   `json:"drain_prompt"`
+	// WindowStats is set on windowed leaderboards.
+	WindowStats *WindowStatsData `json:"window_stats,omitempty"`
+}
+
+type WindowStatsData struct {
+	Amounts     map[string]string `json:"amounts"`
+	PromptCount int               `json:"prompt_count"`
+	BreakCount  int               `json:"break_count"`
 }
 
 type AgentDataPrompt struct {
@@ -216,6 +373,73 @@ type AgentDataPrompt struct {
 	DrainedTo string `json:"drained_to"`
 }
 
+type PromptHistoryEntryData struct {
+	PromptID      string `json:"prompt_id"`
+	TweetID       string `json:"tweet_id"`
+	Prompt        string `json:"prompt"`
+	User          string `json:"user"`
+	Outcome       string `json:"outcome"`
+	IsSuccess     bool   `json:"is_success"`
+	DrainedTo     string `json:"drained_to"`
+	Reply         string `json:"reply"`
+	Block         string `json:"block"`
+	Timestamp     string `json:"timestamp"`
+	TxHash        string `json:"tx_hash"`
+	ConsumeBlock  string `json:"consume_block"`
+	ConsumeTxHash string `json:"consume_tx_hash"`
+}
+
+type PromptHistoryResponse struct {
+	Prompts    []*PromptHistoryEntryData `json:"prompts"`
+	NextCursor string                    `json:"next_cursor"`
+	LastBlock  int                       `json:"last_block"`
+}
+
+type HallOfFameEntryData struct {
+	Agent        string `json:"agent"`
+	AgentName    string `json:"agent_name"`
+	SystemPrompt string `json:"system_prompt"`
+	PromptID     string `json:"prompt_id"`
+	TweetID      string `json:"tweet_id"`
+	Prompt       string `json:"prompt"`
+	User         string `json:"user"`
+	Token        string `json:"token"`
+	Bounty       string `json:"bounty"`
+	// BountyUSD is the USD value of the bounty when it was drained, as a
+	// decimal number. It is empty if the rate was unknown.
+	BountyUSD   string `json:"bounty_usd"`
+	Attempts    string `json:"attempts"`
+	TimeToBreak string `json:"time_to_break"`
+	DrainedAt   string `json:"drained_at"`
+	Block       string `json:"block"`
+	TxHash      string `json:"tx_hash"`
+}
+
+type HallOfFameResponse struct {
+	Entries   []*HallOfFameEntryData `json:"entries"`
+	Total     int                    `json:"total"`
+	Page      int                    `json:"page"`
+	PageSize  int                    `json:"page_size"`
+	LastBlock int                    `json:"last_block"`
+}
+
+type SearchResultData struct {
+	Agent     string  `json:"agent"`
+	AgentName string  `json:"agent_name"`
+	Field     string  `json:"field"`
+	PromptID  string  `json:"prompt_id,omitempty"`
+	Score     float64 `json:"score"`
+	Snippet   string  `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Results   []*SearchResultData `json:"results"`
+	Total     int                 `json:"total"`
+	Page      int                 `json:"page"`
+	PageSize  int                 `json:"page_size"`
+	LastBlock int                 `json:"last_block"`
+}
+
 type AgentPageResponse struct {
 	Agents    []*AgentData `json:"agents"`
 	Total     int          `json:"total"`
@@ -231,6 +455,26 @@ type UserData struct {
 	BreakCount      int               `json:"break_count"`
 }
 
+type UserPromptData struct {
+	Agent       string `json:"agent"`
+	PromptID    string `json:"prompt_id"`
+	TweetID     string `json:"tweet_id"`
+	Prompt      string `json:"prompt"`
+	Outcome     string `json:"outcome"`
+	Amount      string `json:"amount"`
+	Token       string `json:"token"`
+	DrainAmount string `json:"drain_amount"`
+	Block       string `json:"block"`
+	Timestamp   string `json:"timestamp"`
+	TxHash      string `json:"tx_hash"`
+}
+
+type UserPromptsResponse struct {
+	Prompts    []*UserPromptData `json:"prompts"`
+	NextCursor string            `json:"next_cursor"`
+	LastBlock  int               `json:"last_block"`
+}
+
 type UserPageResponse struct {
 	Users     []*UserData `json:"users"`
 	Total     int         `json:"total"`
@@ -249,6 +493,9 @@ func (s *UIService) getPageSize(requestedSize int) int {
 	return requestedSize
 }
 
+// HandleGetLeaderboard returns a page of the agent leaderboard, ordered by the
+// "sort" query parameter: "prize_pool" (default), "uptime", "attempts" or
+// "fees". Windowed leaderboards are only ordered by prize pool.
 func (s *UIService) HandleGetLeaderboard(c *gin.Context) {
 	page, err := strconv.Atoi(c.Query("page"))
 	if err != nil {
@@ -273,7 +520,31 @@ func (s *UIService) HandleGetLeaderboard(c *gin.Context) {
 		isActive = &active
 	}
 
-	agents, err := s.agentBalanceIndexer.GetAgentLeaderboard(uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize), isActive)
+	window, err := parseLeaderboardWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortMode := indexer.AgentSortPrizePool
+	if sortQueryParam := c.Query("sort"); sortQueryParam != "" {
+		sortMode = indexer.AgentSortMode(sortQueryParam)
+		if !sortMode.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"sort\" query parameter"})
+			return
+		}
+		if sortMode != indexer.AgentSortPrizePool && !window.IsAllTime() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "\"sort\" is only supported on the all time leaderboard"})
+			return
+		}
+	}
+
+	var agents *indexer.AgentLeaderboardResponse
+	if window.IsAllTime() {
+		agents, err = s.agentBalanceIndexer.GetAgentLeaderboard(sortMode, uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize), isActive)
+	} else {
+		agents, err = s.agentBalanceIndexer.GetWindowedAgentLeaderboard(window, uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize), isActive)
+	}
 	if err != nil {
 		slog.Error("error fetching agent leaderboard", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching agent leaderboard"})
@@ -282,7 +553,7 @@ func (s *UIService) HandleGetLeaderboard(c *gin.Context) {
 
 	agentDatas := make([]*AgentData, 0, len(agents.Agents))
 	agentAddr := new(felt.Felt)
-	for _, agentBytes := range agents.Agents {
+	for idx, agentBytes := range agents.Agents {
 		agentAddr.SetBytes(agentBytes[:])
 
 		info, ok := s.agentIndexer.GetAgentInfo(agentAddr)
@@ -297,6 +568,10 @@ func (s *UIService) HandleGetLeaderboard(c *gin.Context) {
 			continue
 		}
 
+		if agents.Stats != nil {
+			agentData.WindowStats = buildWindowStatsData(agents.Stats[idx])
+		}
+
 		agentDatas = append(agentDatas, agentData)
 	}
 
@@ -332,6 +607,97 @@ func (s *UIService) HandleGetAgent(c *gin.Context) {
 	c.JSON(http.StatusOK, agentData)
 }
 
+// HandleGetAgentPrompts returns an agent's prompt history, newest first. Pages
+// are selected with the "cursor" of the previous page and "limit", and
+// filtered by "outcome" or "success", "user", "from_block", "to_block",
+// "from_time" and "to_time" (unix seconds).
+func (s *UIService) HandleGetAgentPrompts(c *gin.Context) {
+	agentAddr, err := new(felt.Felt).SetString(c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Errorf("invalid agent address: %w", err).Error()})
+		return
+	}
+
+	filter := &indexer.PromptHistoryFilter{
+		Limit:   s.getPageSize(0),
+		Outcome: indexer.PromptOutcome(c.Query("outcome")),
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			filter.Limit = s.getPageSize(limit)
+		}
+	}
+
+	switch filter.Outcome {
+	case "", indexer.PromptOutcomePending, indexer.PromptOutcomeFailed, indexer.PromptOutcomeDrained, indexer.PromptOutcomeReclaimed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"outcome\" query parameter"})
+		return
+	}
+
+	if successStr := c.Query("success"); successStr != "" {
+		success, err := strconv.ParseBool(successStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"success\" query parameter"})
+			return
+		}
+
+		filter.Outcome = indexer.PromptOutcomeFailed
+		if success {
+			filter.Outcome = indexer.PromptOutcomeDrained
+		}
+	}
+
+	if userStr := c.Query("user"); userStr != "" {
+		filter.User, err = new(felt.Felt).SetString(userStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Errorf("invalid user address: %w", err).Error()})
+			return
+		}
+	}
+
+	for name, value := range map[string]*uint64{
+		"cursor":     &filter.Cursor,
+		"from_block": &filter.FromBlock,
+		"to_block":   &filter.ToBlock,
+		"from_time":  &filter.FromTime,
+		"to_time":    &filter.ToTime,
+	} {
+		str := c.Query(name)
+		if str == "" {
+			continue
+		}
+
+		*value, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %q query parameter", name)})
+			return
+		}
+	}
+
+	page, ok := s.agentUsageIndexer.GetPromptHistory(agentAddr, filter)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found in agent usage indexer"})
+		return
+	}
+
+	prompts := make([]*PromptHistoryEntryData, 0, len(page.Prompts))
+	for _, entry := range page.Prompts {
+		prompts = append(prompts, s.buildPromptHistoryEntryData(entry))
+	}
+
+	nextCursor := ""
+	if page.NextCursor != 0 {
+		nextCursor = strconv.FormatUint(page.NextCursor, 10)
+	}
+
+	c.JSON(http.StatusOK, &PromptHistoryResponse{
+		Prompts:    prompts,
+		NextCursor: nextCursor,
+		LastBlock:  int(page.LastBlock),
+	})
+}
+
 func (s *UIService) HandleGetUserAgents(c *gin.Context) {
 	userAddrStr := c.Query("user")
 	if userAddrStr == "" {
@@ -385,10 +751,55 @@ func (s *UIService) HandleGetUserAgents(c *gin.Context) {
 	})
 }
 
+func (s *UIService) HandleGetHallOfFame(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 0 {
+		page = 0
+	}
+
+	pageSize := s.getPageSize(0)
+	if sizeStr := c.Query("page_size"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil {
+			pageSize = s.getPageSize(size)
+		}
+	}
+
+	order := indexer.HallOfFameSort(c.DefaultQuery("sort", string(indexer.HallOfFameSortRecency)))
+	switch order {
+	case indexer.HallOfFameSortRecency, indexer.HallOfFameSortBounty, indexer.HallOfFameSortAttempts:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"sort\" query parameter"})
+		return
+	}
+
+	hallOfFame := s.agentUsageIndexer.GetHallOfFame(order, uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize))
+
+	entries := make([]*HallOfFameEntryData, 0, len(hallOfFame.Entries))
+	for _, entry := range hallOfFame.Entries {
+		entries = append(entries, s.buildHallOfFameEntryData(entry))
+	}
+
+	c.JSON(http.StatusOK, &HallOfFameResponse{
+		Entries:   entries,
+		Total:     int(hallOfFame.Total),
+		Page:      page,
+		PageSize:  pageSize,
+		LastBlock: int(hallOfFame.LastBlock),
+	})
+}
+
+// HandleSearchAgents searches agents by "name" prefix or, given a "q" query,
+// runs a ranked full-text search over agent names, system prompts and paid
+// prompts, optionally restricted by "scope" to "agents" or "prompts".
 func (s *UIService) HandleSearchAgents(c *gin.Context) {
+	if query := c.Query("q"); query != "" {
+		s.handleFullTextSearch(c, query)
+		return
+	}
+
 	name := c.Query("name")
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "name required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name or q required"})
 		return
 	}
 
@@ -432,6 +843,58 @@ func (s *UIService) HandleSearchAgents(c *gin.Context) {
 	})
 }
 
+func (s *UIService) handleFullTextSearch(c *gin.Context, query string) {
+	scope := indexer.SearchScope(c.Query("scope"))
+	if !scope.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"scope\" query parameter"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize := s.getPageSize(0)
+	if sizeStr := c.Query("page_size"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil {
+			pageSize = s.getPageSize(size)
+		}
+	}
+
+	searchPage := s.agentUsageIndexer.Search(query, scope, uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize))
+
+	results := make([]*SearchResultData, 0, len(searchPage.Results))
+	for _, result := range searchPage.Results {
+		data := &SearchResultData{
+			Agent:     result.Agent.String(),
+			AgentName: result.AgentName,
+			Field:     string(result.Field),
+			Score:     result.Score,
+			Snippet:   result.Snippet,
+		}
+		if data.AgentName == "" {
+			// The agent was registered before its registration was indexed
+			if info, ok := s.agentIndexer.GetAgentInfo(result.Agent); ok {
+				data.AgentName = info.Name
+			}
+		}
+		if result.Field == indexer.SearchFieldPrompt {
+			data.PromptID = strconv.FormatUint(result.PromptID, 10)
+		}
+
+		results = append(results, data)
+	}
+
+	c.JSON(http.StatusOK, &SearchResponse{
+		Results:   results,
+		Total:     int(searchPage.Total),
+		Page:      page,
+		PageSize:  pageSize,
+		LastBlock: int(searchPage.LastBlock),
+	})
+}
+
 type GetUsageResponse struct {
 	RegisteredAgents uint64                     `json:"registered_agents"`
 	Attempts         *GetUsageResponseAttempts  `json:"attempts"`
@@ -464,6 +927,97 @@ func (s *UIService) HandleGetUsage(c *gin.Context) {
 	})
 }
 
+type GetNetworkResponse struct {
+	Name                string `json:"name"`
+	ChainID             string `json:"chain_id"`
+	RegistryAddress     string `json:"registry_address"`
+	FeeTokenAddress     string `json:"fee_token_address"`
+	StrkTokenAddress    string `json:"strk_token_address"`
+	ExplorerTxURL       string `json:"explorer_tx_url"`
+	ExplorerContractURL string `json:"explorer_contract_url"`
+}
+
+func (s *UIService) HandleGetNetwork(c *gin.Context) {
+	c.JSON(http.StatusOK, &GetNetworkResponse{
+		Name:                s.network.Name,
+		ChainID:             s.network.ChainID,
+		RegistryAddress:     s.registryAddress.String(),
+		FeeTokenAddress:     s.network.FeeTokenAddress.String(),
+		StrkTokenAddress:    s.network.StrkTokenAddress.String(),
+		ExplorerTxURL:       s.network.ExplorerTxURL,
+		ExplorerContractURL: s.network.ExplorerContractURL,
+	})
+}
+
+type TokenData struct {
+	Address string `json:"address"`
+	// Name, Symbol and Decimals are unset until the token metadata is
+	// fetched.
+	Name                       string `json:"name"`
+	Symbol                     string `json:"symbol"`
+	Decimals                   *uint8 `json:"decimals"`
+	MinPromptPrice             string `json:"min_prompt_price"`
+	MinPromptPriceFormatted    string `json:"min_prompt_price_formatted,omitempty"`
+	MinInitialBalance          string `json:"min_initial_balance"`
+	MinInitialBalanceFormatted string `json:"min_initial_balance_formatted,omitempty"`
+	// Rate is the USD price of a whole token, with RateDecimals decimals. It
+	// is empty if unknown or stale. Only STRK is priced, so other tokens have
+	// no rate and are left out of USD values and rankings.
+	Rate          string `json:"rate"`
+	RateDecimals  int    `json:"rate_decimals"`
+	RateUpdatedAt string `json:"rate_updated_at"`
+}
+
+type TokensResponse struct {
+	Tokens    []*TokenData `json:"tokens"`
+	LastBlock int          `json:"last_block"`
+}
+
+// HandleGetTokens lists the tokens supported by the registry along with their
+// metadata, minimums and current rates.
+func (s *UIService) HandleGetTokens(c *gin.Context) {
+	tokens := s.tokenIndexer.GetSupportedTokens()
+
+	tokenDatas := make([]*TokenData, 0, len(tokens))
+	for _, token := range tokens {
+		data := &TokenData{
+			Address:           token.Address.String(),
+			MinPromptPrice:    bigIntString(token.MinPromptPrice),
+			MinInitialBalance: bigIntString(token.MinInitialBalance),
+			RateDecimals:      price.RateDecimals,
+		}
+		if token.Metadata != nil {
+			data.Name = token.Metadata.Name
+			data.Symbol = token.Metadata.Symbol
+			data.Decimals = &token.Metadata.Decimals
+			data.MinPromptPriceFormatted = token.Metadata.FormatAmount(token.MinPromptPrice)
+			data.MinInitialBalanceFormatted = token.Metadata.FormatAmount(token.MinInitialBalance)
+		}
+		if token.Rate != nil {
+			data.Rate = token.Rate.String()
+			data.RateUpdatedAt = strconv.FormatInt(token.RateTime.Unix(), 10)
+		}
+
+		tokenDatas = append(tokenDatas, data)
+	}
+
+	c.JSON(http.StatusOK, &TokensResponse{
+		Tokens:    tokenDatas,
+		LastBlock: int(s.tokenIndexer.GetLastIndexedBlock()),
+	})
+}
+
+func bigIntString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
+
+func (s *UIService) HandleGetSubscribers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.eventWatcher.SubscriberStats())
+}
+
 func (s *UIService) HandleGetUserLeaderboard(c *gin.Context) {
 	page, err := strconv.Atoi(c.Query("page"))
 	if err != nil {
@@ -477,7 +1031,18 @@ func (s *UIService) HandleGetUserLeaderboard(c *gin.Context) {
 		}
 	}
 
-	leaderboard, err := s.userIndexer.GetUserLeaderboard(uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize))
+	window, err := parseLeaderboardWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var leaderboard *indexer.UserLeaderboardResponse
+	if window.IsAllTime() {
+		leaderboard, err = s.userIndexer.GetUserLeaderboard(uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize))
+	} else {
+		leaderboard, err = s.userIndexer.GetWindowedUserLeaderboard(window, uint64(page)*uint64(pageSize), uint64(page+1)*uint64(pageSize))
+	}
 	if err != nil {
 		slog.Error("error fetching user leaderboard", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user leaderboard"})
@@ -485,7 +1050,19 @@ func (s *UIService) HandleGetUserLeaderboard(c *gin.Context) {
 	}
 
 	users := make([]*UserData, 0, len(leaderboard.Users))
-	for _, userAddr := range leaderboard.Users {
+	for idx, userAddr := range leaderboard.Users {
+		// Windowed leaderboards report the stats within the window
+		if leaderboard.Stats != nil {
+			stats := buildWindowStatsData(leaderboard.Stats[idx])
+			users = append(users, &UserData{
+				Address:         new(felt.Felt).SetBytes(userAddr[:]).String(),
+				AccruedBalances: stats.Amounts,
+				PromptCount:     stats.PromptCount,
+				BreakCount:      stats.BreakCount,
+			})
+			continue
+		}
+
 		info, ok := s.userIndexer.GetUserInfo(new(felt.Felt).SetBytes(userAddr[:]))
 		if !ok {
 			slog.Error("user info not found", "address", userAddr)
@@ -514,6 +1091,67 @@ func (s *UIService) HandleGetUserLeaderboard(c *gin.Context) {
 	})
 }
 
+func (s *UIService) HandleGetUser(c *gin.Context) {
+	userAddr, err := new(felt.Felt).SetString(c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Errorf("invalid user address: %w", err).Error()})
+		return
+	}
+
+	info, ok := s.userIndexer.GetUserInfo(userAddr)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found in user indexer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.buildUserData(info))
+}
+
+// HandleGetUserPrompts returns the prompts paid by a user across all agents,
+// newest first. Pages are selected with the "cursor" of the previous page and
+// "limit", as for an agent's prompt history.
+func (s *UIService) HandleGetUserPrompts(c *gin.Context) {
+	userAddr, err := new(felt.Felt).SetString(c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Errorf("invalid user address: %w", err).Error()})
+		return
+	}
+
+	limit := s.getPageSize(0)
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if size, err := strconv.Atoi(limitStr); err == nil {
+			limit = s.getPageSize(size)
+		}
+	}
+
+	var cursor uint64
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err = strconv.ParseUint(cursorStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid \"cursor\" query parameter"})
+			return
+		}
+	}
+
+	page := s.userIndexer.GetUserPrompts(userAddr, cursor, limit)
+
+	prompts := make([]*UserPromptData, 0, len(page.Prompts))
+	for _, entry := range page.Prompts {
+		prompts = append(prompts, s.buildUserPromptData(entry))
+	}
+
+	nextCursor := ""
+	if page.NextCursor != 0 {
+		nextCursor = strconv.FormatUint(page.NextCursor, 10)
+	}
+
+	c.JSON(http.StatusOK, &UserPromptsResponse{
+		Prompts:    prompts,
+		NextCursor: nextCursor,
+		LastBlock:  int(page.LastBlock),
+	})
+}
+
 func (s *UIService) buildAgentData(info *indexer.AgentInfo) (*AgentData, error) {
 	balance, ok := s.agentBalanceIndexer.GetBalance(info.Address)
 	if !ok {
@@ -539,25 +1177,44 @@ func (s *UIService) buildAgentData(info *indexer.AgentInfo) (*AgentData, error)
 		drainPrompt = s.buildAgentDataPrompt(usage.DrainPrompt)
 	}
 
-	return &AgentData{
-		Pending:       balance.Pending,
-		Address:       info.Address.String(),
-		Creator:       info.Creator.String(),
-		Name:          info.Name,
-		SystemPrompt:  info.SystemPrompt,
-		Token:         balance.Token.String(),
-		Balance:       new(big.Int).Sub(balance.Amount, balance.PendingAmount).String(),
-		EndTime:       strconv.FormatUint(balance.EndTime, 10),
-		Model:         info.Model.String(),
-		IsDrained:     usage.IsDrained,
-		DrainAmount:   balance.DrainAmount.String(),
-		DrainPrompt:   drainPrompt,
-		IsFinalized:   time.Now().After(time.Unix(int64(balance.EndTime), 0)) || usage.IsDrained,
-		IsWithdrawn:   usage.IsWithdrawn,
-		PromptPrice:   info.PromptPrice.String(),
-		BreakAttempts: strconv.FormatUint(usage.BreakAttempts, 10),
-		LatestPrompts: latestPrompts,
-	}, nil
+	feesCollected := balance.FeesCollected
+	if feesCollected == nil {
+		feesCollected = big.NewInt(0)
+	}
+
+	agentBalance := new(big.Int).Sub(balance.Amount, balance.PendingAmount)
+
+	agentData := &AgentData{
+		Pending:          balance.Pending,
+		Address:          info.Address.String(),
+		Creator:          info.Creator.String(),
+		Name:             info.Name,
+		SystemPrompt:     info.SystemPrompt,
+		Token:            balance.Token.String(),
+		Balance:          agentBalance.String(),
+		EndTime:          strconv.FormatUint(balance.EndTime, 10),
+		Model:            info.Model.String(),
+		IsDrained:        usage.IsDrained,
+		DrainAmount:      balance.DrainAmount.String(),
+		DrainPrompt:      drainPrompt,
+		IsFinalized:      time.Now().After(time.Unix(int64(balance.EndTime), 0)) || usage.IsDrained,
+		IsWithdrawn:      usage.IsWithdrawn,
+		PromptPrice:      info.PromptPrice.String(),
+		BreakAttempts:    strconv.FormatUint(usage.BreakAttempts, 10),
+		SurvivedAttempts: strconv.FormatUint(balance.BreakAttempts, 10),
+		Uptime:           strconv.FormatUint(balance.Uptime(uint64(time.Now().Unix())), 10),
+		FeesCollected:    feesCollected.String(),
+		LatestPrompts:    latestPrompts,
+	}
+
+	if metadata, ok := s.tokenIndexer.GetTokenMetadata(balance.Token); ok {
+		agentData.TokenSymbol = metadata.Symbol
+		agentData.PromptPriceFormatted = metadata.FormatAmount(info.PromptPrice)
+		agentData.BalanceFormatted = metadata.FormatAmount(agentBalance)
+		agentData.DrainAmountFormatted = metadata.FormatAmount(balance.DrainAmount)
+	}
+
+	return agentData, nil
 }
 
 func (s *UIService) buildAgentDataPrompt(prompt *indexer.AgentUsagePrompt) *AgentDataPrompt {
@@ -571,6 +1228,148 @@ func (s *UIService) buildAgentDataPrompt(prompt *indexer.AgentUsagePrompt) *Agen
 	}
 }
 
+func (s *UIService) buildPromptHistoryEntryData(entry *indexer.PromptHistoryEntry) *PromptHistoryEntryData {
+	data := &PromptHistoryEntryData{
+		PromptID:  strconv.FormatUint(entry.PromptID, 10),
+		TweetID:   strconv.FormatUint(entry.TweetID, 10),
+		Prompt:    entry.Prompt,
+		Outcome:   string(entry.Outcome),
+		IsSuccess: entry.Outcome == indexer.PromptOutcomeDrained,
+		Reply:     entry.Reply,
+		Block:     strconv.FormatUint(entry.Block, 10),
+		Timestamp: strconv.FormatUint(entry.Timestamp, 10),
+	}
+
+	if entry.User != nil {
+		data.User = entry.User.String()
+	}
+	if entry.DrainedTo != nil {
+		data.DrainedTo = entry.DrainedTo.String()
+	}
+	if entry.TxHash != nil {
+		data.TxHash = entry.TxHash.String()
+	}
+	if entry.ConsumeTxHash != nil {
+		data.ConsumeBlock = strconv.FormatUint(entry.ConsumeBlock, 10)
+		data.ConsumeTxHash = entry.ConsumeTxHash.String()
+	}
+
+	return data
+}
+
+func (s *UIService) buildHallOfFameEntryData(entry *indexer.HallOfFameEntry) *HallOfFameEntryData {
+	data := &HallOfFameEntryData{
+		AgentName:    entry.AgentName,
+		SystemPrompt: entry.SystemPrompt,
+		PromptID:     strconv.FormatUint(entry.PromptID, 10),
+		TweetID:      strconv.FormatUint(entry.TweetID, 10),
+		Prompt:       entry.Prompt,
+		Attempts:     strconv.FormatUint(entry.Attempts, 10),
+		TimeToBreak:  strconv.FormatUint(entry.TimeToBreak(), 10),
+		DrainedAt:    strconv.FormatUint(entry.DrainedAt, 10),
+		Block:        strconv.FormatUint(entry.Block, 10),
+	}
+
+	if entry.Agent != nil {
+		data.Agent = entry.Agent.String()
+	}
+	if entry.User != nil {
+		data.User = entry.User.String()
+	}
+	if entry.Amount != nil {
+		data.Bounty = entry.Amount.String()
+	}
+	if entry.AmountUSD != nil {
+		data.BountyUSD = indexer.FormatTokenAmount(entry.AmountUSD, price.RateDecimals)
+	}
+	if entry.Token != nil {
+		data.Token = entry.Token.String()
+	}
+	if entry.TxHash != nil {
+		data.TxHash = entry.TxHash.String()
+	}
+
+	return data
+}
+
+func buildWindowStatsData(stats *indexer.WindowedStats) *WindowStatsData {
+	amounts := make(map[string]string, len(stats.Amounts))
+	for token, amount := range stats.Amounts {
+		amounts[new(felt.Felt).SetBytes(token[:]).String()] = amount.String()
+	}
+
+	return &WindowStatsData{
+		Amounts:     amounts,
+		PromptCount: int(stats.PromptCount),
+		BreakCount:  int(stats.BreakCount),
+	}
+}
+
+// parseLeaderboardWindow parses the "window" query parameter of leaderboards:
+// "all" (default), "week" for the current week, "24h", "7d", or "custom" with
+// "from" and optionally "to" as unix timestamps.
+func parseLeaderboardWindow(c *gin.Context) (indexer.LeaderboardWindow, error) {
+	now := time.Now()
+
+	switch c.Query("window") {
+	case "", "all":
+		return indexer.LeaderboardWindow{}, nil
+	case "week":
+		return indexer.CurrentWeekWindow(now), nil
+	case "24h":
+		return indexer.RollingWindow(now, 24*time.Hour), nil
+	case "7d":
+		return indexer.RollingWindow(now, 7*24*time.Hour), nil
+	case "custom":
+	default:
+		return indexer.LeaderboardWindow{}, fmt.Errorf("invalid \"window\" query parameter")
+	}
+
+	from, err := strconv.ParseUint(c.Query("from"), 10, 64)
+	if err != nil || from == 0 {
+		return indexer.LeaderboardWindow{}, fmt.Errorf("invalid \"from\" query parameter")
+	}
+
+	var to uint64
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = strconv.ParseUint(toStr, 10, 64)
+		if err != nil || to <= from {
+			return indexer.LeaderboardWindow{}, fmt.Errorf("invalid \"to\" query parameter")
+		}
+	}
+
+	return indexer.LeaderboardWindow{From: from, To: to}, nil
+}
+
+func (s *UIService) buildUserPromptData(entry *indexer.UserPromptEntry) *UserPromptData {
+	data := &UserPromptData{
+		PromptID:  strconv.FormatUint(entry.PromptID, 10),
+		TweetID:   strconv.FormatUint(entry.TweetID, 10),
+		Prompt:    entry.Prompt,
+		Outcome:   string(entry.Outcome),
+		Block:     strconv.FormatUint(entry.Block, 10),
+		Timestamp: strconv.FormatUint(entry.Timestamp, 10),
+	}
+
+	if entry.Agent != nil {
+		data.Agent = entry.Agent.String()
+	}
+	if entry.Amount != nil {
+		data.Amount = entry.Amount.String()
+	}
+	if entry.Token != nil {
+		data.Token = entry.Token.String()
+	}
+	if entry.DrainAmount != nil {
+		data.DrainAmount = entry.DrainAmount.String()
+	}
+	if entry.TxHash != nil {
+		data.TxHash = entry.TxHash.String()
+	}
+
+	return data
+}
+
 func (s *UIService) buildUserData(info *indexer.UserInfo) *UserData {
 	flt := new(felt.Felt)
 	accruedBalances := make(map[string]string)
@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/gin-gonic/gin"
+
+	"github.com/NethermindEth/teeception/pkg/indexer"
+	"github.com/NethermindEth/teeception/pkg/indexer/store"
+	service "github.com/NethermindEth/teeception/pkg/ui_service"
+)
+
+var (
+	testAgent = new(felt.Felt).SetUint64(1)
+	testToken = new(felt.Felt).SetUint64(2)
+	testUser  = new(felt.Felt).SetUint64(3)
+)
+
+// newTestService creates a service resuming from a store in which testUser
+// paid prompts 1 to 3 to testAgent, and drained it with prompt 2.
+func newTestService(t *testing.T) *service.UIService {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "indexer.db")
+
+	s, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	db, err := indexer.NewUserIndexerDatabasePersistent(s, 0)
+	if err != nil {
+		t.Fatalf("failed to open user database: %v", err)
+	}
+
+	db.SetCurrentBlock(1)
+	db.StoreAgentRegisteredData(&indexer.AgentRegisteredEvent{
+		Agent:        testAgent,
+		TokenAddress: testToken,
+		PromptPrice:  big.NewInt(10),
+	})
+	for promptID := uint64(1); promptID <= 3; promptID++ {
+		db.SetCurrentBlock(promptID + 1)
+		db.StorePromptPaidData(testAgent, &indexer.PromptPaidEvent{
+			User:     testUser,
+			PromptID: promptID,
+			Prompt:   "prompt",
+		}, indexer.PromptEventInfo{Block: promptID + 1})
+	}
+	db.SetCurrentBlock(5)
+	db.StoreDrainedData(testAgent, &indexer.DrainedEvent{
+		PromptID: 2,
+		User:     testUser,
+		Amount:   big.NewInt(1000),
+	}, indexer.PromptEventInfo{Block: 5})
+	db.SetLastIndexedBlock(5)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	svc, err := service.NewUIService(&service.UIServiceConfig{
+		StartingBlock: 1,
+		MaxPageSize:   2,
+		DBPath:        path,
+	})
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	return svc
+}
+
+func serve(handler gin.HandlerFunc, address, query string, response any) int {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	c.Params = gin.Params{{Key: "address", Value: address}}
+	handler(c)
+
+	if w.Code == http.StatusOK && response != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+			return -1
+		}
+	}
+	return w.Code
+}
+
+func TestHandleGetUser(t *testing.T) {
+	svc := newTestService(t)
+
+	var user service.UserData
+	if code := serve(svc.HandleGetUser, testUser.String(), "", &user); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if user.Address != testUser.String() || user.PromptCount != 3 || user.BreakCount != 1 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if balance := user.AccruedBalances[testToken.String()]; balance != "1000" {
+		t.Errorf("expected accrued balance 1000, got %q", balance)
+	}
+
+	if code := serve(svc.HandleGetUser, testAgent.String(), "", nil); code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown user, got %d", code)
+	}
+	if code := serve(svc.HandleGetUser, "not an address", "", nil); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid address, got %d", code)
+	}
+}
+
+func TestHandleGetUserPrompts(t *testing.T) {
+	svc := newTestService(t)
+
+	var page service.UserPromptsResponse
+	if code := serve(svc.HandleGetUserPrompts, testUser.String(), "", &page); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if len(page.Prompts) != 2 || page.Prompts[0].PromptID != "3" || page.Prompts[1].PromptID != "2" {
+		t.Fatalf("expected prompts 3 and 2, got %+v", page.Prompts)
+	}
+	if page.Prompts[1].Outcome != string(indexer.PromptOutcomeDrained) || page.Prompts[1].DrainAmount != "1000" {
+		t.Errorf("expected prompt 2 to have drained 1000, got %+v", page.Prompts[1])
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next page")
+	}
+
+	var next service.UserPromptsResponse
+	if code := serve(svc.HandleGetUserPrompts, testUser.String(), "cursor="+page.NextCursor, &next); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if len(next.Prompts) != 1 || next.Prompts[0].PromptID != "1" || next.NextCursor != "" {
+		t.Errorf("expected only prompt 1 on the last page, got %+v", next)
+	}
+
+	if code := serve(svc.HandleGetUserPrompts, testUser.String(), "cursor=x", nil); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid cursor, got %d", code)
+	}
+}
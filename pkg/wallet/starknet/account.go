@@ -9,6 +9,7 @@ import (
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/account"
+	"github.com/NethermindEth/starknet.go/contracts"
 	"github.com/NethermindEth/starknet.go/curve"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/NethermindEth/starknet.go/utils"
@@ -86,6 +87,23 @@ func (a *StarknetAccount) PublicKey() *felt.Felt {
 	return a.options.PublicKey
 }
 
+// Sign signs a message hash with the account's private key and returns the
+// signature as [r, s].
+func (a *StarknetAccount) Sign(ctx context.Context, msgHash *felt.Felt) ([]*felt.Felt, error) {
+	r, s, err := a.options.Keystore.Sign(ctx, a.options.PublicKey.String(), msgHash.BigInt(new(big.Int)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message hash: %w", err)
+	}
+
+	return []*felt.Felt{utils.BigIntToFelt(r), utils.BigIntToFelt(s)}, nil
+}
+
+// AccountAddressFromPublicKey computes the address of the account deployed
+// for the given public key.
+func AccountAddressFromPublicKey(publicKey *felt.Felt) *felt.Felt {
+	return contracts.PrecomputeAddress(&felt.Zero, publicKey, classHashFelt, []*felt.Felt{publicKey})
+}
+
 func (a *StarknetAccount) Account() (*account.Account, error) {
 	if !a.deployed {
 		return nil, fmt.Errorf("account not deployed")
@@ -13,6 +13,8 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/account"
 	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/NethermindEth/teeception/pkg/metrics"
 )
 
 // TxQueueConfig holds basic configuration for batching transactions.
@@ -23,6 +25,11 @@ type TxQueueConfig struct {
 	// Time interval after which a batch submission is triggered when
 	// at least one function call is queued.
 	SubmissionInterval time.Duration
+
+	// Interval at which the receipt of a broadcast transaction is polled to
+	// record the fee it paid, and how long to poll before giving up.
+	ReceiptPollInterval time.Duration
+	ReceiptTimeout      time.Duration
 }
 
 // TxQueueItem represents a single Starknet function call along with
@@ -64,6 +71,12 @@ func NewTxQueue(account *StarknetAccount, client ProviderWrapper, cfg *TxQueueCo
 	if cfg.SubmissionInterval <= 0 {
 		cfg.SubmissionInterval = 20 * time.Second
 	}
+	if cfg.ReceiptPollInterval <= 0 {
+		cfg.ReceiptPollInterval = 5 * time.Second
+	}
+	if cfg.ReceiptTimeout <= 0 {
+		cfg.ReceiptTimeout = 10 * time.Minute
+	}
 
 	return &TxQueue{
 		cfg:      *cfg,
@@ -181,6 +194,7 @@ func (q *TxQueue) submitBatch(ctx context.Context, items []*TxQueueItem) {
 	defer q.nonceMu.Unlock()
 
 	slog.Info("preparing to submit batch", "calls_in_batch", len(items))
+	metrics.TxQueueBatchSize.Observe(float64(len(items)))
 
 	// Flatten all function calls into a single array.
 	var allCalls []rpc.FunctionCall
@@ -191,8 +205,11 @@ func (q *TxQueue) submitBatch(ctx context.Context, items []*TxQueueItem) {
 	// Attempt multicall:
 	err := q.tryMulticall(ctx, items, allCalls)
 	if err == nil {
+		metrics.TxQueueSubmissions.WithLabelValues("multicall", "success").Inc()
 		return
 	} else {
+		metrics.TxQueueSubmissions.WithLabelValues("multicall", "failure").Inc()
+
 		if isMaxFeeExceedsBalance(err) {
 			slog.Error("insufficient balance for multicall, returning items to queue", "error", err)
 			q.itemsMu.Lock()
@@ -311,6 +328,7 @@ func (q *TxQueue) tryMulticall(ctx context.Context, items []*TxQueueItem, allCal
 	q.nonce = q.nonce.Add(q.nonce, new(felt.Felt).SetUint64(1))
 
 	slog.Info("multicall broadcast successful", "tx_hash", resp.TransactionHash)
+	q.observeFees(ctx, invokeTxn.MaxFee, resp.TransactionHash)
 	q.notifyAll(items, resp.TransactionHash, nil)
 	return nil
 }
@@ -326,16 +344,21 @@ func (q *TxQueue) submitSingle(ctx context.Context, item *TxQueueItem) {
 
 	invokeTxn, err := q.buildTx(ctx, item.FunctionCalls)
 	if err != nil {
+		metrics.TxQueueSubmissions.WithLabelValues("single", "failure").Inc()
 		q.notifySingle(item, nil, err)
 		return
 	}
 
 	resp, err := q.addInvokeTransaction(ctx, acc, invokeTxn)
 	if err != nil {
+		metrics.TxQueueSubmissions.WithLabelValues("single", "failure").Inc()
 		q.notifySingle(item, nil, err)
 		return
 	}
 
+	metrics.TxQueueSubmissions.WithLabelValues("single", "success").Inc()
+	q.observeFees(ctx, invokeTxn.MaxFee, resp.TransactionHash)
+
 	// Increment nonce after successful broadcast
 	q.nonce = q.nonce.Add(q.nonce, new(felt.Felt).SetUint64(1))
 
@@ -371,6 +394,58 @@ func (q *TxQueue) simulateBatch(ctx context.Context, calls []rpc.FunctionCall) e
 	return nil
 }
 
+// observeFees records the max fee of a broadcast transaction, and the fee it
+// actually paid once its receipt is available.
+func (q *TxQueue) observeFees(ctx context.Context, maxFee, txHash *felt.Felt) {
+	metrics.TxQueueMaxFee.Add(feltToFloat(maxFee))
+
+	go q.observeActualFee(ctx, txHash)
+}
+
+// observeActualFee polls the receipt of a transaction until it is available or
+// the receipt timeout passes, and records the fee it paid.
+func (q *TxQueue) observeActualFee(ctx context.Context, txHash *felt.Felt) {
+	ticker := time.NewTicker(q.cfg.ReceiptPollInterval)
+	defer ticker.Stop()
+
+	timeout := time.After(q.cfg.ReceiptTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			slog.Warn("timed out waiting for transaction receipt, fee not recorded", "tx_hash", txHash)
+			return
+		case <-ticker.C:
+		}
+
+		var receipt *rpc.TransactionReceiptWithBlockInfo
+		err := q.client.Do(func(provider rpc.RpcProvider) error {
+			var err error
+			receipt, err = provider.TransactionReceipt(ctx, txHash)
+			return err
+		})
+		if err != nil {
+			slog.Debug("transaction receipt not available yet", "tx_hash", txHash, "error", FormatRpcError(err))
+			continue
+		}
+
+		if receipt.ActualFee.Amount == nil {
+			slog.Warn("transaction receipt has no actual fee", "tx_hash", txHash)
+			return
+		}
+
+		metrics.TxQueueActualFee.WithLabelValues(string(receipt.ActualFee.Unit)).Add(feltToFloat(receipt.ActualFee.Amount))
+		return
+	}
+}
+
+func feltToFloat(f *felt.Felt) float64 {
+	v, _ := new(big.Float).SetInt(f.BigInt(new(big.Int))).Float64()
+	return v
+}
+
 // notifyAll notifies all queued items in this batch with a single transaction result.
 func (q *TxQueue) notifyAll(items []*TxQueueItem, txHash *felt.Felt, err error) {
 	for _, item := range items {
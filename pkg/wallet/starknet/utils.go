@@ -63,3 +63,27 @@ func ByteArrFeltToString(arr []*felt.Felt) (string, error) {
 
 	return strings.Join(res, ""), nil
 }
+
+// FeltToShortString decodes a Cairo short string (felt252 literal) into a Go string.
+func FeltToShortString(f *felt.Felt) string {
+	if f == nil {
+		return ""
+	}
+
+	b := f.Bytes()
+	start := 0
+	for start < len(b) && b[start] == 0 {
+		start++
+	}
+
+	return string(b[start:])
+}
+
+// ShortStringToFelt encodes a Cairo short string (at most 31 ASCII characters) as a felt.
+func ShortStringToFelt(s string) (*felt.Felt, error) {
+	if len(s) > 31 {
+		return nil, fmt.Errorf("short string too long: %d characters", len(s))
+	}
+
+	return new(felt.Felt).SetBytes([]byte(s)), nil
+}